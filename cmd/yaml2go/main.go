@@ -0,0 +1,60 @@
+// Command yaml2go generates Go struct definitions from a sample YAML document.
+//
+// Usage:
+//
+//	yaml2go [-package name] [-type name] [-o output.go] [input.yaml]
+//
+// When no input file is given the document is read from standard input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elioetibr/golang-yaml/pkg/generator"
+)
+
+func main() {
+	opts := generator.DefaultOptions()
+
+	flag.StringVar(&opts.PackageName, "package", opts.PackageName, "package name of the generated file")
+	flag.StringVar(&opts.RootName, "type", opts.RootName, "name of the root struct")
+	noComments := flag.Bool("no-comments", false, "do not turn YAML comments into doc comments")
+	noOmitEmpty := flag.Bool("no-omitempty", false, "do not add omitempty to optional fields")
+	output := flag.String("o", "", "output file (default: standard output)")
+	flag.Parse()
+
+	opts.EmitComments = !*noComments
+	opts.OmitEmpty = !*noOmitEmpty
+
+	if err := run(flag.Arg(0), *output, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "yaml2go: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, output string, opts *generator.Options) error {
+	var data []byte
+	var err error
+	if input == "" || input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return err
+	}
+
+	code, err := generator.GenerateFromString(string(data), opts)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(output, code, 0644)
+}
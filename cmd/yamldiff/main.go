@@ -0,0 +1,78 @@
+// Command yamldiff reports structural differences between two YAML documents.
+//
+// Usage:
+//
+//	yamldiff [-json] [-color] [-key name,id] [-ignore-order] old.yaml new.yaml
+//
+// The exit status is 0 when the documents are equal, 1 when they differ and
+// 2 on errors, so it can gate configuration drift in CI.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/diff"
+	"github.com/elioetibr/golang-yaml/pkg/merge"
+)
+
+func main() {
+	opts := diff.DefaultOptions()
+
+	asJSON := flag.Bool("json", false, "write the changes as JSON")
+	color := flag.Bool("color", false, "color the text report")
+	keys := flag.String("key", "", "match sequence items by these comma-separated key fields")
+	replace := flag.Bool("replace-arrays", false, "compare sequences as a whole")
+	flag.BoolVar(&opts.IgnoreKeyOrder, "ignore-order", false, "do not report reordered mapping keys")
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: yamldiff [flags] old.yaml new.yaml")
+		os.Exit(2)
+	}
+
+	switch {
+	case *keys != "":
+		opts.ArrayMergeStrategy = merge.ArrayMergeByKey
+		opts.KeyFields = strings.Split(*keys, ",")
+	case *replace:
+		opts.ArrayMergeStrategy = merge.ArrayReplace
+	}
+
+	changes, err := run(flag.Arg(0), flag.Arg(1), opts, *asJSON, *color)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "yamldiff: %v\n", err)
+		os.Exit(2)
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(oldFile, newFile string, opts *diff.Options, asJSON, color bool) ([]*diff.Change, error) {
+	oldData, err := os.ReadFile(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := os.ReadFile(newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := diff.DiffStrings(string(oldData), string(newData), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if asJSON {
+		data, err := diff.FormatJSON(changes)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fmt.Println(string(data))
+		return changes, err
+	}
+	return changes, diff.WriteText(os.Stdout, changes, color)
+}
@@ -0,0 +1,233 @@
+// Package charset detects and converts the character encodings of a YAML
+// stream: UTF-8, UTF-16 and UTF-32 in either byte order (YAML 1.2.2 spec
+// section 5.2).
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a Unicode encoding form
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	UTF16LE
+	UTF16BE
+	UTF32LE
+	UTF32BE
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case UTF8:
+		return "UTF-8"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	case UTF32LE:
+		return "UTF-32LE"
+	case UTF32BE:
+		return "UTF-32BE"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+}
+
+// unitSize returns the size in bytes of a code unit
+func (e Encoding) unitSize() int {
+	switch e {
+	case UTF16LE, UTF16BE:
+		return 2
+	case UTF32LE, UTF32BE:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// byteOrder reads and appends code units in the byte order of an encoding
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+func (e Encoding) byteOrder() byteOrder {
+	if e == UTF16BE || e == UTF32BE {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// Detect returns the encoding of a stream starting with prefix, from its
+// byte order mark or, without one, from the null bytes around its first
+// character, which the spec requires to be ASCII. It also returns the size
+// of the byte order mark, 0 when there is none. Up to four bytes are
+// examined.
+func Detect(prefix []byte) (Encoding, int) {
+	b := prefix
+	switch {
+	case bytes.HasPrefix(b, []byte{0x00, 0x00, 0xFE, 0xFF}):
+		return UTF32BE, 4
+	case len(b) >= 4 && b[0] == 0 && b[1] == 0 && b[2] == 0:
+		return UTF32BE, 0
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE, 0x00, 0x00}):
+		return UTF32LE, 4
+	case len(b) >= 4 && b[1] == 0 && b[2] == 0 && b[3] == 0:
+		return UTF32LE, 0
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return UTF16BE, 2
+	case len(b) >= 2 && b[0] == 0:
+		return UTF16BE, 0
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return UTF16LE, 2
+	case len(b) >= 2 && b[1] == 0:
+		return UTF16LE, 0
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return UTF8, 3
+	default:
+		return UTF8, 0
+	}
+}
+
+// NewReader returns a reader of r transcoded to UTF-8. The encoding is
+// detected from the first bytes read. A byte order mark is kept, as the
+// UTF-8 encoding of U+FEFF, so offsets into UTF-8 input stay unchanged.
+// Invalid code units are replaced by U+FFFD.
+func NewReader(r io.Reader) io.Reader {
+	return &reader{r: bufio.NewReader(r)}
+}
+
+type reader struct {
+	r        *bufio.Reader
+	enc      Encoding
+	detected bool
+	pending  []byte // UTF-8 of a character that did not fit the last read
+}
+
+func (d *reader) Read(p []byte) (int, error) {
+	if !d.detected {
+		prefix, _ := d.r.Peek(4)
+		d.enc, _ = Detect(prefix)
+		d.detected = true
+	}
+	if d.enc == UTF8 {
+		return d.r.Read(p)
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	for n < len(p) {
+		// Return what is decoded rather than wait on a slow reader
+		if n > 0 && d.r.Buffered() < d.enc.unitSize() {
+			break
+		}
+		r, err := d.readRune()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+
+		var buf [utf8.UTFMax]byte
+		size := utf8.EncodeRune(buf[:], r)
+		copied := copy(p[n:], buf[:size])
+		n += copied
+		if copied < size {
+			d.pending = append(d.pending[:0], buf[copied:size]...)
+		}
+	}
+	return n, nil
+}
+
+// readRune decodes the next character, replacing an invalid or truncated
+// one with U+FFFD
+func (d *reader) readRune() (rune, error) {
+	unit, err := d.readUnit()
+	if err != nil {
+		return 0, err
+	}
+
+	if d.enc.unitSize() == 4 {
+		r := rune(unit)
+		if unit > utf8.MaxRune || utf16.IsSurrogate(r) {
+			return utf8.RuneError, nil
+		}
+		return r, nil
+	}
+
+	r := rune(unit)
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	// Take the low half of a surrogate pair only if it follows
+	next, err := d.r.Peek(2)
+	if err == nil {
+		if r2 := rune(d.enc.byteOrder().Uint16(next)); utf16.DecodeRune(r, r2) != utf8.RuneError {
+			_, _ = d.r.Discard(2)
+			return utf16.DecodeRune(r, r2), nil
+		}
+	}
+	return utf8.RuneError, nil
+}
+
+// readUnit reads one code unit
+func (d *reader) readUnit() (uint32, error) {
+	var buf [4]byte
+	size := d.enc.unitSize()
+	n, err := io.ReadFull(d.r, buf[:size])
+	switch {
+	case err == io.ErrUnexpectedEOF:
+		return utf8.RuneError, nil
+	case err != nil:
+		return 0, err
+	case size == 4:
+		return d.enc.byteOrder().Uint32(buf[:n]), nil
+	default:
+		return uint32(d.enc.byteOrder().Uint16(buf[:n])), nil
+	}
+}
+
+// Decode returns data transcoded from its detected encoding to UTF-8. UTF-8
+// data is returned as is.
+func Decode(data []byte) []byte {
+	if enc, _ := Detect(data[:min(len(data), 4)]); enc == UTF8 {
+		return data
+	}
+	decoded, _ := io.ReadAll(NewReader(bytes.NewReader(data)))
+	return decoded
+}
+
+// Encode returns the UTF-8 text s in the encoding enc, preceded by a byte
+// order mark when bom is set
+func Encode(s string, enc Encoding, bom bool) []byte {
+	if bom {
+		s = "\uFEFF" + s
+	}
+
+	order := enc.byteOrder()
+	switch enc.unitSize() {
+	case 2:
+		out := make([]byte, 0, 2*len(s))
+		for _, unit := range utf16.Encode([]rune(s)) {
+			out = order.AppendUint16(out, unit)
+		}
+		return out
+	case 4:
+		out := make([]byte, 0, 4*len(s))
+		for _, r := range s {
+			out = order.AppendUint32(out, uint32(r))
+		}
+		return out
+	default:
+		return []byte(s)
+	}
+}
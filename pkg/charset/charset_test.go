@@ -0,0 +1,104 @@
+package charset
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix []byte
+		enc    Encoding
+		bom    int
+	}{
+		{"UTF-32BE BOM", []byte{0x00, 0x00, 0xFE, 0xFF}, UTF32BE, 4},
+		{"UTF-32BE", []byte{0x00, 0x00, 0x00, 'a'}, UTF32BE, 0},
+		{"UTF-32LE BOM", []byte{0xFF, 0xFE, 0x00, 0x00}, UTF32LE, 4},
+		{"UTF-32LE", []byte{'a', 0x00, 0x00, 0x00}, UTF32LE, 0},
+		{"UTF-16BE BOM", []byte{0xFE, 0xFF, 0x00, 'a'}, UTF16BE, 2},
+		{"UTF-16BE", []byte{0x00, 'a', 0x00, ':'}, UTF16BE, 0},
+		{"UTF-16LE BOM", []byte{0xFF, 0xFE, 'a', 0x00}, UTF16LE, 2},
+		{"UTF-16LE", []byte{'a', 0x00, ':', 0x00}, UTF16LE, 0},
+		{"UTF-8 BOM", []byte{0xEF, 0xBB, 0xBF, 'a'}, UTF8, 3},
+		{"UTF-8", []byte("a: 1"), UTF8, 0},
+		{"short", []byte("a"), UTF8, 0},
+		{"empty", nil, UTF8, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, bom := Detect(tt.prefix)
+			if enc != tt.enc || bom != tt.bom {
+				t.Errorf("Expected %v with a %d byte BOM, got %v with %d", tt.enc, tt.bom, enc, bom)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	text := "key: välue\nemoji: 😀\n"
+	for _, enc := range []Encoding{UTF8, UTF16LE, UTF16BE, UTF32LE, UTF32BE} {
+		for _, bom := range []bool{false, true} {
+			want := text
+			if bom {
+				want = "\uFEFF" + text
+			}
+			data := Encode(text, enc, bom)
+
+			if got := string(Decode(data)); got != want {
+				t.Errorf("%v (BOM %v): Decode gave %q", enc, bom, got)
+			}
+			got, err := io.ReadAll(NewReader(iotest.OneByteReader(bytes.NewReader(data))))
+			if err != nil {
+				t.Fatalf("%v (BOM %v): read error: %v", enc, bom, err)
+			}
+			if string(got) != want {
+				t.Errorf("%v (BOM %v): NewReader gave %q", enc, bom, got)
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		enc  Encoding
+		bom  bool
+		want []byte
+	}{
+		{UTF8, true, []byte{0xEF, 0xBB, 0xBF, 'a'}},
+		{UTF16LE, true, []byte{0xFF, 0xFE, 'a', 0x00}},
+		{UTF16BE, false, []byte{0x00, 'a'}},
+		{UTF32LE, false, []byte{'a', 0x00, 0x00, 0x00}},
+		{UTF32BE, true, []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 'a'}},
+	}
+
+	for _, tt := range tests {
+		if got := Encode("a", tt.enc, tt.bom); !bytes.Equal(got, tt.want) {
+			t.Errorf("%v (BOM %v): expected % x, got % x", tt.enc, tt.bom, tt.want, got)
+		}
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"lone high surrogate", []byte{0xFF, 0xFE, 0x3D, 0xD8, 'a', 0x00}, "\uFEFF\uFFFDa"},
+		{"lone low surrogate", []byte{0xFE, 0xFF, 0xDE, 0x00, 0x00, 'a'}, "\uFEFF\uFFFDa"},
+		{"truncated unit", []byte{'a', 0x00, 'b'}, "a\uFFFD"},
+		{"out of range", []byte{0xFF, 0xFE, 0x00, 0x00, 0x00, 0x00, 0x11, 0x00}, "\uFEFF\uFFFD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Decode(tt.data)); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
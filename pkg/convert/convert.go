@@ -0,0 +1,266 @@
+// Package convert translates between YAML node trees and JSON documents
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+// ToJSON converts a YAML node to compact JSON.
+// Mapping key order is preserved and scalars are emitted according to their resolved tag.
+func ToJSON(n node.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	c := &jsonWriter{buf: &buf, resolver: parser.NewTagResolver()}
+	if err := c.writeNode(n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ToJSONIndent is like ToJSON but indents the output
+func ToJSONIndent(n node.Node, prefix, indent string) ([]byte, error) {
+	data, err := ToJSON(n)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FromJSON parses a JSON document into a YAML node, preserving object key order
+func FromJSON(data []byte) (node.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	n, err := readValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return n, nil
+}
+
+// YAMLToJSON converts a YAML document to JSON
+func YAMLToJSON(data []byte) ([]byte, error) {
+	n, err := parser.ParseString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return ToJSON(n)
+}
+
+// JSONToYAML converts a JSON document to block-style YAML
+func JSONToYAML(data []byte) ([]byte, error) {
+	n, err := FromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := serializer.SerializeToString(n, serializer.DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
+// jsonWriter writes YAML nodes as JSON
+type jsonWriter struct {
+	buf      *bytes.Buffer
+	resolver *parser.TagResolver
+}
+
+func (c *jsonWriter) writeNode(n node.Node) error {
+	switch v := node.Resolve(n).(type) {
+	case nil:
+		c.buf.WriteString("null")
+	case *node.AliasNode:
+		return fmt.Errorf("unresolved alias *%s", v.Name)
+	case *node.ScalarNode:
+		return c.writeScalar(v)
+	case *node.SequenceNode:
+		c.buf.WriteByte('[')
+		for i, item := range v.Items {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			if err := c.writeNode(item); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte(']')
+	case *node.MappingNode:
+		c.buf.WriteByte('{')
+		for i, pair := range node.MergedPairs(v) {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			key, ok := pair.Key.(*node.ScalarNode)
+			if !ok {
+				return fmt.Errorf("line %d: JSON object keys must be scalars", pair.Key.Line())
+			}
+			c.writeString(key.Value)
+			c.buf.WriteByte(':')
+			if err := c.writeNode(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported node type: %T", n)
+	}
+	return nil
+}
+
+// writeScalar emits a scalar as a JSON string, number, boolean or null
+func (c *jsonWriter) writeScalar(n *node.ScalarNode) error {
+	tag := ScalarTag(n)
+
+	switch tag {
+	case parser.CommonTags.Null:
+		c.buf.WriteString("null")
+		return nil
+	case parser.CommonTags.Bool:
+		b, err := c.resolver.ProcessTaggedValue(tag, n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line(), err)
+		}
+		c.buf.WriteString(strconv.FormatBool(b.(bool)))
+		return nil
+	case parser.CommonTags.Int:
+		if isJSONNumber(n.Value) {
+			c.buf.WriteString(n.Value)
+			return nil
+		}
+		i, err := c.resolver.ProcessTaggedValue(tag, n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid integer %q", n.Line(), n.Value)
+		}
+		c.buf.WriteString(strconv.FormatInt(i.(int64), 10))
+		return nil
+	case parser.CommonTags.Float:
+		if isJSONNumber(n.Value) {
+			c.buf.WriteString(n.Value)
+			return nil
+		}
+		f, err := c.resolver.ProcessTaggedValue(tag, n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid float %q", n.Line(), n.Value)
+		}
+		// JSON has no representation for infinities and NaN
+		if math.IsInf(f.(float64), 0) || math.IsNaN(f.(float64)) {
+			c.writeString(n.Value)
+			return nil
+		}
+		c.buf.WriteString(strconv.FormatFloat(f.(float64), 'g', -1, 64))
+		return nil
+	default:
+		c.writeString(n.Value)
+		return nil
+	}
+}
+
+// isJSONNumber reports whether s is already a valid JSON number literal
+func isJSONNumber(s string) bool {
+	var num json.Number
+	return json.Unmarshal([]byte(s), &num) == nil && s != "" && s[0] != '"'
+}
+
+func (c *jsonWriter) writeString(s string) {
+	data, _ := json.Marshal(s)
+	c.buf.Write(data)
+}
+
+// ScalarTag returns the resolved core schema tag of a scalar.
+// Explicit tags win; quoted and block scalars are strings; plain scalars are inferred.
+func ScalarTag(n *node.ScalarNode) string {
+	if tag := n.Tag(); tag != "" {
+		if strings.HasPrefix(tag, "tag:yaml.org,2002:") {
+			return "!!" + strings.TrimPrefix(tag, "tag:yaml.org,2002:")
+		}
+		return tag
+	}
+	if n.Style != node.StylePlain && n.Style != node.StyleAny {
+		return parser.CommonTags.Str
+	}
+	return parser.InferTag(n.Value)
+}
+
+// readValue reads a single JSON value from the decoder as a node
+func readValue(dec *json.Decoder) (node.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			mapping := &node.MappingNode{Style: node.StyleBlock}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				mapping.Pairs = append(mapping.Pairs, &node.MappingPair{
+					Key:   stringNode(keyTok.(string)),
+					Value: value,
+				})
+			}
+			_, err := dec.Token() // consume '}'
+			return mapping, err
+		case '[':
+			seq := &node.SequenceNode{Style: node.StyleBlock}
+			for dec.More() {
+				item, err := readValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				seq.Items = append(seq.Items, item)
+			}
+			_, err := dec.Token() // consume ']'
+			return seq, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", v)
+	case string:
+		return stringNode(v), nil
+	case json.Number:
+		return &node.ScalarNode{Value: v.String(), Style: node.StylePlain}, nil
+	case bool:
+		return &node.ScalarNode{Value: strconv.FormatBool(v), Style: node.StylePlain}, nil
+	case nil:
+		return &node.ScalarNode{Value: "null", Style: node.StylePlain}, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %v", tok)
+	}
+}
+
+// stringNode builds a scalar for a JSON string, quoting it when a plain
+// scalar would resolve to a different type
+func stringNode(s string) *node.ScalarNode {
+	style := node.StylePlain
+	if parser.InferTag(s) != parser.CommonTags.Str {
+		style = node.StyleDoubleQuoted
+	}
+	return &node.ScalarNode{Value: s, Style: style}
+}
@@ -0,0 +1,100 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "preserves key order",
+			input:    "zeta: 1\nalpha: 2\nmid: 3",
+			expected: `{"zeta":1,"alpha":2,"mid":3}`,
+		},
+		{
+			name:     "resolves scalar types",
+			input:    "s: hello\ni: 42\nf: 3.50\nb: yes\nn: ~\nq: \"42\"\nhex: !!int 0x1F",
+			expected: `{"s":"hello","i":42,"f":3.50,"b":true,"n":null,"q":"42","hex":31}`,
+		},
+		{
+			name:     "nested collections",
+			input:    "items:\n  - name: a\n    tags: [x, y]\n  - name: b",
+			expected: `{"items":[{"name":"a","tags":["x","y"]},{"name":"b"}]}`,
+		},
+		{
+			name:     "non finite floats become strings",
+			input:    "limit: !!float .inf",
+			expected: `{"limit":".inf"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parser.ParseString(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			data, err := ToJSON(root)
+			if err != nil {
+				t.Fatalf("ToJSON error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	input := `{"name": "app", "replicas": 3, "debug": false, "version": "1.0", "enabled": "true", "tags": ["a", "b"], "owner": null}`
+
+	data, err := JSONToYAML([]byte(input))
+	if err != nil {
+		t.Fatalf("JSONToYAML error: %v", err)
+	}
+
+	expected := `name: app
+replicas: 3
+debug: false
+version: "1.0"
+enabled: "true"
+tags:
+  - a
+  - b
+owner: null`
+
+	if result := strings.TrimSpace(string(data)); result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := `{"b":{"y":[1,2.5,"3"],"x":true},"a":"text"}`
+
+	n, err := FromJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("FromJSON error: %v", err)
+	}
+
+	data, err := ToJSON(n)
+	if err != nil {
+		t.Fatalf("ToJSON error: %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("Expected %s, got %s", input, data)
+	}
+}
+
+func TestFromJSONTrailingData(t *testing.T) {
+	if _, err := FromJSON([]byte(`{"a":1} {"b":2}`)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
@@ -8,34 +8,98 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/elioetibr/golang-yaml/pkg/errors"
 	"github.com/elioetibr/golang-yaml/pkg/node"
 	"github.com/elioetibr/golang-yaml/pkg/parser"
 )
 
+// Options configures the decoding behavior
+type Options struct {
+	// JSONTagFallback uses `json` struct tags for fields without a `yaml` tag
+	JSONTagFallback bool
+
+	// Parser configures how Unmarshal parses its input. Aliases are always
+	// kept during parsing and resolved while decoding, within the alias,
+	// node and depth limits of these options. Merge keys are resolved
+	// unless DisableMergeKeys is set, and the handlers of TagResolver
+	// decode tagged scalars into interface values. Nil uses
+	// parser.DefaultOptions.
+	Parser *parser.Options
+}
+
+// DefaultOptions returns the default decoding options
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
 // Unmarshal parses the YAML-encoded data and stores the result
 // in the value pointed to by v
 func Unmarshal(data []byte, v interface{}) error {
-	n, err := parser.ParseString(string(data))
+	return UnmarshalWithOptions(data, v, DefaultOptions())
+}
+
+// UnmarshalWithOptions is like Unmarshal but with custom decoding options
+func UnmarshalWithOptions(data []byte, v interface{}, opts *Options) error {
+	d := newDecodeState(opts)
+
+	// Aliases and merge keys are resolved while decoding, and tags with
+	// the %TAG handles of their document for the handlers to find
+	parseOpts := *d.parserOpts
+	parseOpts.PreserveAliases = true
+	parseOpts.ResolveTags = true
+	n, err := parser.ParseStringWithOptions(string(data), &parseOpts)
 	if err != nil {
 		return err
 	}
 
-	return nodeToValue(n, reflect.ValueOf(v))
+	return d.decode(n, reflect.ValueOf(v))
+}
+
+// UnmarshalAs parses the YAML-encoded data into a new value of type T
+func UnmarshalAs[T any](data []byte) (T, error) {
+	var v T
+	err := Unmarshal(data, &v)
+	return v, err
+}
+
+// DecodeNode stores an already parsed node in the value pointed to by v
+func DecodeNode(n node.Node, v interface{}) error {
+	return newDecodeState(nil).decode(n, reflect.ValueOf(v))
+}
+
+// Get decodes the subtree at path (see node.Lookup) into a new value of type
+// T. It belongs with the node helpers but lives here: decoding a node needs
+// this package, which imports node, so node.Get would be an import cycle.
+func Get[T any](root node.Node, path string) (T, error) {
+	var v T
+	n, err := node.Lookup(root, path)
+	if err != nil {
+		return v, err
+	}
+	err = DecodeNode(n, &v)
+	return v, err
 }
 
 // Decoder reads and decodes YAML values-with-comments from an input stream
 type Decoder struct {
-	reader io.Reader
-	buffer []byte
+	reader  io.Reader
+	buffer  []byte
+	options *Options
 }
 
 // NewDecoder returns a new decoder that reads from r
 func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{
-		reader: r,
+		reader:  r,
+		options: DefaultOptions(),
 	}
 }
 
+// SetOptions sets the decoding options
+func (d *Decoder) SetOptions(opts *Options) {
+	d.options = opts
+}
+
 // Decode reads the next YAML-encoded value from its input
 // and stores it in the value pointed to by v
 func (d *Decoder) Decode(v interface{}) error {
@@ -47,15 +111,107 @@ func (d *Decoder) Decode(v interface{}) error {
 		d.buffer = data
 	}
 
-	return Unmarshal(d.buffer, v)
+	return UnmarshalWithOptions(d.buffer, v, d.options)
+}
+
+// decodeState carries the options for a single decoding pass
+type decodeState struct {
+	options    *Options
+	parserOpts *parser.Options
+	resolver   *parser.TagResolver
+
+	// Work done so far, bounded by the limits in parserOpts
+	aliases  int
+	nodes    int
+	depth    int
+	limitErr error
+}
+
+// newDecodeState creates a decode state, falling back to the default options
+func newDecodeState(opts *Options) *decodeState {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	parserOpts := opts.Parser
+	if parserOpts == nil {
+		parserOpts = parser.DefaultOptions()
+	}
+	resolver := parserOpts.TagResolver
+	if resolver == nil {
+		resolver = parser.NewTagResolver()
+	}
+	return &decodeState{options: opts, parserOpts: parserOpts, resolver: resolver}
+}
+
+// pairs returns the pairs of a mapping to decode, with merge keys applied
+// unless the parser options disable them
+func (d *decodeState) pairs(n *node.MappingNode) []*node.MappingPair {
+	if d.parserOpts.DisableMergeKeys {
+		return n.Pairs
+	}
+	return node.MergedPairs(n)
+}
+
+// decode converts n into v. An exceeded limit fails the whole pass, even
+// where errors of single values are otherwise skipped.
+func (d *decodeState) decode(n node.Node, v reflect.Value) error {
+	err := d.nodeToValue(n, v)
+	if d.limitErr != nil {
+		return d.limitErr
+	}
+	return err
+}
+
+// enter counts a node about to be decoded. Aliases are resolved each time
+// they are decoded, so a small tree can stand for a huge value; the limits
+// of the parser options stop that before it exhausts memory.
+func (d *decodeState) enter(n node.Node) error {
+	if d.limitErr != nil {
+		return d.limitErr
+	}
+	d.nodes++
+	d.depth++
+
+	var msg string
+	_, isAlias := n.(*node.AliasNode)
+	if isAlias {
+		d.aliases++
+	}
+	switch {
+	case isAlias && d.parserOpts.MaxAliasExpansions > 0 && d.aliases > d.parserOpts.MaxAliasExpansions:
+		msg = fmt.Sprintf("alias *%s exceeds the maximum of %d alias expansions", n.(*node.AliasNode).Name, d.parserOpts.MaxAliasExpansions)
+	case d.parserOpts.MaxNodes > 0 && d.nodes > d.parserOpts.MaxNodes:
+		msg = fmt.Sprintf("document exceeds the maximum of %d nodes", d.parserOpts.MaxNodes)
+	case d.parserOpts.MaxDepth > 0 && d.depth > d.parserOpts.MaxDepth:
+		msg = fmt.Sprintf("document exceeds the maximum nesting depth of %d", d.parserOpts.MaxDepth)
+	default:
+		return nil
+	}
+
+	start := node.RangeOf(n).Start
+	d.limitErr = errors.New(msg, errors.Position{Line: n.Line(), Column: n.Column(), Offset: start.Offset}, errors.ErrorTypeDecoder)
+	return d.limitErr
 }
 
 // nodeToValue converts a YAML node to a Go value
-func nodeToValue(n node.Node, v reflect.Value) error {
+func (d *decodeState) nodeToValue(n node.Node, v reflect.Value) error {
 	if !v.IsValid() {
 		return fmt.Errorf("invalid value")
 	}
 
+	if n != nil {
+		if err := d.enter(n); err != nil {
+			return err
+		}
+		defer func() { d.depth-- }()
+	}
+
+	// Documents decode as their content and aliases as their targets
+	n = node.Resolve(n)
+	if alias, ok := n.(*node.AliasNode); ok {
+		return fmt.Errorf("unresolved alias *%s", alias.Name)
+	}
+
 	// Handle nil node (empty document or only comments)
 	if n == nil {
 		// For empty documents, set to zero value of the target type
@@ -88,28 +244,28 @@ func nodeToValue(n node.Node, v reflect.Value) error {
 		if v.IsNil() {
 			v.Set(reflect.New(v.Type().Elem()))
 		}
-		return nodeToValue(n, v.Elem())
+		return d.nodeToValue(n, v.Elem())
 	}
 
 	// Handle interfaces
 	if v.Kind() == reflect.Interface && !v.IsNil() {
-		return nodeToValue(n, v.Elem())
+		return d.nodeToValue(n, v.Elem())
 	}
 
 	switch node := n.(type) {
 	case *node.ScalarNode:
-		return scalarToValue(node, v)
+		return d.scalarToValue(node, v)
 	case *node.SequenceNode:
-		return sequenceToValue(node, v)
+		return d.sequenceToValue(node, v)
 	case *node.MappingNode:
-		return mappingToValue(node, v)
+		return d.mappingToValue(node, v)
 	default:
 		return fmt.Errorf("unknown node type: %T", n)
 	}
 }
 
 // scalarToValue converts a scalar node to a Go value
-func scalarToValue(n *node.ScalarNode, v reflect.Value) error {
+func (d *decodeState) scalarToValue(n *node.ScalarNode, v reflect.Value) error {
 	// Check if the value is valid and can be set
 	if !v.IsValid() {
 		return fmt.Errorf("cannot set value on invalid reflect.Value")
@@ -154,6 +310,22 @@ func scalarToValue(n *node.ScalarNode, v reflect.Value) error {
 		v.SetFloat(f)
 
 	case reflect.Interface:
+		// Tagged values go to the handler of their tag
+		if tag := n.Tag(); tag != "" {
+			if handler, ok := d.resolver.Handler(tag); ok {
+				parsed, err := handler(value)
+				if err != nil {
+					return err
+				}
+				if parsed == nil {
+					v.Set(reflect.Zero(v.Type()))
+				} else {
+					v.Set(reflect.ValueOf(parsed))
+				}
+				return nil
+			}
+		}
+
 		// Try to parse the value as appropriate type
 		parsed := parser.ParseValue(value)
 		if parsed != nil {
@@ -171,13 +343,13 @@ func scalarToValue(n *node.ScalarNode, v reflect.Value) error {
 }
 
 // sequenceToValue converts a sequence node to a Go value
-func sequenceToValue(n *node.SequenceNode, v reflect.Value) error {
+func (d *decodeState) sequenceToValue(n *node.SequenceNode, v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.Slice:
 		// Create a new slice with appropriate capacity
 		slice := reflect.MakeSlice(v.Type(), len(n.Items), len(n.Items))
 		for i, item := range n.Items {
-			if err := nodeToValue(item, slice.Index(i)); err != nil {
+			if err := d.nodeToValue(item, slice.Index(i)); err != nil {
 				return err
 			}
 		}
@@ -186,7 +358,7 @@ func sequenceToValue(n *node.SequenceNode, v reflect.Value) error {
 	case reflect.Array:
 		// Fill array elements
 		for i := 0; i < len(n.Items) && i < v.Len(); i++ {
-			if err := nodeToValue(n.Items[i], v.Index(i)); err != nil {
+			if err := d.nodeToValue(n.Items[i], v.Index(i)); err != nil {
 				return err
 			}
 		}
@@ -196,7 +368,7 @@ func sequenceToValue(n *node.SequenceNode, v reflect.Value) error {
 		slice := make([]interface{}, len(n.Items))
 		for i, item := range n.Items {
 			var val interface{}
-			if err := nodeToValue(item, reflect.ValueOf(&val).Elem()); err != nil {
+			if err := d.nodeToValue(item, reflect.ValueOf(&val).Elem()); err != nil {
 				return err
 			}
 			slice[i] = val
@@ -211,17 +383,17 @@ func sequenceToValue(n *node.SequenceNode, v reflect.Value) error {
 }
 
 // mappingToValue converts a mapping node to a Go value
-func mappingToValue(n *node.MappingNode, v reflect.Value) error {
+func (d *decodeState) mappingToValue(n *node.MappingNode, v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.Map:
-		return mappingToMap(n, v)
+		return d.mappingToMap(n, v)
 	case reflect.Struct:
-		return mappingToStruct(n, v)
+		return d.mappingToStruct(n, v)
 	case reflect.Interface:
 		// Create a map[string]interface{}
 		m := make(map[string]interface{})
 		mapVal := reflect.ValueOf(m)
-		if err := mappingToMap(n, mapVal); err != nil {
+		if err := d.mappingToMap(n, mapVal); err != nil {
 			return err
 		}
 		v.Set(mapVal)
@@ -233,7 +405,7 @@ func mappingToValue(n *node.MappingNode, v reflect.Value) error {
 }
 
 // mappingToMap converts a mapping node to a Go map
-func mappingToMap(n *node.MappingNode, v reflect.Value) error {
+func (d *decodeState) mappingToMap(n *node.MappingNode, v reflect.Value) error {
 	// Check if the value is valid
 	if !v.IsValid() {
 		return fmt.Errorf("invalid map value")
@@ -244,7 +416,7 @@ func mappingToMap(n *node.MappingNode, v reflect.Value) error {
 		v.Set(reflect.MakeMap(v.Type()))
 	}
 
-	for _, pair := range n.Pairs {
+	for _, pair := range d.pairs(n) {
 		// Get key as string (most common case)
 		keyStr := ""
 		if scalar, ok := pair.Key.(*node.ScalarNode); ok {
@@ -268,7 +440,7 @@ func mappingToMap(n *node.MappingNode, v reflect.Value) error {
 			keyVal.SetString(keyStr)
 		} else {
 			if keyVal.CanSet() {
-				if err := scalarToValue(&node.ScalarNode{Value: keyStr}, keyVal); err != nil {
+				if err := d.scalarToValue(&node.ScalarNode{Value: keyStr}, keyVal); err != nil {
 					// Skip this key if we can't set it
 					continue
 				}
@@ -276,12 +448,12 @@ func mappingToMap(n *node.MappingNode, v reflect.Value) error {
 		}
 
 		// Set the value
-		if err := nodeToValue(pair.Value, valVal); err != nil {
+		if err := d.nodeToValue(pair.Value, valVal); err != nil {
 			// If we can't set the value, try setting it as interface{}
 			if v.Type().Elem().Kind() == reflect.Interface {
 				var iface interface{}
 				ifaceVal := reflect.ValueOf(&iface).Elem()
-				if err := nodeToValue(pair.Value, ifaceVal); err == nil {
+				if err := d.nodeToValue(pair.Value, ifaceVal); err == nil {
 					valVal = ifaceVal
 				} else {
 					// Skip this pair if we can't convert the value
@@ -300,7 +472,7 @@ func mappingToMap(n *node.MappingNode, v reflect.Value) error {
 }
 
 // mappingToStruct converts a mapping node to a Go struct
-func mappingToStruct(n *node.MappingNode, v reflect.Value) error {
+func (d *decodeState) mappingToStruct(n *node.MappingNode, v reflect.Value) error {
 	t := v.Type()
 
 	// Build field map
@@ -311,15 +483,9 @@ func mappingToStruct(n *node.MappingNode, v reflect.Value) error {
 			continue
 		}
 
-		name := field.Name
-		if tag := field.Tag.Get("yaml"); tag != "" {
-			parts := strings.Split(tag, ",")
-			if parts[0] != "" {
-				name = parts[0]
-			}
-			if name == "-" {
-				continue
-			}
+		name := d.fieldName(field)
+		if name == "-" {
+			continue
 		}
 
 		// Store both lowercase and original
@@ -328,7 +494,8 @@ func mappingToStruct(n *node.MappingNode, v reflect.Value) error {
 	}
 
 	// Set fields from mapping
-	for _, pair := range n.Pairs {
+	seen := make(map[int]bool)
+	for _, pair := range d.pairs(n) {
 		// Get key as string
 		keyStr := ""
 		if scalar, ok := pair.Key.(*node.ScalarNode); ok {
@@ -350,15 +517,47 @@ func mappingToStruct(n *node.MappingNode, v reflect.Value) error {
 		// Set field value
 		fieldVal := v.Field(fieldIndex)
 		if fieldVal.CanSet() {
-			if err := nodeToValue(pair.Value, fieldVal); err != nil {
+			if err := d.nodeToValue(pair.Value, fieldVal); err != nil {
+				return err
+			}
+		}
+		seen[fieldIndex] = true
+
+		// Enforce validation tags against the decoded value, looking through aliases
+		field := t.Field(fieldIndex)
+		if tag := field.Tag.Get("validate"); tag != "" {
+			if err := validateField(keyStr, parseValidateTag(tag), fieldVal, node.Resolve(pair.Value)); err != nil {
 				return err
 			}
 		}
 	}
 
+	// Report required fields that are missing from the mapping
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if seen[i] || !field.IsExported() || d.fieldName(field) == "-" {
+			continue
+		}
+		if tag := field.Tag.Get("validate"); tag != "" && hasRule(parseValidateTag(tag), "required") {
+			return validationError(n, "missing required field %q", d.fieldName(field))
+		}
+	}
+
 	return nil
 }
 
+// fieldName returns the YAML key for a struct field
+func (d *decodeState) fieldName(field reflect.StructField) string {
+	tag, ok := field.Tag.Lookup("yaml")
+	if !ok && d.options.JSONTagFallback {
+		tag = field.Tag.Get("json")
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
 // parseBool parses a YAML boolean value
 func parseBool(s string) (bool, error) {
 	switch strings.ToLower(s) {
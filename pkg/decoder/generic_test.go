@@ -0,0 +1,218 @@
+package decoder_test
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/decoder"
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+func TestUnmarshalAs(t *testing.T) {
+	type Config struct {
+		Name     string `yaml:"name"`
+		Replicas int    `yaml:"replicas"`
+	}
+
+	cfg, err := decoder.UnmarshalAs[Config]([]byte("name: api\nreplicas: 3"))
+	if err != nil {
+		t.Fatalf("UnmarshalAs error: %v", err)
+	}
+	if cfg.Name != "api" || cfg.Replicas != 3 {
+		t.Errorf("Unexpected result: %+v", cfg)
+	}
+
+	ports, err := decoder.UnmarshalAs[[]int]([]byte("- 80\n- 443"))
+	if err != nil {
+		t.Fatalf("UnmarshalAs error: %v", err)
+	}
+	if len(ports) != 2 || ports[1] != 443 {
+		t.Errorf("Unexpected ports: %v", ports)
+	}
+}
+
+func TestGet(t *testing.T) {
+	root, err := parser.ParseString(`spec:
+  containers:
+    - name: web
+      image: nginx:1.25
+      ports: [80, 443]
+metadata:
+  labels:
+    "app.kubernetes.io/name": web`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	image, err := decoder.Get[string](root, "spec.containers[0].image")
+	if err != nil || image != "nginx:1.25" {
+		t.Errorf("Expected image nginx:1.25, got %q (%v)", image, err)
+	}
+
+	ports, err := decoder.Get[[]int](root, "spec.containers[0].ports")
+	if err != nil || len(ports) != 2 || ports[0] != 80 {
+		t.Errorf("Expected ports [80 443], got %v (%v)", ports, err)
+	}
+
+	type Container struct {
+		Name  string `yaml:"name"`
+		Image string `yaml:"image"`
+	}
+	containers, err := decoder.Get[[]Container](root, "spec.containers")
+	if err != nil || len(containers) != 1 || containers[0].Name != "web" {
+		t.Errorf("Unexpected containers %+v (%v)", containers, err)
+	}
+
+	name, err := decoder.Get[string](root, `metadata.labels["app.kubernetes.io/name"]`)
+	if err != nil || name != "web" {
+		t.Errorf("Expected label web, got %q (%v)", name, err)
+	}
+
+	_, err = decoder.Get[string](root, "spec.replicas")
+	if !stderrors.Is(err, node.ErrPathNotFound) {
+		t.Errorf("Expected ErrPathNotFound, got %v", err)
+	}
+
+	_, err = decoder.Get[string](root, "spec.containers[3].image")
+	if !stderrors.Is(err, node.ErrPathNotFound) {
+		t.Errorf("Expected ErrPathNotFound for out of range index, got %v", err)
+	}
+}
+
+func TestDecodeDocumentAndAlias(t *testing.T) {
+	target := &node.ScalarNode{Value: "8080", Style: node.StylePlain}
+	doc := &node.DocumentNode{Content: &node.MappingNode{Pairs: []*node.MappingPair{
+		{Key: &node.ScalarNode{Value: "port"}, Value: target},
+		{Key: &node.ScalarNode{Value: "backup"}, Value: &node.AliasNode{Name: "port", Target: target}},
+	}}}
+
+	var cfg struct {
+		Port   int `yaml:"port"`
+		Backup int `yaml:"backup"`
+	}
+	if err := decoder.DecodeNode(doc, &cfg); err != nil {
+		t.Fatalf("DecodeNode error: %v", err)
+	}
+	if cfg.Port != 8080 || cfg.Backup != 8080 {
+		t.Errorf("Unexpected result: %+v", cfg)
+	}
+
+	var v interface{}
+	if err := decoder.DecodeNode(&node.AliasNode{Name: "missing"}, &v); err == nil {
+		t.Error("Expected an error for an unresolved alias")
+	}
+}
+
+func TestDecodePreservedMergeKeys(t *testing.T) {
+	input := "base: &base\n  host: localhost\n  port: 80\nextra: &extra\n  tls: true\n  port: 443\nsite:\n  <<: [*base, *extra]\n  host: example.com\n"
+
+	root, err := parser.ParseStringWithOptions(input, &parser.Options{PreserveAliases: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	type site struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+		TLS  bool   `yaml:"tls"`
+	}
+	var cfg struct {
+		Site site `yaml:"site"`
+	}
+	if err := decoder.DecodeNode(root, &cfg); err != nil {
+		t.Fatalf("DecodeNode error: %v", err)
+	}
+	if cfg.Site != (site{Host: "example.com", Port: 80, TLS: true}) {
+		t.Errorf("Unexpected result: %+v", cfg.Site)
+	}
+
+	// Unmarshal resolves the same way
+	var m map[string]interface{}
+	if err := decoder.Unmarshal([]byte(input), &m); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if s := m["site"].(map[string]interface{}); len(s) != 3 || s["host"] != "example.com" || s["tls"] != true {
+		t.Errorf("Unexpected map: %v", s)
+	}
+}
+
+func TestDecodeAliasLimits(t *testing.T) {
+	// Each level references the previous one ten times: 10^9 values in total
+	var sb strings.Builder
+	sb.WriteString("l0: &l0 lol\n")
+	for i := 1; i <= 9; i++ {
+		prev := fmt.Sprintf("*l%d", i-1)
+		fmt.Fprintf(&sb, "l%d: &l%d [%s%s]\n", i, i, strings.Repeat(prev+", ", 9), prev)
+	}
+
+	var v interface{}
+	err := decoder.Unmarshal([]byte(sb.String()), &v)
+	var yamlErr *errors.YAMLError
+	if !stderrors.As(err, &yamlErr) || yamlErr.Type != errors.ErrorTypeDecoder {
+		t.Fatalf("Expected a decoder error, got %v", err)
+	}
+	if !strings.Contains(yamlErr.Message, "maximum of 10000 alias expansions") || yamlErr.Position.Line < 2 {
+		t.Errorf("Unexpected error: %v", yamlErr)
+	}
+
+	opts := decoder.DefaultOptions()
+	opts.Parser = &parser.Options{MaxNodes: 15}
+	if err := decoder.UnmarshalWithOptions([]byte("a: &a [1, 2, 3]\nb: [*a, *a, *a]\n"), &v, opts); err == nil {
+		t.Error("Expected the node limit to apply while decoding")
+	}
+}
+
+func TestUnmarshalParserOptions(t *testing.T) {
+	resolver := parser.NewTagResolver()
+	resolver.RegisterCustomHandler("!upper", func(value string) (interface{}, error) {
+		return strings.ToUpper(value), nil
+	})
+
+	opts := decoder.DefaultOptions()
+	opts.Parser = &parser.Options{TagResolver: resolver, DisableMergeKeys: true}
+
+	var m map[string]interface{}
+	input := "name: !upper web\ncount: !!str 10\nbase: &b {x: 1}\nsite:\n  <<: *b\n"
+	if err := decoder.UnmarshalWithOptions([]byte(input), &m, opts); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if m["name"] != "WEB" || m["count"] != "10" {
+		t.Errorf("Expected tag handlers to decode tagged scalars: %v", m)
+	}
+	if _, ok := m["site"].(map[string]interface{})["<<"]; !ok {
+		t.Errorf("Expected << to decode as an ordinary key: %v", m["site"])
+	}
+
+	// %TAG handles resolve for the handlers of their document only
+	resolver.RegisterCustomHandler("tag:example.com,2024:upper", func(value string) (interface{}, error) {
+		return strings.ToUpper(value), nil
+	})
+	m = nil
+	if err := decoder.UnmarshalWithOptions([]byte("%TAG !e! tag:example.com,2024:\n---\nname: !e!upper web\n"), &m, opts); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if m["name"] != "WEB" {
+		t.Errorf("Expected the %%TAG handle to reach the handler: %v", m)
+	}
+	m = nil
+	if err := decoder.UnmarshalWithOptions([]byte("name: !e!upper web\n"), &m, opts); err == nil || !strings.Contains(err.Error(), "undefined tag handle") {
+		t.Errorf("Expected the %%TAG handle of another document to be undefined, got %v", err)
+	}
+
+	opts.Parser = &parser.Options{DuplicateKeys: parser.DuplicateKeysError}
+	if err := decoder.UnmarshalWithOptions([]byte("a: 1\na: 2\n"), &m, opts); err == nil {
+		t.Error("Expected duplicate keys to be rejected")
+	}
+
+	opts.Parser = parser.StrictOptions()
+	if err := decoder.UnmarshalWithOptions([]byte("1: a\n0x1: b\n"), &m, opts); err == nil {
+		t.Error("Expected the strict options to reject keys with the same value")
+	}
+	if err := decoder.UnmarshalWithOptions([]byte("base: &b {x: 1}\nsite:\n  <<: *b\n  x: 2\n"), &m, opts); err != nil {
+		t.Errorf("Expected explicit keys to override merged ones: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package decoder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/decoder"
+)
+
+type podSpec struct {
+	ServiceAccountName string            `json:"serviceAccountName,omitempty"`
+	NodeSelector       map[string]string `json:"nodeSelector,omitempty"`
+	Priority           int               `json:"priority" yaml:"prio"`
+	Internal           string            `json:"-"`
+}
+
+func TestUnmarshalJSONTagFallback(t *testing.T) {
+	input := `serviceAccountName: builder
+nodeSelector:
+  disk: ssd
+prio: 7
+Internal: secret`
+
+	var spec podSpec
+	opts := decoder.DefaultOptions()
+	opts.JSONTagFallback = true
+	if err := decoder.UnmarshalWithOptions([]byte(input), &spec, opts); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+
+	if spec.ServiceAccountName != "builder" {
+		t.Errorf("Expected serviceAccountName 'builder', got %q", spec.ServiceAccountName)
+	}
+	if spec.NodeSelector["disk"] != "ssd" {
+		t.Errorf("Expected nodeSelector disk 'ssd', got %v", spec.NodeSelector)
+	}
+	if spec.Priority != 7 {
+		t.Errorf("Expected yaml tag to win over json tag, got priority %d", spec.Priority)
+	}
+	if spec.Internal != "" {
+		t.Errorf("Expected json:\"-\" field to be skipped, got %q", spec.Internal)
+	}
+}
+
+func TestDecoderJSONTagFallbackDisabledByDefault(t *testing.T) {
+	type account struct {
+		Name string `json:"sa"`
+	}
+
+	var acc account
+	dec := decoder.NewDecoder(strings.NewReader("sa: builder"))
+	if err := dec.Decode(&acc); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if acc.Name != "" {
+		t.Errorf("Expected json tags to be ignored by default, got %q", acc.Name)
+	}
+
+	dec = decoder.NewDecoder(strings.NewReader("sa: builder"))
+	dec.SetOptions(&decoder.Options{JSONTagFallback: true})
+	if err := dec.Decode(&acc); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if acc.Name != "builder" {
+		t.Errorf("Expected name 'builder', got %q", acc.Name)
+	}
+}
@@ -0,0 +1,199 @@
+package decoder
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// validationRule is a single constraint parsed from a `validate` struct tag
+type validationRule struct {
+	name  string
+	param string
+}
+
+// parseValidateTag splits a `validate` tag into its rules.
+// The regex rule consumes the remainder of the tag so patterns may contain commas.
+func parseValidateTag(tag string) []validationRule {
+	rules := make([]validationRule, 0)
+
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, validationRule{name: name, param: param})
+	}
+
+	return rules
+}
+
+// hasRule reports whether rules contains a rule with the given name
+func hasRule(rules []validationRule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateField checks a decoded struct field against its validation rules.
+// The node is used to report the position of the offending value.
+func validateField(field string, rules []validationRule, v reflect.Value, n node.Node) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if hasRule(rules, "required") {
+				return validationError(n, "field %q is required", field)
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		var err error
+
+		switch rule.name {
+		case "required":
+			if isNullNode(n) {
+				err = validationError(n, "field %q is required", field)
+			}
+		case "min", "max":
+			err = validateBound(field, rule, v, n)
+		case "oneof":
+			err = validateOneOf(field, rule, n)
+		case "regex":
+			err = validateRegex(field, rule, n)
+		default:
+			err = validationError(n, "field %q has unknown validation rule %q", field, rule.name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateBound applies a min or max rule to a number or to the length of a string, slice or map
+func validateBound(field string, rule validationRule, v reflect.Value, n node.Node) error {
+	limit, err := strconv.ParseFloat(rule.param, 64)
+	if err != nil {
+		return validationError(n, "field %q has invalid %s parameter %q", field, rule.name, rule.param)
+	}
+
+	var actual float64
+	what := "value"
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = v.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		actual = float64(v.Len())
+		what = "length"
+	default:
+		return validationError(n, "field %q of type %v does not support %s", field, v.Type(), rule.name)
+	}
+
+	if rule.name == "min" && actual < limit {
+		return validationError(n, "field %q %s %v is less than min %s", field, what, formatActual(v, actual), rule.param)
+	}
+	if rule.name == "max" && actual > limit {
+		return validationError(n, "field %q %s %v exceeds max %s", field, what, formatActual(v, actual), rule.param)
+	}
+
+	return nil
+}
+
+// validateOneOf checks that a scalar value is one of a space separated list of options
+func validateOneOf(field string, rule validationRule, n node.Node) error {
+	scalar, ok := node.Resolve(n).(*node.ScalarNode)
+	if !ok {
+		return validationError(n, "field %q must be a scalar for oneof", field)
+	}
+
+	options := strings.Fields(rule.param)
+	for _, option := range options {
+		if scalar.Value == option {
+			return nil
+		}
+	}
+
+	return validationError(n, "field %q value %q must be one of [%s]", field, scalar.Value, strings.Join(options, " "))
+}
+
+// validateRegex checks that a scalar value matches a regular expression
+func validateRegex(field string, rule validationRule, n node.Node) error {
+	scalar, ok := node.Resolve(n).(*node.ScalarNode)
+	if !ok {
+		return validationError(n, "field %q must be a scalar for regex", field)
+	}
+
+	re, err := regexp.Compile(rule.param)
+	if err != nil {
+		return validationError(n, "field %q has invalid regex %q: %v", field, rule.param, err)
+	}
+
+	if !re.MatchString(scalar.Value) {
+		return validationError(n, "field %q value %q does not match %q", field, scalar.Value, rule.param)
+	}
+
+	return nil
+}
+
+// isNullNode reports whether n is absent or a null scalar, looking through aliases
+func isNullNode(n node.Node) bool {
+	n = node.Resolve(n)
+	if n == nil {
+		return true
+	}
+	scalar, ok := n.(*node.ScalarNode)
+	if !ok {
+		return false
+	}
+	switch scalar.Value {
+	case "", "~", "null", "Null", "NULL":
+		return scalar.Style == node.StylePlain
+	}
+	return false
+}
+
+// formatActual renders the checked quantity without a trailing fraction for integers
+func formatActual(v reflect.Value, actual float64) string {
+	if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+		return strconv.FormatFloat(actual, 'g', -1, 64)
+	}
+	return strconv.FormatInt(int64(actual), 10)
+}
+
+// validationError creates a validation error positioned at n
+func validationError(n node.Node, format string, args ...interface{}) *errors.YAMLError {
+	pos := errors.Position{}
+	if n != nil {
+		pos.Line = n.Line()
+		pos.Column = n.Column()
+	}
+	return errors.New(fmt.Sprintf(format, args...), pos, errors.ErrorTypeValidation)
+}
@@ -0,0 +1,139 @@
+package decoder_test
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/decoder"
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+)
+
+type validatedConfig struct {
+	Name     string   `yaml:"name" validate:"required"`
+	Port     int      `yaml:"port" validate:"min=1,max=65535"`
+	LogLevel string   `yaml:"logLevel" validate:"oneof=debug info warn"`
+	Image    string   `yaml:"image" validate:"regex=^[a-z]+:[0-9]{1,3}$"`
+	Hosts    []string `yaml:"hosts" validate:"min=1"`
+}
+
+func TestUnmarshalValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+		line    int
+		column  int
+	}{
+		{
+			name: "valid document",
+			yaml: "name: api\nport: 8080\nlogLevel: info\nimage: nginx:1\nhosts:\n  - a\n",
+		},
+		{
+			name:    "port above max",
+			yaml:    "name: api\nport: 70000\n",
+			wantErr: true,
+			line:    2,
+			column:  7,
+		},
+		{
+			name:    "port below min",
+			yaml:    "name: api\nport: 0\n",
+			wantErr: true,
+			line:    2,
+			column:  7,
+		},
+		{
+			name:    "log level not allowed",
+			yaml:    "name: api\nlogLevel: trace\n",
+			wantErr: true,
+			line:    2,
+			column:  11,
+		},
+		{
+			name:    "image does not match regex",
+			yaml:    "name: api\nimage: \"Nginx\"\n",
+			wantErr: true,
+			line:    2,
+			column:  8,
+		},
+		{
+			name:    "empty hosts",
+			yaml:    "name: api\nhosts: []\n",
+			wantErr: true,
+			line:    2,
+			column:  8,
+		},
+		{
+			name: "aliased values are validated through their anchors",
+			yaml: "defs:\n  - &l info\n  - &i nginx:1\nname: api\nlogLevel: *l\nimage: *i\n",
+		},
+		{
+			name:    "aliased log level not allowed",
+			yaml:    "defs: &l trace\nname: api\nlogLevel: *l\n",
+			wantErr: true,
+			line:    1,
+			column:  10,
+		},
+		{
+			name:    "alias to null fails required",
+			yaml:    "defs: &n ~\nname: *n\n",
+			wantErr: true,
+			line:    1,
+			column:  10,
+		},
+		{
+			name:    "missing required key points at parent mapping",
+			yaml:    "port: 80\n",
+			wantErr: true,
+			line:    1,
+			column:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg validatedConfig
+			err := decoder.Unmarshal([]byte(tt.yaml), &cfg)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var yamlErr *errors.YAMLError
+			if !stderrors.As(err, &yamlErr) {
+				t.Fatalf("expected *errors.YAMLError, got %T: %v", err, err)
+			}
+			if yamlErr.Type != errors.ErrorTypeValidation {
+				t.Errorf("expected ErrorTypeValidation, got %v", yamlErr.Type)
+			}
+			if yamlErr.Position.Line != tt.line || yamlErr.Position.Column != tt.column {
+				t.Errorf("expected position %d:%d, got %d:%d (%s)",
+					tt.line, tt.column, yamlErr.Position.Line, yamlErr.Position.Column, yamlErr.Message)
+			}
+		})
+	}
+}
+
+func TestUnmarshalValidationNested(t *testing.T) {
+	type Service struct {
+		Name string `yaml:"name" validate:"required"`
+	}
+	type Root struct {
+		Services []Service `yaml:"services"`
+	}
+
+	input := "services:\n  - name: a\n  - port: 1\n"
+
+	var root Root
+	err := decoder.Unmarshal([]byte(input), &root)
+
+	var yamlErr *errors.YAMLError
+	if !stderrors.As(err, &yamlErr) {
+		t.Fatalf("expected *errors.YAMLError, got %v", err)
+	}
+	if yamlErr.Position.Line != 3 || yamlErr.Position.Column != 5 {
+		t.Errorf("expected position 3:5, got %d:%d", yamlErr.Position.Line, yamlErr.Position.Column)
+	}
+}
@@ -0,0 +1,376 @@
+// Package diff computes structural differences between YAML node trees
+package diff
+
+import (
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/merge"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+// ChangeType classifies a difference
+type ChangeType int
+
+const (
+	// Added means the node exists only in the new document
+	Added ChangeType = iota
+	// Removed means the node exists only in the old document
+	Removed
+	// Modified means the node exists in both documents with different content
+	Modified
+	// Moved means a sequence item matched by key changed position
+	Moved
+	// Reordered means a mapping kept its keys but changed their order
+	Reordered
+)
+
+// String returns the lower-case name of the change type
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Moved:
+		return "moved"
+	case Reordered:
+		return "reordered"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a single difference between two documents
+type Change struct {
+	Type ChangeType
+
+	// Path locates the node in the new document, or in the old one for removals
+	Path string
+
+	// From is the previous path of a moved sequence item
+	From string
+
+	// Old and New are the nodes before and after the change; Old is nil for
+	// additions and New is nil for removals
+	Old node.Node
+	New node.Node
+}
+
+// Options configures how documents are compared
+type Options struct {
+	// ArrayMergeStrategy selects how sequence items are matched, using the
+	// same modes as merging: ArrayReplace compares sequences as a whole,
+	// ArrayMergeByIndex and ArrayAppend compare items position by position and
+	// ArrayMergeByKey matches mapping items by one of KeyFields
+	ArrayMergeStrategy merge.ArrayMergeStrategy
+
+	// KeyFields are the mapping keys identifying sequence items for ArrayMergeByKey,
+	// tried in order
+	KeyFields []string
+
+	// IgnoreKeyOrder suppresses Reordered changes for mappings
+	IgnoreKeyOrder bool
+}
+
+// DefaultOptions returns the default diff options
+func DefaultOptions() *Options {
+	return &Options{
+		ArrayMergeStrategy: merge.ArrayMergeByIndex,
+		KeyFields:          []string{"name", "id", "key"},
+	}
+}
+
+// Diff compares two nodes and returns their differences in document order
+func Diff(oldNode, newNode node.Node, opts *Options) []*Change {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	d := &differ{options: opts}
+	d.compare("", oldNode, newNode)
+	return d.changes
+}
+
+// DiffStrings parses two YAML documents and compares them
+func DiffStrings(oldYAML, newYAML string, opts *Options) ([]*Change, error) {
+	oldNode, err := parser.ParseString(oldYAML)
+	if err != nil {
+		return nil, err
+	}
+	newNode, err := parser.ParseString(newYAML)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(oldNode, newNode, opts), nil
+}
+
+type differ struct {
+	options *Options
+	changes []*Change
+}
+
+func (d *differ) add(c *Change) {
+	d.changes = append(d.changes, c)
+}
+
+// compare records the differences between two nodes at path
+func (d *differ) compare(path string, a, b node.Node) {
+	// Documents compare by content and aliases by their targets
+	a, b = node.Resolve(a), node.Resolve(b)
+
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		d.add(&Change{Type: Added, Path: path, New: b})
+		return
+	case b == nil:
+		d.add(&Change{Type: Removed, Path: path, Old: a})
+		return
+	}
+
+	switch oldValue := a.(type) {
+	case *node.MappingNode:
+		if newValue, ok := b.(*node.MappingNode); ok {
+			d.compareMappings(path, oldValue, newValue)
+			return
+		}
+	case *node.SequenceNode:
+		if newValue, ok := b.(*node.SequenceNode); ok {
+			d.compareSequences(path, oldValue, newValue)
+			return
+		}
+	case *node.ScalarNode:
+		if newValue, ok := b.(*node.ScalarNode); ok {
+			if !scalarsEqual(oldValue, newValue) {
+				d.add(&Change{Type: Modified, Path: path, Old: a, New: b})
+			}
+			return
+		}
+	}
+
+	// Different node kinds
+	d.add(&Change{Type: Modified, Path: path, Old: a, New: b})
+}
+
+// compareMappings diffs two mappings key by key
+func (d *differ) compareMappings(path string, a, b *node.MappingNode) {
+	oldPairs := indexPairs(a)
+	newPairs := indexPairs(b)
+
+	var oldOrder, newOrder []string
+	for _, pair := range a.Pairs {
+		key := keyString(pair.Key)
+		if _, ok := newPairs[key]; ok {
+			oldOrder = append(oldOrder, key)
+		}
+	}
+	for _, pair := range b.Pairs {
+		key := keyString(pair.Key)
+		if _, ok := oldPairs[key]; ok {
+			newOrder = append(newOrder, key)
+		}
+	}
+
+	if !d.options.IgnoreKeyOrder && !equalStrings(oldOrder, newOrder) {
+		d.add(&Change{Type: Reordered, Path: path, Old: a, New: b})
+	}
+
+	for _, pair := range a.Pairs {
+		key := keyString(pair.Key)
+		if _, ok := newPairs[key]; !ok {
+			d.add(&Change{Type: Removed, Path: node.JoinKey(path, key), Old: pair.Value})
+		}
+	}
+
+	for _, pair := range b.Pairs {
+		key := keyString(pair.Key)
+		childPath := node.JoinKey(path, key)
+		if oldPair, ok := oldPairs[key]; ok {
+			d.compare(childPath, oldPair.Value, pair.Value)
+		} else {
+			d.add(&Change{Type: Added, Path: childPath, New: pair.Value})
+		}
+	}
+}
+
+// compareSequences diffs two sequences according to the array strategy
+func (d *differ) compareSequences(path string, a, b *node.SequenceNode) {
+	switch d.options.ArrayMergeStrategy {
+	case merge.ArrayReplace:
+		if !equalNodes(a, b) {
+			d.add(&Change{Type: Modified, Path: path, Old: a, New: b})
+		}
+	case merge.ArrayMergeByKey:
+		d.compareByKey(path, a, b)
+	default:
+		d.compareByIndex(path, a, b)
+	}
+}
+
+func (d *differ) compareByIndex(path string, a, b *node.SequenceNode) {
+	for i := 0; i < len(a.Items) || i < len(b.Items); i++ {
+		var oldItem, newItem node.Node
+		if i < len(a.Items) {
+			oldItem = a.Items[i]
+		}
+		if i < len(b.Items) {
+			newItem = b.Items[i]
+		}
+		d.compare(node.JoinIndex(path, i), oldItem, newItem)
+	}
+}
+
+// compareByKey matches items by identity: the value of the first key field
+// present, or the full content for items without one. Matched items whose
+// relative order changed are reported as moved.
+func (d *differ) compareByKey(path string, a, b *node.SequenceNode) {
+	oldIndex := make(map[string][]int)
+	for i, item := range a.Items {
+		id := d.identity(item)
+		oldIndex[id] = append(oldIndex[id], i)
+	}
+
+	matchedOld := make([]int, len(b.Items)) // old index for each new item, or -1
+	used := make(map[int]bool)
+	for j, item := range b.Items {
+		matchedOld[j] = -1
+		id := d.identity(item)
+		if candidates := oldIndex[id]; len(candidates) > 0 {
+			matchedOld[j] = candidates[0]
+			oldIndex[id] = candidates[1:]
+			used[candidates[0]] = true
+		}
+	}
+
+	for i, item := range a.Items {
+		if !used[i] {
+			d.add(&Change{Type: Removed, Path: node.JoinIndex(path, i), Old: item})
+		}
+	}
+
+	stable := longestIncreasing(matchedOld)
+	for j, item := range b.Items {
+		childPath := node.JoinIndex(path, j)
+		i := matchedOld[j]
+		if i < 0 {
+			d.add(&Change{Type: Added, Path: childPath, New: item})
+			continue
+		}
+		if !stable[j] {
+			d.add(&Change{
+				Type: Moved,
+				Path: childPath,
+				From: node.JoinIndex(path, i),
+				Old:  a.Items[i],
+				New:  item,
+			})
+		}
+		d.compare(childPath, a.Items[i], item)
+	}
+}
+
+// identity returns the matching key of a sequence item
+func (d *differ) identity(item node.Node) string {
+	if mapping, ok := node.Resolve(item).(*node.MappingNode); ok {
+		for _, field := range d.options.KeyFields {
+			for _, pair := range mapping.Pairs {
+				if keyString(pair.Key) != field {
+					continue
+				}
+				if value, ok := pair.Value.(*node.ScalarNode); ok {
+					return field + "=" + value.Value
+				}
+			}
+		}
+	}
+
+	data, err := convert.ToJSON(item)
+	if err != nil {
+		return ""
+	}
+	return "content=" + string(data)
+}
+
+// longestIncreasing marks the entries of indexes (ignoring negatives) that
+// form a longest strictly increasing subsequence; the others changed order
+func longestIncreasing(indexes []int) []bool {
+	var tails []int // tails[k] is the position ending the best subsequence of length k+1
+	prev := make([]int, len(indexes))
+
+	for j, v := range indexes {
+		prev[j] = -1
+		if v < 0 {
+			continue
+		}
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if indexes[tails[mid]] < v {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo > 0 {
+			prev[j] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, j)
+		} else {
+			tails[lo] = j
+		}
+	}
+
+	stable := make([]bool, len(indexes))
+	if len(tails) > 0 {
+		for j := tails[len(tails)-1]; j >= 0; j = prev[j] {
+			stable[j] = true
+		}
+	}
+	return stable
+}
+
+// equalNodes reports whether two nodes have the same content, ignoring
+// comments, styles and key order
+func equalNodes(a, b node.Node) bool {
+	d := &differ{options: &Options{ArrayMergeStrategy: merge.ArrayMergeByIndex, IgnoreKeyOrder: true}}
+	d.compare("", a, b)
+	return len(d.changes) == 0
+}
+
+// scalarsEqual compares scalars by value and resolved tag
+func scalarsEqual(a, b *node.ScalarNode) bool {
+	return a.Value == b.Value && convert.ScalarTag(a) == convert.ScalarTag(b)
+}
+
+func indexPairs(m *node.MappingNode) map[string]*node.MappingPair {
+	pairs := make(map[string]*node.MappingPair, len(m.Pairs))
+	for _, pair := range m.Pairs {
+		pairs[keyString(pair.Key)] = pair
+	}
+	return pairs
+}
+
+// keyString returns the text of a mapping key
+func keyString(key node.Node) string {
+	if scalar, ok := key.(*node.ScalarNode); ok {
+		return scalar.Value
+	}
+	data, _ := convert.ToJSON(key)
+	return string(data)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/merge"
+)
+
+func summarize(changes []*Change) []string {
+	var out []string
+	for _, c := range changes {
+		s := c.Type.String() + " " + c.Path
+		if c.From != "" {
+			s += " from " + c.From
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		opts     *Options
+		expected []string
+	}{
+		{
+			name:     "identical documents",
+			old:      "a: 1\nb: [x, y]",
+			new:      "# comment\na: 1\nb:\n  - x\n  - y",
+			expected: nil,
+		},
+		{
+			name:     "scalar changes",
+			old:      "image:\n  tag: \"1.0\"\n  pull: always\ndebug: true",
+			new:      "image:\n  tag: \"1.1\"\n  pull: always\nreplicas: 3",
+			expected: []string{"removed debug", "modified image.tag", "added replicas"},
+		},
+		{
+			name:     "type change is a modification",
+			old:      "port: 8080",
+			new:      "port: \"8080\"",
+			expected: []string{"modified port"},
+		},
+		{
+			name:     "reordered keys",
+			old:      "a: 1\nb: 2",
+			new:      "b: 2\na: 1",
+			expected: []string{"reordered "},
+		},
+		{
+			name:     "reordered keys ignored",
+			old:      "a: 1\nb: 2",
+			new:      "b: 2\na: 1",
+			opts:     &Options{ArrayMergeStrategy: merge.ArrayMergeByIndex, IgnoreKeyOrder: true},
+			expected: nil,
+		},
+		{
+			name:     "sequences by index",
+			old:      "env: [a, b, c]",
+			new:      "env: [a, x]",
+			expected: []string{"modified env[1]", "removed env[2]"},
+		},
+		{
+			name:     "sequences replaced as a whole",
+			old:      "env: [a, b, c]",
+			new:      "env: [a, x]",
+			opts:     &Options{ArrayMergeStrategy: merge.ArrayReplace},
+			expected: []string{"modified env"},
+		},
+		{
+			name: "sequences by key",
+			old: `containers:
+  - name: app
+    image: app:1
+  - name: sidecar
+    image: envoy:1
+  - name: old
+    image: old:1`,
+			new: `containers:
+  - name: sidecar
+    image: envoy:2
+  - name: app
+    image: app:1
+  - name: new
+    image: new:1`,
+			opts: &Options{ArrayMergeStrategy: merge.ArrayMergeByKey, KeyFields: []string{"name"}},
+			expected: []string{
+				"removed containers[2]",
+				"moved containers[0] from containers[1]",
+				"modified containers[0].image",
+				"added containers[2]",
+			},
+		},
+		{
+			name:     "scalar items by key use their content",
+			old:      "tags: [a, b, c]",
+			new:      "tags: [b, c, d]",
+			opts:     &Options{ArrayMergeStrategy: merge.ArrayMergeByKey},
+			expected: []string{"removed tags[0]", "added tags[2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := DiffStrings(tt.old, tt.new, tt.opts)
+			if err != nil {
+				t.Fatalf("DiffStrings failed: %v", err)
+			}
+			got := summarize(changes)
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestReports(t *testing.T) {
+	changes, err := DiffStrings("tag: \"1.0\"\ndebug: true", "tag: \"1.1\"\nports: [80]", nil)
+	if err != nil {
+		t.Fatalf("DiffStrings failed: %v", err)
+	}
+
+	text := FormatText(changes, false)
+	expected := "- debug: true\n~ tag: \"1.0\" -> \"1.1\"\n+ ports: [80]\n"
+	if text != expected {
+		t.Errorf("text report:\n%s\nwant:\n%s", text, expected)
+	}
+
+	colored := FormatText(changes, true)
+	if !strings.Contains(colored, colorRed+"- debug: true"+colorReset) {
+		t.Errorf("expected colored removal line, got %q", colored)
+	}
+
+	data, err := FormatJSON(changes)
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(decoded) != 3 || decoded[1]["type"] != "modified" || decoded[1]["old"] != "1.0" || decoded[1]["new"] != "1.1" {
+		t.Errorf("unexpected JSON report:\n%s", data)
+	}
+	if _, ok := decoded[0]["new"]; ok {
+		t.Errorf("removal should not have a new value:\n%s", data)
+	}
+}
@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// ANSI color escape sequences used by the text report
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// WriteText writes a human-readable report, one change per line:
+//
+//	~ path: old -> new   modified
+//	+ path: value        added
+//	- path: value        removed
+//	> path (from old)    moved
+//	= path: keys reordered
+//
+// When color is true each line is wrapped in ANSI color codes.
+func WriteText(w io.Writer, changes []*Change, color bool) error {
+	for _, c := range changes {
+		var line, code string
+		switch c.Type {
+		case Added:
+			line, code = fmt.Sprintf("+ %s: %s", displayPath(c.Path), formatValue(c.New)), colorGreen
+		case Removed:
+			line, code = fmt.Sprintf("- %s: %s", displayPath(c.Path), formatValue(c.Old)), colorRed
+		case Modified:
+			line, code = fmt.Sprintf("~ %s: %s -> %s", displayPath(c.Path), formatValue(c.Old), formatValue(c.New)), colorYellow
+		case Moved:
+			line, code = fmt.Sprintf("> %s (from %s)", displayPath(c.Path), displayPath(c.From)), colorCyan
+		case Reordered:
+			line, code = fmt.Sprintf("= %s: keys reordered", displayPath(c.Path)), colorCyan
+		default:
+			continue
+		}
+
+		if color {
+			line = code + line + colorReset
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatText returns the text report as a string
+func FormatText(changes []*Change, color bool) string {
+	var sb strings.Builder
+	_ = WriteText(&sb, changes, color)
+	return sb.String()
+}
+
+// jsonChange is the JSON representation of a Change
+type jsonChange struct {
+	Type string          `json:"type"`
+	Path string          `json:"path"`
+	From string          `json:"from,omitempty"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// MarshalJSON encodes a change with its nodes converted to JSON values
+func (c *Change) MarshalJSON() ([]byte, error) {
+	out := jsonChange{Type: c.Type.String(), Path: c.Path, From: c.From}
+
+	if c.Type != Moved && c.Type != Reordered {
+		var err error
+		if out.Old, err = nodeJSON(c.Old); err != nil {
+			return nil, err
+		}
+		if out.New, err = nodeJSON(c.New); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+// FormatJSON encodes changes as an indented JSON array
+func FormatJSON(changes []*Change) ([]byte, error) {
+	if changes == nil {
+		changes = []*Change{}
+	}
+	return json.MarshalIndent(changes, "", "  ")
+}
+
+func nodeJSON(n node.Node) (json.RawMessage, error) {
+	if n == nil {
+		return nil, nil
+	}
+	return convert.ToJSON(n)
+}
+
+// formatValue renders a node on one line for the text report
+func formatValue(n node.Node) string {
+	data, err := convert.ToJSON(n)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(data)
+}
+
+// displayPath shows the document root as "."
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
@@ -13,7 +13,7 @@ import (
 
 // Marshal returns the YAML encoding of v
 func Marshal(v interface{}) ([]byte, error) {
-	n, err := valueToNode(reflect.ValueOf(v))
+	n, err := newEncodeState(nil).valueToNode(reflect.ValueOf(v))
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +29,7 @@ func Marshal(v interface{}) ([]byte, error) {
 
 // MarshalWithOptions returns the YAML encoding of v with custom options
 func MarshalWithOptions(v interface{}, opts *serializer.Options) ([]byte, error) {
-	n, err := valueToNode(reflect.ValueOf(v))
+	n, err := newEncodeState(nil).valueToNode(reflect.ValueOf(v))
 	if err != nil {
 		return nil, err
 	}
@@ -44,17 +44,20 @@ func MarshalWithOptions(v interface{}, opts *serializer.Options) ([]byte, error)
 
 // Encoder writes YAML values-with-comments to an output stream
 type Encoder struct {
-	writer  io.Writer
-	options *serializer.Options
-	builder node.Builder
+	writer          io.Writer
+	options         *serializer.Options
+	builder         node.Builder
+	typeStyles      map[reflect.Type]node.Style
+	jsonTagFallback bool
 }
 
 // NewEncoder returns a new encoder that writes to w
 func NewEncoder(w io.Writer) *Encoder {
 	return &Encoder{
-		writer:  w,
-		options: serializer.DefaultOptions(),
-		builder: &node.DefaultBuilder{},
+		writer:     w,
+		options:    serializer.DefaultOptions(),
+		builder:    &node.DefaultBuilder{},
+		typeStyles: make(map[reflect.Type]node.Style),
 	}
 }
 
@@ -63,9 +66,23 @@ func (e *Encoder) SetOptions(opts *serializer.Options) {
 	e.options = opts
 }
 
+// SetTypeStyle sets the node style used for every value of type t.
+// Field tags such as `yaml:"name,flow"` take precedence over type styles.
+func (e *Encoder) SetTypeStyle(t reflect.Type, style node.Style) {
+	e.typeStyles[t] = style
+}
+
+// SetJSONTagFallback makes the encoder use `json` struct tags for fields without a `yaml` tag
+func (e *Encoder) SetJSONTagFallback(enabled bool) {
+	e.jsonTagFallback = enabled
+}
+
 // Encode writes the YAML encoding of v to the stream
 func (e *Encoder) Encode(v interface{}) error {
-	n, err := valueToNode(reflect.ValueOf(v))
+	state := newEncodeState(e.typeStyles)
+	state.jsonTagFallback = e.jsonTagFallback
+
+	n, err := state.valueToNode(reflect.ValueOf(v))
 	if err != nil {
 		return err
 	}
@@ -74,8 +91,37 @@ func (e *Encoder) Encode(v interface{}) error {
 	return s.Serialize(n)
 }
 
-// valueToNode converts a Go value to a YAML node
-func valueToNode(v reflect.Value) (node.Node, error) {
+// encodeState carries the style configuration for a single encoding pass
+type encodeState struct {
+	typeStyles      map[reflect.Type]node.Style
+	jsonTagFallback bool
+}
+
+// newEncodeState creates an encode state with optional per-type styles
+func newEncodeState(typeStyles map[reflect.Type]node.Style) *encodeState {
+	return &encodeState{typeStyles: typeStyles}
+}
+
+// valueToNode converts a Go value to a YAML node, applying any style configured for its type
+func (e *encodeState) valueToNode(v reflect.Value) (node.Node, error) {
+	n, err := e.convertValue(v)
+	if err != nil || !v.IsValid() {
+		return n, err
+	}
+
+	if style, ok := e.typeStyles[v.Type()]; ok {
+		applyStyle(n, style)
+	} else if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if style, ok := e.typeStyles[v.Type().Elem()]; ok {
+			applyStyle(n, style)
+		}
+	}
+
+	return n, nil
+}
+
+// convertValue converts a Go value to a YAML node
+func (e *encodeState) convertValue(v reflect.Value) (node.Node, error) {
 	builder := &node.DefaultBuilder{}
 
 	// Handle nil and zero values-with-comments
@@ -90,6 +136,10 @@ func valueToNode(v reflect.Value) (node.Node, error) {
 
 	switch v.Kind() {
 	case reflect.String:
+		// Multi-line strings read best as literal block scalars
+		if isBlockScalarCandidate(v.String()) {
+			return builder.BuildScalar(v.String(), node.StyleLiteral), nil
+		}
 		return builder.BuildScalar(v.String(), node.StylePlain), nil
 
 	case reflect.Bool:
@@ -109,19 +159,19 @@ func valueToNode(v reflect.Value) (node.Node, error) {
 		return builder.BuildScalar(fmt.Sprintf("%v", v.Float()), node.StylePlain), nil
 
 	case reflect.Slice, reflect.Array:
-		return sliceToNode(v)
+		return e.sliceToNode(v)
 
 	case reflect.Map:
-		return mapToNode(v)
+		return e.mapToNode(v)
 
 	case reflect.Struct:
-		return structToNode(v)
+		return e.structToNode(v)
 
 	case reflect.Interface:
 		if v.IsNil() {
 			return builder.BuildScalar("null", node.StylePlain), nil
 		}
-		return valueToNode(v.Elem())
+		return e.valueToNode(v.Elem())
 
 	default:
 		return nil, fmt.Errorf("unsupported type: %v", v.Type())
@@ -129,12 +179,12 @@ func valueToNode(v reflect.Value) (node.Node, error) {
 }
 
 // sliceToNode converts a slice or array to a sequence node
-func sliceToNode(v reflect.Value) (node.Node, error) {
+func (e *encodeState) sliceToNode(v reflect.Value) (node.Node, error) {
 	builder := &node.DefaultBuilder{}
 	items := make([]node.Node, v.Len())
 
 	for i := 0; i < v.Len(); i++ {
-		item, err := valueToNode(v.Index(i))
+		item, err := e.valueToNode(v.Index(i))
 		if err != nil {
 			return nil, err
 		}
@@ -145,17 +195,17 @@ func sliceToNode(v reflect.Value) (node.Node, error) {
 }
 
 // mapToNode converts a map to a mapping node
-func mapToNode(v reflect.Value) (node.Node, error) {
+func (e *encodeState) mapToNode(v reflect.Value) (node.Node, error) {
 	builder := &node.DefaultBuilder{}
 	pairs := make([]*node.MappingPair, 0, v.Len())
 
 	for _, key := range v.MapKeys() {
-		keyNode, err := valueToNode(key)
+		keyNode, err := e.valueToNode(key)
 		if err != nil {
 			return nil, err
 		}
 
-		valueNode, err := valueToNode(v.MapIndex(key))
+		valueNode, err := e.valueToNode(v.MapIndex(key))
 		if err != nil {
 			return nil, err
 		}
@@ -170,7 +220,7 @@ func mapToNode(v reflect.Value) (node.Node, error) {
 }
 
 // structToNode converts a struct to a mapping node
-func structToNode(v reflect.Value) (node.Node, error) {
+func (e *encodeState) structToNode(v reflect.Value) (node.Node, error) {
 	builder := &node.DefaultBuilder{}
 	t := v.Type()
 	pairs := make([]*node.MappingPair, 0)
@@ -183,32 +233,36 @@ func structToNode(v reflect.Value) (node.Node, error) {
 			continue
 		}
 
-		// Get field name from yaml tag if present
-		name := field.Name
-		if tag := field.Tag.Get("yaml"); tag != "" {
-			parts := strings.Split(tag, ",")
-			if parts[0] != "" {
-				name = parts[0]
-			}
-			// Skip if tag is "-"
-			if name == "-" {
-				continue
-			}
+		// Get field name and options from yaml tag if present
+		tag, ok := field.Tag.Lookup("yaml")
+		if !ok && e.jsonTagFallback {
+			tag = field.Tag.Get("json")
+		}
+		name, opts := parseFieldTag(tag)
+		if name == "" {
+			name = field.Name
+		}
+		// Skip if tag is "-"
+		if name == "-" {
+			continue
+		}
 
-			// Handle omitempty
-			if len(parts) > 1 && parts[1] == "omitempty" {
-				if isEmptyValue(v.Field(i)) {
-					continue
-				}
-			}
+		// Handle omitempty
+		if opts.omitEmpty && isEmptyValue(v.Field(i)) {
+			continue
 		}
 
 		keyNode := builder.BuildScalar(name, node.StylePlain)
-		valueNode, err := valueToNode(v.Field(i))
+		valueNode, err := e.valueToNode(v.Field(i))
 		if err != nil {
 			return nil, err
 		}
 
+		// Field tag styles override type styles and defaults
+		if opts.style != node.StyleAny {
+			applyStyle(valueNode, opts.style)
+		}
+
 		pairs = append(pairs, &node.MappingPair{
 			Key:   keyNode,
 			Value: valueNode,
@@ -218,6 +272,69 @@ func structToNode(v reflect.Value) (node.Node, error) {
 	return builder.BuildMapping(pairs, node.StyleBlock), nil
 }
 
+// fieldOptions holds the options that follow the name in a yaml struct tag
+type fieldOptions struct {
+	omitEmpty bool
+	style     node.Style
+}
+
+// parseFieldTag splits a yaml struct tag into the field name and its options
+func parseFieldTag(tag string) (string, fieldOptions) {
+	parts := strings.Split(tag, ",")
+	opts := fieldOptions{style: node.StyleAny}
+
+	for _, part := range parts[1:] {
+		switch strings.TrimSpace(part) {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "literal":
+			opts.style = node.StyleLiteral
+		case "folded":
+			opts.style = node.StyleFolded
+		case "quoted":
+			opts.style = node.StyleDoubleQuoted
+		case "flow":
+			opts.style = node.StyleFlow
+		}
+	}
+
+	return parts[0], opts
+}
+
+// applyStyle sets a style on a node when the style is meaningful for its kind.
+// Scalar styles apply to scalars; flow and block apply to sequences and mappings.
+func applyStyle(n node.Node, style node.Style) {
+	switch v := n.(type) {
+	case *node.ScalarNode:
+		switch style {
+		case node.StylePlain, node.StyleSingleQuoted, node.StyleDoubleQuoted,
+			node.StyleLiteral, node.StyleFolded:
+			v.Style = style
+		}
+	case *node.SequenceNode:
+		if style == node.StyleFlow || style == node.StyleBlock {
+			v.Style = style
+		}
+	case *node.MappingNode:
+		if style == node.StyleFlow || style == node.StyleBlock {
+			v.Style = style
+		}
+	}
+}
+
+// isBlockScalarCandidate reports whether a string can be emitted as a literal block.
+// Leading spaces or carriage returns would need indentation indicators or escapes,
+// so those strings keep the serializer's quoting.
+func isBlockScalarCandidate(s string) bool {
+	if !strings.Contains(strings.TrimRight(s, "\n"), "\n") {
+		return false
+	}
+	if strings.ContainsRune(s, '\r') || strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t") {
+		return false
+	}
+	return true
+}
+
 // isEmptyValue checks if a value is empty for omitempty purposes
 func isEmptyValue(v reflect.Value) bool {
 	switch v.Kind() {
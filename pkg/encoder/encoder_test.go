@@ -2,9 +2,12 @@ package encoder
 
 import (
 	"bytes"
-	"github.com/elioetibr/golang-yaml/pkg/decoder"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/decoder"
+	"github.com/elioetibr/golang-yaml/pkg/node"
 )
 
 func TestMarshalScalar(t *testing.T) {
@@ -231,3 +234,176 @@ func TestRoundTrip(t *testing.T) {
 		t.Errorf("Active mismatch: got %v, want %v", decoded.Active, original.Active)
 	}
 }
+
+func TestMarshalFieldStyles(t *testing.T) {
+	type Job struct {
+		Name    string            `yaml:"name,quoted"`
+		Script  string            `yaml:"script,literal"`
+		Summary string            `yaml:"summary,folded"`
+		Tags    []string          `yaml:"tags,flow"`
+		Env     map[string]string `yaml:"env,flow"`
+		Notes   string            `yaml:"notes,omitempty,literal"`
+	}
+
+	job := Job{
+		Name:    "build",
+		Script:  "make deps\nmake build\n",
+		Summary: "compiles the\nproject\n",
+		Tags:    []string{"ci", "go"},
+		Env:     map[string]string{"GOOS": "linux"},
+	}
+
+	data, err := Marshal(job)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+
+	expected := `name: "build"
+script: |
+  make deps
+  make build
+summary: >
+  compiles the
+  project
+tags: [ci, go]
+env: {GOOS: linux}`
+
+	if result := strings.TrimSpace(string(data)); result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestMarshalMultiLineDefaultsToLiteral(t *testing.T) {
+	type Step struct {
+		Run  string `yaml:"run"`
+		Name string `yaml:"name"`
+	}
+
+	tests := []struct {
+		run    string
+		header string
+	}{
+		{"go vet ./...\ngo test ./...", "|-"},
+		{"go vet ./...\ngo test ./...\n", "|"},
+		{"go vet ./...\ngo test ./...\n\n", "|+"},
+	}
+
+	for _, tt := range tests {
+		data, err := Marshal([]Step{{Run: tt.run, Name: "test"}})
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+		if !strings.Contains(string(data), "run: "+tt.header+"\n    go vet ./...\n    go test ./...\n") {
+			t.Errorf("Expected a %s literal for %q, got:\n%s", tt.header, tt.run, data)
+		}
+
+		var decoded []Step
+		if err := decoder.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal error: %v", err)
+		}
+		if len(decoded) != 1 || decoded[0].Run != tt.run || decoded[0].Name != "test" {
+			t.Errorf("Round trip mismatch for %q: %#v", tt.run, decoded)
+		}
+	}
+}
+
+// TestMarshalForcedBlockStyles checks that values forced into block style
+// decode back unchanged, falling back to quotes where a block scalar
+// cannot hold them
+func TestMarshalForcedBlockStyles(t *testing.T) {
+	type Doc struct {
+		Literal string `yaml:"literal,literal"`
+		Folded  string `yaml:"folded,folded"`
+		After   string `yaml:"after"`
+	}
+
+	values := []string{
+		"",
+		"\n",
+		"\n\n",
+		"x",
+		"line\n",
+		"  x",
+		"  indented\nline",
+		"\n  indented",
+		"text\n  ",
+		" ",
+		"a\n  b\n",
+	}
+
+	for _, value := range values {
+		data, err := Marshal(Doc{Literal: value, Folded: value, After: "kept"})
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+		var decoded Doc
+		if err := decoder.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal error for %q: %v", value, err)
+		}
+		if decoded != (Doc{Literal: value, Folded: value, After: "kept"}) {
+			t.Errorf("Round trip mismatch for %q through:\n%s\ngot %#v", value, data, decoded)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTypeStyle(reflect.TypeOf(""), node.StyleLiteral)
+	if err := enc.Encode(map[string]string{"a": "  x\ny"}); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	var m map[string]string
+	if err := decoder.Unmarshal(buf.Bytes(), &m); err != nil || m["a"] != "  x\ny" {
+		t.Errorf("Expected the type style to round trip, got %q (%v) from:\n%s", m["a"], err, buf.String())
+	}
+}
+
+func TestEncoderTypeStyle(t *testing.T) {
+	type Point struct {
+		X int `yaml:"x"`
+		Y int `yaml:"y"`
+	}
+	type Shape struct {
+		Origin Point   `yaml:"origin"`
+		Path   []Point `yaml:"path,flow"`
+		Label  string  `yaml:"label"`
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTypeStyle(reflect.TypeOf(Point{}), node.StyleFlow)
+	enc.SetTypeStyle(reflect.TypeOf(""), node.StyleSingleQuoted)
+
+	if err := enc.Encode(Shape{Origin: Point{1, 2}, Path: []Point{{3, 4}}, Label: "box"}); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	expected := `origin: {x: 1, y: 2}
+path: [{x: 3, y: 4}]
+label: 'box'`
+
+	if result := strings.TrimSpace(buf.String()); result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestEncoderJSONTagFallback(t *testing.T) {
+	type Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace,omitempty"`
+		Labels    map[string]string `json:"labels,omitempty"`
+		Version   int               `json:"version" yaml:"rev"`
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetJSONTagFallback(true)
+
+	if err := enc.Encode(Metadata{Name: "web", Version: 3}); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	expected := "name: web\nrev: 3"
+	if result := strings.TrimSpace(buf.String()); result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
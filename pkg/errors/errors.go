@@ -15,6 +15,10 @@ type YAMLError struct {
 	Position Position
 	Context  string
 	Type     ErrorType
+
+	// Related holds other locations involved in the error, such as where
+	// a duplicated key was first defined
+	Related []Position
 }
 
 // ErrorType represents the type of YAML error
@@ -27,6 +31,7 @@ const (
 	ErrorTypeDecoder
 	ErrorTypeEncoder
 	ErrorTypeValidation
+	ErrorTypePatch
 )
 
 func (e *YAMLError) Error() string {
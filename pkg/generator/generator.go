@@ -0,0 +1,444 @@
+// Package generator infers Go type definitions from YAML documents
+package generator
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+// Options configures code generation
+type Options struct {
+	// PackageName is the package clause of the generated file
+	PackageName string
+
+	// RootName is the name of the struct generated for the document root
+	RootName string
+
+	// OmitEmpty adds omitempty to fields that are missing or null in some samples
+	OmitEmpty bool
+
+	// EmitComments turns YAML head comments into Go doc comments
+	EmitComments bool
+}
+
+// DefaultOptions returns the default generator options
+func DefaultOptions() *Options {
+	return &Options{
+		PackageName:  "config",
+		RootName:     "Config",
+		OmitEmpty:    true,
+		EmitComments: true,
+	}
+}
+
+// GenerateFromString parses YAML input and generates Go types for it
+func GenerateFromString(input string, opts *Options) ([]byte, error) {
+	root, err := parser.ParseString(input)
+	if err != nil {
+		return nil, err
+	}
+	return Generate(root, opts)
+}
+
+// Generate emits Go type definitions describing the given node
+func Generate(root node.Node, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	g := &generator{
+		options: opts,
+		docs:    collectDocs(root),
+		names:   make(map[string]bool),
+	}
+
+	rootType := g.infer(root)
+	if rootType.kind != kindStruct {
+		// Wrap non-mapping documents so there is always a named root type
+		rootType = &goType{kind: kindAlias, elem: rootType}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// Code generated from YAML. DO NOT EDIT.\n\npackage %s\n", opts.PackageName)
+
+	g.declare(rootType, opts.RootName)
+	for _, t := range g.decls {
+		sb.WriteString("\n")
+		g.writeDecl(&sb, t)
+	}
+
+	formatted, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// kind classifies an inferred Go type
+type kind int
+
+const (
+	kindUnknown kind = iota // only null values seen so far
+	kindScalar
+	kindStruct
+	kindSlice
+	kindAny
+	kindAlias
+)
+
+// goType is an inferred Go type
+type goType struct {
+	kind   kind
+	scalar string   // Go type name for scalars
+	elem   *goType  // element type for slices and aliases
+	fields []*field // fields for structs
+	name   string   // assigned declaration name for structs and aliases
+	parent *goType  // enclosing struct, used for naming collisions
+}
+
+// field is a struct field inferred from a mapping key
+type field struct {
+	key      string
+	typ      *goType
+	doc      []string
+	optional bool
+}
+
+type generator struct {
+	options *Options
+	docs    map[node.Node][]string
+	names   map[string]bool
+	decls   []*goType
+}
+
+// infer derives a Go type from a node
+func (g *generator) infer(n node.Node) *goType {
+	switch v := node.Resolve(n).(type) {
+	case nil:
+		return &goType{kind: kindUnknown}
+	case *node.ScalarNode:
+		return inferScalar(v)
+	case *node.SequenceNode:
+		var elem *goType
+		for _, item := range v.Items {
+			elem = unify(elem, g.infer(item))
+		}
+		if elem == nil {
+			elem = &goType{kind: kindAny}
+		}
+		return &goType{kind: kindSlice, elem: elem}
+	case *node.MappingNode:
+		t := &goType{kind: kindStruct}
+		for _, pair := range v.Pairs {
+			key, ok := pair.Key.(*node.ScalarNode)
+			if !ok || node.IsMergeKey(key) {
+				continue
+			}
+			valueType := g.infer(pair.Value)
+			t.fields = append(t.fields, &field{
+				key:      key.Value,
+				typ:      valueType,
+				doc:      g.docs[pair.Key],
+				optional: valueType.kind == kindUnknown,
+			})
+		}
+		return t
+	default:
+		return &goType{kind: kindAny}
+	}
+}
+
+// inferScalar maps a scalar to a Go type using the resolved YAML tag
+func inferScalar(n *node.ScalarNode) *goType {
+	if n.Style != node.StylePlain && n.Style != node.StyleAny {
+		return &goType{kind: kindScalar, scalar: "string"}
+	}
+
+	tag := n.Tag()
+	if tag == "" {
+		tag = parser.InferTag(n.Value)
+	}
+
+	switch tag {
+	case parser.CommonTags.Null:
+		return &goType{kind: kindUnknown}
+	case parser.CommonTags.Bool:
+		return &goType{kind: kindScalar, scalar: "bool"}
+	case parser.CommonTags.Int:
+		return &goType{kind: kindScalar, scalar: "int"}
+	case parser.CommonTags.Float:
+		return &goType{kind: kindScalar, scalar: "float64"}
+	default:
+		return &goType{kind: kindScalar, scalar: "string"}
+	}
+}
+
+// unify combines two inferred types into one that can hold both
+func unify(a, b *goType) *goType {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.kind == kindUnknown {
+		return b
+	}
+	if b.kind == kindUnknown {
+		return a
+	}
+	if a.kind != b.kind {
+		return &goType{kind: kindAny}
+	}
+
+	switch a.kind {
+	case kindScalar:
+		if a.scalar == b.scalar {
+			return a
+		}
+		if isNumeric(a.scalar) && isNumeric(b.scalar) {
+			return &goType{kind: kindScalar, scalar: "float64"}
+		}
+		return &goType{kind: kindScalar, scalar: "string"}
+	case kindSlice:
+		return &goType{kind: kindSlice, elem: unify(a.elem, b.elem)}
+	case kindStruct:
+		return unifyStructs(a, b)
+	default:
+		return a
+	}
+}
+
+// unifyStructs merges the fields of two structs, marking fields that are not
+// present in both as optional
+func unifyStructs(a, b *goType) *goType {
+	result := &goType{kind: kindStruct}
+	index := make(map[string]*field)
+
+	for _, f := range a.fields {
+		merged := *f
+		index[f.key] = &merged
+		result.fields = append(result.fields, &merged)
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range b.fields {
+		seen[f.key] = true
+		if existing, ok := index[f.key]; ok {
+			existing.typ = unify(existing.typ, f.typ)
+			existing.optional = existing.optional || f.optional
+			if len(existing.doc) == 0 {
+				existing.doc = f.doc
+			}
+			continue
+		}
+		merged := *f
+		merged.optional = true
+		index[f.key] = &merged
+		result.fields = append(result.fields, &merged)
+	}
+
+	for _, f := range result.fields {
+		if !seen[f.key] {
+			f.optional = true
+		}
+	}
+
+	return result
+}
+
+func isNumeric(scalar string) bool {
+	return scalar == "int" || scalar == "float64"
+}
+
+// declare assigns names to struct types reachable from t and queues them for emission
+func (g *generator) declare(t *goType, name string) {
+	switch t.kind {
+	case kindStruct, kindAlias:
+		t.name = g.uniqueName(name, t.parent)
+		g.decls = append(g.decls, t)
+		if t.kind == kindAlias {
+			g.declareNested(t.elem, t, name)
+			return
+		}
+		for _, f := range t.fields {
+			g.declareNested(f.typ, t, exportedName(f.key))
+		}
+	case kindSlice:
+		g.declareNested(t.elem, t.parent, singular(name))
+	}
+}
+
+// declareNested declares a type nested inside parent
+func (g *generator) declareNested(t *goType, parent *goType, name string) {
+	if t == nil {
+		return
+	}
+	t.parent = parent
+	if t.kind == kindSlice {
+		g.declareNested(t.elem, parent, singular(name))
+		return
+	}
+	if t.kind == kindStruct {
+		g.declare(t, name)
+	}
+}
+
+// uniqueName returns an unused type name, prefixing with the parent name on collisions
+func (g *generator) uniqueName(name string, parent *goType) string {
+	if name == "" {
+		name = "Item"
+	}
+	candidate := name
+	if g.names[candidate] && parent != nil && parent.name != "" {
+		candidate = parent.name + name
+	}
+	base := candidate
+	for i := 2; g.names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	g.names[candidate] = true
+	return candidate
+}
+
+// writeDecl writes a type declaration
+func (g *generator) writeDecl(sb *strings.Builder, t *goType) {
+	if t.kind == kindAlias {
+		fmt.Fprintf(sb, "type %s %s\n", t.name, g.typeExpr(t.elem))
+		return
+	}
+
+	fmt.Fprintf(sb, "type %s struct {\n", t.name)
+	used := make(map[string]int)
+	for _, f := range t.fields {
+		if g.options.EmitComments {
+			for _, line := range f.doc {
+				fmt.Fprintf(sb, "\t// %s\n", line)
+			}
+		}
+
+		name := exportedName(f.key)
+		if used[name] > 0 {
+			name = fmt.Sprintf("%s%d", name, used[name]+1)
+		}
+		used[exportedName(f.key)]++
+
+		tag := f.key
+		if f.optional && g.options.OmitEmpty {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(sb, "\t%s %s `yaml:%q`\n", name, g.typeExpr(f.typ), tag)
+	}
+	sb.WriteString("}\n")
+}
+
+// typeExpr renders the Go type expression for t
+func (g *generator) typeExpr(t *goType) string {
+	switch t.kind {
+	case kindScalar:
+		return t.scalar
+	case kindStruct, kindAlias:
+		return t.name
+	case kindSlice:
+		return "[]" + g.typeExpr(t.elem)
+	default:
+		return "interface{}"
+	}
+}
+
+// collectDocs maps each mapping key to the comment lines that precede it,
+// which the parser attaches to the key
+func collectDocs(root node.Node) map[node.Node][]string {
+	docs := make(map[node.Node][]string)
+
+	var walk func(n node.Node)
+	walk = func(n node.Node) {
+		switch v := n.(type) {
+		case *node.SequenceNode:
+			for _, item := range v.Items {
+				walk(item)
+			}
+		case *node.MappingNode:
+			for _, pair := range v.Pairs {
+				if key, ok := pair.Key.(*node.ScalarNode); ok {
+					if lines := commentLines(key.HeadComment); len(lines) > 0 {
+						docs[key] = lines
+					}
+				}
+				walk(pair.Value)
+			}
+		}
+	}
+	walk(root)
+
+	return docs
+}
+
+// commentLines strips comment markers from a comment group
+func commentLines(cg *node.CommentGroup) []string {
+	if cg == nil {
+		return nil
+	}
+	lines := make([]string, 0, len(cg.Comments))
+	for _, c := range cg.Comments {
+		c = strings.TrimPrefix(strings.TrimSpace(c), "#")
+		lines = append(lines, strings.TrimSpace(c))
+	}
+	return lines
+}
+
+// exportedName converts a YAML key into an exported Go identifier
+func exportedName(key string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit(rune(name[0])) {
+		name = "F" + name
+	}
+	for _, initialism := range commonInitialisms {
+		if strings.EqualFold(name, initialism) {
+			return initialism
+		}
+		if strings.HasSuffix(name, initialism[:1]+strings.ToLower(initialism[1:])) {
+			name = name[:len(name)-len(initialism)] + initialism
+		}
+	}
+	return name
+}
+
+// commonInitialisms are rendered in upper case as golint recommends
+var commonInitialisms = []string{"ID", "URL", "URI", "API", "HTTP", "HTTPS", "JSON", "YAML", "TLS", "DNS", "CPU", "IP"}
+
+// singular derives an element type name from a plural field name
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	default:
+		return name + "Item"
+	}
+}
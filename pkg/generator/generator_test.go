@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+func TestGenerateScalars(t *testing.T) {
+	input := `name: app
+replicas: 3
+ratio: 0.5
+enabled: true
+quoted: "42"
+`
+	code, err := GenerateFromString(input, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+
+	result := string(code)
+	for _, want := range []string{
+		"package config",
+		"type Config struct {",
+		"Name     string  `yaml:\"name\"`",
+		"Replicas int     `yaml:\"replicas\"`",
+		"Ratio    float64 `yaml:\"ratio\"`",
+		"Enabled  bool    `yaml:\"enabled\"`",
+		"Quoted   string  `yaml:\"quoted\"`",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q\n%s", want, result)
+		}
+	}
+}
+
+func TestGenerateNestedWithComments(t *testing.T) {
+	input := `# Container image settings
+image:
+  # Image repository
+  repository: nginx
+  tag: "1.25"
+# Service port
+port: 80
+`
+	code, err := GenerateFromString(input, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+
+	result := string(code)
+	for _, want := range []string{
+		"// Container image settings\n\tImage Image `yaml:\"image\"`",
+		"// Service port\n\tPort int `yaml:\"port\"`",
+		"type Image struct {",
+		"// Image repository\n\tRepository string `yaml:\"repository\"`",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q\n%s", want, result)
+		}
+	}
+}
+
+func TestGenerateUnifiesSequenceOfMappings(t *testing.T) {
+	input := `containers:
+  - name: web
+    port: 80
+  - name: sidecar
+    debug: true
+    port: 8.5
+`
+	code, err := GenerateFromString(input, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+
+	result := string(code)
+	for _, want := range []string{
+		"Containers []Container `yaml:\"containers\"`",
+		"type Container struct {",
+		"Name  string  `yaml:\"name\"`",
+		"Port  float64 `yaml:\"port\"`",
+		"Debug bool    `yaml:\"debug,omitempty\"`",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q\n%s", want, result)
+		}
+	}
+	if strings.Count(result, "type Container") != 1 {
+		t.Errorf("expected a single element struct\n%s", result)
+	}
+}
+
+func TestGenerateSkipsOnlyMergeKeys(t *testing.T) {
+	input := "base: &b\n  x: 1\nplain:\n  <<: *b\n  y: 2\nquoted:\n  \"<<\": text\n"
+	root, err := parser.ParseStringWithOptions(input, &parser.Options{PreserveAliases: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	code, err := Generate(root, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+
+	result := string(code)
+	if strings.Count(result, "`yaml:\"<<\"`") != 1 {
+		t.Errorf("expected the quoted << as the only << field\n%s", result)
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"name":              "Name",
+		"service-account":   "ServiceAccount",
+		"image_pull_policy": "ImagePullPolicy",
+		"apiVersion":        "ApiVersion",
+		"userId":            "UserID",
+		"1st":               "F1st",
+		"":                  "Field",
+	}
+	for input, want := range tests {
+		if got := exportedName(input); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -1,19 +1,24 @@
 package lexer
 
 import (
-	"bufio"
 	"io"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/elioetibr/golang-yaml/pkg/charset"
 	"github.com/elioetibr/golang-yaml/pkg/errors"
 )
 
 // Lexer tokenizes YAML input
 type Lexer struct {
-	reader      *bufio.Reader
-	input       string
+	reader      io.Reader // UTF-8 input, nil once it is exhausted
+	readErr     error
+	buf         []byte // input from offset base up to what has been read
+	base        int
+	mark        int  // offset before which buffered input may be dropped
+	lineStart   int  // offset of the current line
+	whole       bool // buf holds the whole input
 	pos         int
 	line        int
 	column      int
@@ -31,10 +36,13 @@ type Lexer struct {
 	lastTokenLine    int
 }
 
-// NewLexer creates a new lexer from a reader
+// NewLexer creates a new lexer from a reader. The input is read as tokens
+// are requested and only the lines being scanned are kept in memory. UTF-16
+// and UTF-32 input is detected and transcoded to UTF-8 (YAML 1.2.2 spec
+// section 5.2), so token offsets count bytes of the UTF-8 text.
 func NewLexer(r io.Reader) *Lexer {
 	return &Lexer{
-		reader:      bufio.NewReader(r),
+		reader:      charset.NewReader(r),
 		line:        1,
 		column:      1,
 		indentStack: []int{0},
@@ -42,10 +50,12 @@ func NewLexer(r io.Reader) *Lexer {
 	}
 }
 
-// NewLexerFromString creates a new lexer from a string
+// NewLexerFromString creates a new lexer from a string. A string holding
+// UTF-16 or UTF-32 text is transcoded to UTF-8 like the input of NewLexer.
 func NewLexerFromString(input string) *Lexer {
 	return &Lexer{
-		input:       input,
+		buf:         charset.Decode([]byte(input)),
+		whole:       true,
 		line:        1,
 		column:      1,
 		indentStack: []int{0},
@@ -55,6 +65,7 @@ func NewLexerFromString(input string) *Lexer {
 
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() (*Token, error) {
+	l.mark = l.lineStart
 	l.skipWhitespace()
 
 	// Check if we encountered any errors during whitespace processing
@@ -65,6 +76,11 @@ func (l *Lexer) NextToken() (*Token, error) {
 	}
 
 	if l.isEOF() {
+		// Input cut short by a failing reader ends with its error
+		if err := l.takeReadError(); err != nil {
+			return nil, err
+		}
+
 		// For EOF, position it at the start of the next line if we're not already at column 1
 		token := &Token{
 			Type:   TokenEOF,
@@ -78,9 +94,41 @@ func (l *Lexer) NextToken() (*Token, error) {
 			token.Line = l.line + 1
 			token.Column = 1
 		}
+		token.EndLine, token.EndCol, token.EndOffset = token.Line, token.Column, token.Offset
 		return token, nil
 	}
 
+	offset, line, column := l.pos, l.line, l.column
+	l.mark = l.lineStart
+	token, err := l.scanToken()
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		l.setSpan(token, offset, line, column)
+	}
+	return token, nil
+}
+
+// setSpan records where a token read from offset starts and ends. Scanners
+// report some tokens at their last line or past an indicator, so the start
+// is set here from the position before scanning.
+func (l *Lexer) setSpan(token *Token, offset, line, column int) {
+	text := strings.TrimRight(l.text(offset, l.pos), " \t\r\n")
+	end := offset + len(text)
+
+	token.Line, token.Column, token.Offset = line, column, offset
+	token.EndLine = line + strings.Count(text, "\n")
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		token.EndCol = len(text) - i
+	} else {
+		token.EndCol = column + len(text)
+	}
+	token.EndOffset = end
+}
+
+// scanToken reads the token starting at the current position
+func (l *Lexer) scanToken() (*Token, error) {
 	var token *Token
 	var err error
 
@@ -129,21 +177,21 @@ func (l *Lexer) NextToken() (*Token, error) {
 	// Check for structure indicators
 	switch l.current {
 	case '-':
-		if l.isWhitespace(l.peek()) {
+		if l.followedBySpace() {
 			l.advance(1)
 			token = l.createToken(TokenSequenceEntry, "-")
 			l.lastTokenLine = token.Line
 			return token, nil
 		}
 	case ':':
-		if l.isWhitespace(l.peek()) || l.isEOF() {
+		if l.followedBySpace() {
 			l.advance(1)
 			token = l.createToken(TokenMappingValue, ":")
 			l.lastTokenLine = token.Line
 			return token, nil
 		}
 	case '?':
-		if l.isWhitespace(l.peek()) {
+		if l.followedBySpace() {
 			l.advance(1)
 			token = l.createToken(TokenMappingKey, "?")
 			l.lastTokenLine = token.Line
@@ -182,6 +230,10 @@ func (l *Lexer) NextToken() (*Token, error) {
 		token, err = l.scanPlainScalar()
 	}
 
+	if token == nil && err == nil {
+		// An indicator not followed by a space starts a plain scalar
+		token, err = l.scanPlainScalar()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -193,17 +245,19 @@ func (l *Lexer) NextToken() (*Token, error) {
 
 // Helper methods
 
+// advance moves n bytes ahead, stopping at the end of the input
 func (l *Lexer) advance(n int) {
-	for i := 0; i < n; i++ {
+	for i := 0; i < n && l.ensure(l.pos); i++ {
 		if l.current == '\n' {
 			l.line++
 			l.column = 1
+			l.lineStart = l.pos + 1
 		} else {
 			l.column++
 		}
 		l.pos++
-		if l.pos < len(l.input) {
-			l.current = rune(l.input[l.pos])
+		if l.ensure(l.pos) {
+			l.current = rune(l.buf[l.pos-l.base])
 		} else {
 			l.current = 0
 		}
@@ -211,14 +265,20 @@ func (l *Lexer) advance(n int) {
 }
 
 func (l *Lexer) peek() rune {
-	if l.pos+1 < len(l.input) {
-		return rune(l.input[l.pos+1])
+	if l.ensure(l.pos + 1) {
+		return rune(l.buf[l.pos+1-l.base])
 	}
 	return 0
 }
 
+// followedBySpace reports whether the current character is followed by
+// whitespace or the end of the input, as an indicator must be
+func (l *Lexer) followedBySpace() bool {
+	return !l.ensure(l.pos+1) || l.isWhitespace(l.peek())
+}
+
 func (l *Lexer) isEOF() bool {
-	return l.pos >= len(l.input)
+	return !l.ensure(l.pos)
 }
 
 func (l *Lexer) isWhitespace(r rune) bool {
@@ -289,10 +349,10 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) checkString(s string) bool {
-	if l.pos+len(s) > len(l.input) {
+	if !l.ensure(l.pos + len(s) - 1) {
 		return false
 	}
-	return l.input[l.pos:l.pos+len(s)] == s
+	return string(l.buf[l.pos-l.base:l.pos+len(s)-l.base]) == s
 }
 
 func (l *Lexer) createToken(typ TokenType, value string) *Token {
@@ -321,7 +381,7 @@ func (l *Lexer) scanComment() (*Token, error) {
 		l.advance(1)
 	}
 
-	value := l.input[start:l.pos]
+	value := l.text(start, l.pos)
 	token := &Token{
 		Type:             TokenComment,
 		Value:            value,
@@ -350,11 +410,11 @@ func (l *Lexer) scanAnchor() (*Token, error) {
 	start := l.pos
 	l.advance(1) // skip &
 
-	for unicode.IsLetter(l.current) || unicode.IsDigit(l.current) || l.current == '_' || l.current == '-' {
+	for isAnchorChar(l.current) {
 		l.advance(1)
 	}
 
-	value := l.input[start+1 : l.pos]
+	value := l.text(start+1, l.pos)
 	return l.createToken(TokenAnchor, value), nil
 }
 
@@ -362,23 +422,43 @@ func (l *Lexer) scanAlias() (*Token, error) {
 	start := l.pos
 	l.advance(1) // skip *
 
-	for unicode.IsLetter(l.current) || unicode.IsDigit(l.current) || l.current == '_' || l.current == '-' {
+	for isAnchorChar(l.current) {
 		l.advance(1)
 	}
 
-	value := l.input[start+1 : l.pos]
+	value := l.text(start+1, l.pos)
 	return l.createToken(TokenAlias, value), nil
 }
 
+// isAnchorChar reports whether r may appear in an anchor or alias name.
+// Bytes of multi-byte UTF-8 characters are accepted whole.
+func isAnchorChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r >= utf8.RuneSelf
+}
+
 func (l *Lexer) scanTag() (*Token, error) {
 	start := l.pos
 	l.advance(1) // skip !
 
+	// A verbatim tag, !<...>, runs to its closing bracket
+	if l.current == '<' {
+		for l.current != '>' && !l.isWhitespace(l.current) && !l.isEOF() {
+			l.advance(1)
+		}
+		if l.current == '>' {
+			l.advance(1)
+		}
+		return l.createToken(TokenTag, l.text(start, l.pos)), nil
+	}
+
 	for !l.isWhitespace(l.current) && !l.isEOF() {
+		if l.inFlow > 0 && strings.ContainsRune(",[]{}", l.current) {
+			break
+		}
 		l.advance(1)
 	}
 
-	value := l.input[start:l.pos]
+	value := l.text(start, l.pos)
 	return l.createToken(TokenTag, value), nil
 }
 
@@ -390,7 +470,7 @@ func (l *Lexer) scanDirective() (*Token, error) {
 		l.advance(1)
 	}
 
-	value := l.input[start:l.pos]
+	value := l.text(start, l.pos)
 	return l.createToken(TokenDirective, value), nil
 }
 
@@ -413,7 +493,7 @@ func (l *Lexer) scanPlainScalar() (*Token, error) {
 			return l.scanComment()
 		}
 
-		if l.current == ':' && l.isWhitespace(l.peek()) {
+		if l.current == ':' && l.followedBySpace() {
 			break
 		}
 		if l.current == '\n' {
@@ -423,7 +503,7 @@ func (l *Lexer) scanPlainScalar() (*Token, error) {
 			break
 		}
 
-		sb.WriteRune(l.current)
+		sb.WriteByte(byte(l.current))
 		l.advance(1)
 	}
 
@@ -436,8 +516,11 @@ func (l *Lexer) scanPlainScalar() (*Token, error) {
 
 func (l *Lexer) scanSingleQuotedScalar() (*Token, error) {
 	var sb strings.Builder
+	start := l.pos
+	startCol := l.column
 	l.advance(1) // skip opening '
 
+	closed := false
 	for !l.isEOF() {
 		if l.current == '\'' {
 			if l.peek() == '\'' {
@@ -445,26 +528,34 @@ func (l *Lexer) scanSingleQuotedScalar() (*Token, error) {
 				l.advance(2)
 			} else {
 				l.advance(1) // skip closing '
+				closed = true
 				break
 			}
 		} else {
-			sb.WriteRune(l.current)
+			sb.WriteByte(byte(l.current))
 			l.advance(1)
 		}
 	}
 
 	token := l.createToken(TokenSingleQuotedScalar, sb.String())
 	token.Style = ScalarStyleSingleQuoted
+	token.Unterminated = !closed
+	token.Column = startCol
+	token.Offset = start
 	return token, nil
 }
 
 func (l *Lexer) scanDoubleQuotedScalar() (*Token, error) {
 	var sb strings.Builder
+	start := l.pos
+	startCol := l.column
 	l.advance(1) // skip opening "
 
+	closed := false
 	for !l.isEOF() {
 		if l.current == '"' {
 			l.advance(1) // skip closing "
+			closed = true
 			break
 		} else if l.current == '\\' {
 			l.advance(1)
@@ -480,80 +571,216 @@ func (l *Lexer) scanDoubleQuotedScalar() (*Token, error) {
 			case '"':
 				sb.WriteRune('"')
 			default:
-				sb.WriteRune(l.current)
+				sb.WriteByte(byte(l.current))
 			}
 			l.advance(1)
 		} else {
-			sb.WriteRune(l.current)
+			sb.WriteByte(byte(l.current))
 			l.advance(1)
 		}
 	}
 
 	token := l.createToken(TokenDoubleQuotedScalar, sb.String())
 	token.Style = ScalarStyleDoubleQuoted
+	token.Unterminated = !closed
+	token.Column = startCol
+	token.Offset = start
 	return token, nil
 }
 
 func (l *Lexer) scanLiteralScalar() (*Token, error) {
 	var sb strings.Builder
+	parentIndent := l.lineIndent()
 	l.advance(1) // skip |
-
-	// Skip to next line
-	for l.current != '\n' && !l.isEOF() {
-		l.advance(1)
-	}
-	l.advance(1)
+	indicator := l.scanBlockScalarHeader()
 
 	// Read literal block
-	baseIndent := l.column
-	for !l.isEOF() {
-		if l.column < baseIndent && !l.isWhitespace(l.current) {
-			break
-		}
-		sb.WriteRune(l.current)
-		l.advance(1)
-	}
+	sb.WriteString(l.scanBlockScalarBody(parentIndent))
 
-	token := l.createToken(TokenLiteralScalar, strings.TrimRight(sb.String(), "\n"))
+	token := l.createToken(TokenLiteralScalar, chomp(sb.String(), indicator))
 	token.Style = ScalarStyleLiteral
 	return token, nil
 }
 
 func (l *Lexer) scanFoldedScalar() (*Token, error) {
 	var sb strings.Builder
+	parentIndent := l.lineIndent()
 	l.advance(1) // skip >
+	indicator := l.scanBlockScalarHeader()
+
+	// Read folded block
+	sb.WriteString(l.scanBlockScalarBody(parentIndent))
+
+	token := l.createToken(TokenFoldedScalar, chomp(sb.String(), indicator))
+	token.Style = ScalarStyleFolded
+	return token, nil
+}
 
-	// Skip to next line
+// scanBlockScalarHeader reads the rest of the line after | or > and returns
+// its chomping indicator: '-' to strip the final line breaks, '+' to keep
+// them all, or 0 to clip them to one
+func (l *Lexer) scanBlockScalarHeader() rune {
+	var indicator rune
+	inHeader := true
 	for l.current != '\n' && !l.isEOF() {
+		switch {
+		case l.current == ' ' || l.current == '\t' || l.current == '#':
+			inHeader = false
+		case inHeader && (l.current == '-' || l.current == '+'):
+			indicator = l.current
+		}
 		l.advance(1)
 	}
 	l.advance(1)
+	return indicator
+}
+
+// chomp applies a chomping indicator to the body of a block scalar. Empty
+// lines after the last content line are line breaks to strip, clip or
+// keep.
+func chomp(body string, indicator rune) string {
+	lines := strings.Split(body, "\n")
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	content := strings.Join(lines[:end], "\n")
+	breaks := strings.Count(body[len(content):], "\n")
+
+	switch {
+	case indicator == '-' || breaks == 0:
+		return content
+	case indicator == '+':
+		return content + strings.Repeat("\n", breaks)
+	case content == "":
+		return ""
+	}
+	return content + "\n"
+}
+
+// lineIndent returns the number of leading spaces on the current line
+func (l *Lexer) lineIndent() int {
+	indent := 0
+	for l.ensure(l.lineStart+indent) && l.buf[l.lineStart+indent-l.base] == ' ' {
+		indent++
+	}
+	return indent
+}
+
+// scanBlockScalarBody reads the lines of a block scalar. The block ends at the
+// first non-empty line that is indented less than the first content line or
+// not more than the line that introduced the scalar.
+func (l *Lexer) scanBlockScalarBody(parentIndent int) string {
+	var sb strings.Builder
+	blockIndent := -1
 
-	// Read folded block
-	baseIndent := l.column
 	for !l.isEOF() {
-		if l.column < baseIndent && !l.isWhitespace(l.current) {
-			break
+		line := l.restOfLine()
+
+		if strings.TrimSpace(line) != "" {
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if blockIndent < 0 {
+				if indent <= parentIndent {
+					break
+				}
+				blockIndent = indent
+			}
+			if indent < blockIndent {
+				break
+			}
+		}
+
+		sb.WriteString(line)
+		l.advance(len(line))
+		if !l.isEOF() {
+			sb.WriteByte('\n')
+			l.advance(1)
 		}
-		sb.WriteRune(l.current)
-		l.advance(1)
 	}
 
-	token := l.createToken(TokenFoldedScalar, strings.TrimRight(sb.String(), "\n"))
-	token.Style = ScalarStyleFolded
-	return token, nil
+	return sb.String()
 }
 
-// Initialize reads the first character
+// Initialize reads the first character, skipping a byte order mark
 func (l *Lexer) Initialize() error {
-	if l.input != "" {
-		if len(l.input) > 0 {
-			r, _ := utf8.DecodeRuneInString(l.input)
-			l.current = r
+	if l.checkString(byteOrderMark) {
+		l.pos = len(byteOrderMark)
+		l.lineStart = l.pos
+	}
+	if l.ensure(l.pos) {
+		l.current = rune(l.buf[l.pos-l.base])
+	}
+	return l.takeReadError()
+}
+
+// byteOrderMark is U+FEFF in UTF-8, which may start a stream
+const byteOrderMark = "\uFEFF"
+
+// readSize is how much input is requested from a reader at a time
+const readSize = 4096
+
+// ensure reports whether the byte at offset i is available, reading more of
+// the input as needed
+func (l *Lexer) ensure(i int) bool {
+	for i-l.base >= len(l.buf) && l.reader != nil {
+		l.fill()
+	}
+	return i-l.base < len(l.buf)
+}
+
+// fill reads the next chunk of input from the reader. Input before the mark
+// has been tokenized and is dropped first, so the buffer holds only the lines
+// of the token being scanned and what has been read ahead of them.
+func (l *Lexer) fill() {
+	if drop := l.mark - l.base; drop > 0 {
+		n := copy(l.buf, l.buf[drop:])
+		l.buf = l.buf[:n]
+		l.base = l.mark
+	}
+	if cap(l.buf)-len(l.buf) < readSize {
+		grown := make([]byte, len(l.buf), 2*cap(l.buf)+readSize)
+		copy(grown, l.buf)
+		l.buf = grown
+	}
+
+	n, err := l.reader.Read(l.buf[len(l.buf):cap(l.buf)])
+	l.buf = l.buf[:len(l.buf)+n]
+	if err != nil {
+		if err != io.EOF {
+			l.readErr = err
 		}
-	} else if l.reader != nil {
-		// Read from reader implementation
-		// This would be implemented based on the reader
+		l.reader = nil
+	}
+}
+
+// takeReadError returns and clears the error the reader failed with
+func (l *Lexer) takeReadError() error {
+	err := l.readErr
+	l.readErr = nil
+	return err
+}
+
+// Input returns the whole UTF-8 input of a lexer created by
+// NewLexerFromString. A lexer reading from an io.Reader does not keep its
+// input and returns false.
+func (l *Lexer) Input() (string, bool) {
+	if !l.whole {
+		return "", false
+	}
+	return string(l.buf), true
+}
+
+// text returns the input between two offsets
+func (l *Lexer) text(start, end int) string {
+	return string(l.buf[start-l.base : end-l.base])
+}
+
+// restOfLine returns the input from the current position to the end of the
+// line
+func (l *Lexer) restOfLine() string {
+	end := l.pos
+	for l.ensure(end) && l.buf[end-l.base] != '\n' {
+		end++
 	}
-	return nil
+	return l.text(l.pos, end)
 }
@@ -40,6 +40,20 @@ func TestBasicTokens(t *testing.T) {
 				TokenEOF,
 			},
 		},
+		{
+			name:  "indicators starting plain scalars",
+			input: "- -1\n- :a\n- ?b\n-",
+			expected: []TokenType{
+				TokenSequenceEntry,
+				TokenPlainScalar,
+				TokenSequenceEntry,
+				TokenPlainScalar,
+				TokenSequenceEntry,
+				TokenPlainScalar,
+				TokenSequenceEntry,
+				TokenEOF,
+			},
+		},
 		{
 			name:  "flow sequence",
 			input: "[a, b, c]",
@@ -148,6 +162,27 @@ func TestScalarStyles(t *testing.T) {
 			expectedValue: "it's",
 			expectedStyle: ScalarStyleSingleQuoted,
 		},
+		{
+			name:          "non-ASCII plain",
+			input:         "é ü 😀",
+			expectedType:  TokenPlainScalar,
+			expectedValue: "é ü 😀",
+			expectedStyle: ScalarStylePlain,
+		},
+		{
+			name:          "non-ASCII double quoted",
+			input:         `"ñ\tß"`,
+			expectedType:  TokenDoubleQuotedScalar,
+			expectedValue: "ñ\tß",
+			expectedStyle: ScalarStyleDoubleQuoted,
+		},
+		{
+			name:          "byte order mark",
+			input:         "\uFEFFplain",
+			expectedType:  TokenPlainScalar,
+			expectedValue: "plain",
+			expectedStyle: ScalarStylePlain,
+		},
 	}
 
 	for _, tt := range tests {
@@ -305,6 +340,12 @@ func TestSpecialTokens(t *testing.T) {
 			expectedType:  TokenTag,
 			expectedValue: "!tag",
 		},
+		{
+			name:          "verbatim tag",
+			input:         "!<tag:example.com,2024:a>x",
+			expectedType:  TokenTag,
+			expectedValue: "!<tag:example.com,2024:a>",
+		},
 		{
 			name:          "directive",
 			input:         "%YAML 1.2",
@@ -547,6 +588,44 @@ second: line
 	}
 }
 
+// TestTokenSpans tests start and end offsets of tokens
+func TestTokenSpans(t *testing.T) {
+	input := "a: &x 'q'   # note\nb: |\n  text\n"
+
+	expected := []struct {
+		text    string
+		endLine int
+		endCol  int
+	}{
+		{"a", 1, 2},
+		{":", 1, 3},
+		{"&x", 1, 6},
+		{"'q'", 1, 10},
+		{"# note", 1, 19},
+		{"b", 2, 2},
+		{":", 2, 3},
+		{"|\n  text", 3, 7},
+	}
+
+	lexer := NewLexerFromString(input)
+	if err := lexer.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize lexer: %v", err)
+	}
+
+	for i, want := range expected {
+		token, err := lexer.NextToken()
+		if err != nil {
+			t.Fatalf("Unexpected error at token %d: %v", i, err)
+		}
+		if got := input[token.Offset:token.EndOffset]; got != want.text {
+			t.Errorf("Token %d text: expected %q, got %q", i, want.text, got)
+		}
+		if token.EndLine != want.endLine || token.EndCol != want.endCol {
+			t.Errorf("Token %d end: expected %d:%d, got %d:%d", i, want.endLine, want.endCol, token.EndLine, token.EndCol)
+		}
+	}
+}
+
 // TestErrorCases tests error handling
 func TestErrorCases(t *testing.T) {
 	tests := []struct {
@@ -604,6 +683,33 @@ func TestErrorCases(t *testing.T) {
 	}
 }
 
+func TestUnterminatedQuotes(t *testing.T) {
+	tests := []struct {
+		input        string
+		unterminated bool
+	}{
+		{`"closed"`, false},
+		{`'it''s'`, false},
+		{"\"open\nnext: 1", true},
+		{"'open", true},
+		{`"escaped \"`, true},
+	}
+
+	for _, tt := range tests {
+		lexer := NewLexerFromString(tt.input)
+		if err := lexer.Initialize(); err != nil {
+			t.Fatalf("Failed to initialize lexer: %v", err)
+		}
+		token, err := lexer.NextToken()
+		if err != nil {
+			t.Fatalf("NextToken(%q) error: %v", tt.input, err)
+		}
+		if token.Unterminated != tt.unterminated {
+			t.Errorf("%q: expected Unterminated %v, got %v", tt.input, tt.unterminated, token.Unterminated)
+		}
+	}
+}
+
 // BenchmarkLexer benchmarks lexer performance
 func BenchmarkLexer(b *testing.B) {
 	input := `---
@@ -0,0 +1,157 @@
+package lexer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/elioetibr/golang-yaml/pkg/charset"
+)
+
+// TestReaderMatchesString checks that lexing from a reader yields the same
+// tokens as lexing the whole input as a string
+func TestReaderMatchesString(t *testing.T) {
+	inputs := []string{
+		"",
+		"key: value",
+		"# head\nkey: value # line\n\n# foot\n",
+		"---\na: 1\n...\n---\nb: [1, 2, {c: d}]\n",
+		"list:\n  - &anchor one\n  - *anchor\n  - !!str two\n",
+		"%YAML 1.2\n---\ntext: |\n  line 1\n\n  line 2\nnext: >\n  folded\n  text\n",
+		"quoted: 'it''s'\nescaped: \"a\\tb\\n\"\n",
+		"a:\n\tb: 1\n",
+		"key: " + strings.Repeat("long ", 2000) + "\nblock: |\n" + strings.Repeat("  text\n", 2000),
+	}
+
+	for i, input := range inputs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			want, wantErr := tokenize(NewLexerFromString(input))
+			readers := map[string]io.Reader{
+				"reader":   strings.NewReader(input),
+				"one byte": iotest.OneByteReader(strings.NewReader(input)),
+				"half":     iotest.HalfReader(strings.NewReader(input)),
+			}
+			for name, r := range readers {
+				got, err := tokenize(NewLexer(r))
+				if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+					t.Errorf("%s: expected error %v, got %v", name, wantErr, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: tokens differ from the string lexer:\n%v\n%v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestReaderBoundedBuffer checks that a long stream is lexed in constant
+// memory
+func TestReaderBoundedBuffer(t *testing.T) {
+	const entries = 100000
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < entries; i++ {
+			fmt.Fprintf(pw, "- item: %d # entry\n  text: |\n    block %d\n", i, i)
+		}
+		pw.Close()
+	}()
+
+	l := NewLexer(pr)
+	if err := l.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+	scalars := 0
+	for {
+		token, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if token.Type == TokenEOF {
+			break
+		}
+		if token.Type == TokenLiteralScalar {
+			scalars++
+		}
+		if cap(l.buf) > 4*readSize {
+			t.Fatalf("Buffer grew to %d bytes at line %d", cap(l.buf), token.Line)
+		}
+	}
+	if scalars != entries {
+		t.Errorf("Expected %d block scalars, got %d", entries, scalars)
+	}
+}
+
+// TestReaderError checks that a failing reader ends the tokens with its error
+func TestReaderError(t *testing.T) {
+	failure := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("a: 1\nb: 2\n"), iotest.ErrReader(failure))
+
+	tokens, err := tokenize(NewLexer(r))
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the read error, got %v", err)
+	}
+	if len(tokens) == 0 || tokens[len(tokens)-1].Value != "2" {
+		t.Errorf("Expected the tokens read before the error, got %v", tokens)
+	}
+}
+
+// tokenize returns the tokens of l up to EOF or the first error
+func tokenize(l *Lexer) ([]Token, error) {
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+	var tokens []Token
+	for {
+		token, err := l.NextToken()
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, *token)
+		if token.Type == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// TestReaderEncodings checks that UTF-16 and UTF-32 input yields the tokens
+// of the same text in UTF-8
+func TestReaderEncodings(t *testing.T) {
+	text := "# héad\nkey: välue\nlist: [1, 😀]\nanchor: &ä x\n"
+	want, err := tokenize(NewLexerFromString(text))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, enc := range []charset.Encoding{charset.UTF8, charset.UTF16LE, charset.UTF16BE, charset.UTF32LE, charset.UTF32BE} {
+		for _, bom := range []bool{false, true} {
+			data := charset.Encode(text, enc, bom)
+			lexers := map[string]*Lexer{
+				"reader": NewLexer(iotest.HalfReader(bytes.NewReader(data))),
+				"string": NewLexerFromString(string(data)),
+			}
+			for name, l := range lexers {
+				got, err := tokenize(l)
+				if err != nil {
+					t.Fatalf("%v (BOM %v) %s: unexpected error: %v", enc, bom, name, err)
+				}
+				if fmt.Sprint(values(got)) != fmt.Sprint(values(want)) {
+					t.Errorf("%v (BOM %v) %s: expected tokens %q, got %q", enc, bom, name, values(want), values(got))
+				}
+			}
+		}
+	}
+}
+
+// values returns the types and values of tokens
+func values(tokens []Token) []string {
+	var out []string
+	for _, token := range tokens {
+		out = append(out, token.Type.String()+" "+token.Value)
+	}
+	return out
+}
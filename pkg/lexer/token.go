@@ -37,9 +37,11 @@ const (
 	TokenDirective // %
 	TokenComment   // #
 
-	// Whitespace
+	// Whitespace, reported only by lossless tokenizing
 	TokenNewLine
-	TokenIndent
+	TokenIndent     // spaces and tabs at the start of a line
+	TokenWhitespace // spaces and tabs after content
+	TokenByteOrderMark
 )
 
 // Token represents a lexical token in YAML
@@ -51,6 +53,19 @@ type Token struct {
 	Offset  int
 	EndLine int
 	EndCol  int
+
+	// EndOffset is the byte offset just after the token text. Together
+	// with EndLine and EndCol it excludes whitespace consumed after the
+	// token.
+	EndOffset int
+
+	// Text is the source of the token, set by Tokenize
+	Text string
+
+	// Unterminated is set on a quoted scalar whose closing quote is
+	// missing, so that it runs to the end of the input
+	Unterminated bool
+
 	Style   ScalarStyle
 	Indent  int
 	IsKey   bool
@@ -100,6 +115,8 @@ func (t TokenType) String() string {
 		TokenComment:            "COMMENT",
 		TokenNewLine:            "NEWLINE",
 		TokenIndent:             "INDENT",
+		TokenWhitespace:         "WHITESPACE",
+		TokenByteOrderMark:      "BOM",
 	}
 	if name, ok := names[t]; ok {
 		return name
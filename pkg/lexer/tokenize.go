@@ -0,0 +1,105 @@
+package lexer
+
+import "bytes"
+
+// TokenizeOptions configures Tokenize
+type TokenizeOptions struct {
+	// Lossless adds trivia tokens for the whitespace, line breaks and byte
+	// order mark between the other tokens, so the Text of the tokens
+	// concatenates back to the input byte for byte
+	Lossless bool
+}
+
+// Tokenize returns the tokens of input up to and including EOF, each with
+// its source Text. Comments are included. Input in UTF-16 or UTF-32 is
+// transcoded first, and offsets and texts refer to its UTF-8 form.
+func Tokenize(input string) ([]Token, error) {
+	return TokenizeWithOptions(input, nil)
+}
+
+// TokenizeWithOptions is Tokenize with options. A nil opts uses the zero
+// value, which leaves out trivia.
+func TokenizeWithOptions(input string, opts *TokenizeOptions) ([]Token, error) {
+	if opts == nil {
+		opts = &TokenizeOptions{}
+	}
+
+	l := NewLexerFromString(input)
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]Token, 0)
+	end, line, column := 0, 1, 1 // just after the last token
+	for {
+		token, err := l.NextToken()
+		if err != nil {
+			return tokens, err
+		}
+
+		start := min(token.Offset, len(l.buf))
+		if opts.Lossless {
+			tokens = l.appendTrivia(tokens, end, start, line, column)
+		}
+		token.Text = l.text(start, token.EndOffset)
+		tokens = append(tokens, *token)
+		if token.EndOffset > end {
+			end, line, column = token.EndOffset, token.EndLine, token.EndCol
+		}
+
+		if token.Type == TokenEOF {
+			if opts.Lossless && end < len(l.buf) {
+				// Trivia after the last token goes before EOF
+				eof := tokens[len(tokens)-1]
+				tokens = l.appendTrivia(tokens[:len(tokens)-1], end, len(l.buf), line, column)
+				tokens = append(tokens, eof)
+			}
+			return tokens, nil
+		}
+	}
+}
+
+// appendTrivia appends the tokens of the whitespace between two offsets,
+// the first at line and column
+func (l *Lexer) appendTrivia(tokens []Token, start, end, line, column int) []Token {
+	for start < end {
+		typ, size := TokenWhitespace, 1
+		switch text := l.buf[start:end]; {
+		case start == 0 && bytes.HasPrefix(text, []byte(byteOrderMark)):
+			typ, size = TokenByteOrderMark, len(byteOrderMark)
+		case text[0] == '\n':
+			typ = TokenNewLine
+		case bytes.HasPrefix(text, []byte("\r\n")):
+			typ, size = TokenNewLine, 2
+		default:
+			if column == 1 {
+				typ = TokenIndent
+			}
+			for size < len(text) && (text[size] == ' ' || text[size] == '\t') {
+				size++
+			}
+		}
+
+		token := Token{
+			Type:      typ,
+			Text:      l.text(start, start+size),
+			Line:      line,
+			Column:    column,
+			Offset:    start,
+			EndLine:   line,
+			EndCol:    column + size,
+			EndOffset: start + size,
+		}
+		if typ == TokenNewLine {
+			token.EndLine, token.EndCol = line+1, 1
+			line, column = line+1, 1
+		} else if typ == TokenByteOrderMark {
+			token.EndCol = column
+		} else {
+			column += size
+		}
+		tokens = append(tokens, token)
+		start += size
+	}
+	return tokens
+}
@@ -0,0 +1,101 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := Tokenize("key: 'it''s' # note\nlist: [a, -1]\n")
+	if err != nil {
+		t.Fatalf("Tokenize error: %v", err)
+	}
+
+	var got []string
+	for _, token := range tokens {
+		got = append(got, fmt.Sprintf("%s %q", token.Type, token.Text))
+	}
+	want := []string{
+		`PLAIN_SCALAR "key"`,
+		`MAPPING_VALUE ":"`,
+		`SINGLE_QUOTED "'it''s'"`,
+		`COMMENT "# note"`,
+		`PLAIN_SCALAR "list"`,
+		`MAPPING_VALUE ":"`,
+		`FLOW_SEQ_START "["`,
+		`PLAIN_SCALAR "a"`,
+		`FLOW_ENTRY ","`,
+		`PLAIN_SCALAR "-1"`,
+		`FLOW_SEQ_END "]"`,
+		`EOF ""`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected tokens:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestTokenizeLossless(t *testing.T) {
+	inputs := []string{
+		"",
+		"key: value",
+		"\uFEFF# head\n\nkey:   value  # comment\n",
+		"list:\n  - 'a''b'\n  -\t[1, {x: y}]\r\n",
+		"text: |\n  one\n\n  two\n\n\n...\n---\n\"q\\n\": >-\n  folded\n   \n",
+		"%YAML 1.2\n--- !!map\n? &a complex\n: *a\n",
+		"   \n\t\n",
+		"key: |",
+		"\"unterminated\\",
+	}
+
+	for _, input := range inputs {
+		t.Run(fmt.Sprintf("%q", input), func(t *testing.T) {
+			tokens, err := TokenizeWithOptions(input, &TokenizeOptions{Lossless: true})
+			if err != nil {
+				t.Fatalf("Tokenize error: %v", err)
+			}
+
+			var sb strings.Builder
+			for i, token := range tokens {
+				if token.Text != input[token.Offset:token.EndOffset] {
+					t.Errorf("Token %d: text %q does not match offsets %d-%d", i, token.Text, token.Offset, token.EndOffset)
+				}
+				sb.WriteString(token.Text)
+			}
+			if sb.String() != input {
+				t.Errorf("Tokens concatenate to %q", sb.String())
+			}
+			if last := tokens[len(tokens)-1]; last.Type != TokenEOF {
+				t.Errorf("Expected EOF last, got %s", last.Type)
+			}
+		})
+	}
+}
+
+func TestTokenizeTrivia(t *testing.T) {
+	tokens, err := TokenizeWithOptions("a:  b\n  - c\n", &TokenizeOptions{Lossless: true})
+	if err != nil {
+		t.Fatalf("Tokenize error: %v", err)
+	}
+
+	var got []string
+	for _, token := range tokens {
+		got = append(got, fmt.Sprintf("%s %q %d:%d-%d:%d", token.Type, token.Text, token.Line, token.Column, token.EndLine, token.EndCol))
+	}
+	want := []string{
+		`PLAIN_SCALAR "a" 1:1-1:2`,
+		`MAPPING_VALUE ":" 1:2-1:3`,
+		`WHITESPACE "  " 1:3-1:5`,
+		`PLAIN_SCALAR "b" 1:5-1:6`,
+		`NEWLINE "\n" 1:6-2:1`,
+		`INDENT "  " 2:1-2:3`,
+		`SEQUENCE_ENTRY "-" 2:3-2:4`,
+		`WHITESPACE " " 2:4-2:5`,
+		`PLAIN_SCALAR "c" 2:5-2:6`,
+		`NEWLINE "\n" 2:6-3:1`,
+		`EOF "" 3:1-3:1`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected tokens:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
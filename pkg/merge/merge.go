@@ -33,14 +33,18 @@ func MergeStrings(baseYAML, overrideYAML string) (string, error) {
 
 // MergeStringsWithOptions merges two YAML strings with the specified options
 func MergeStringsWithOptions(baseYAML, overrideYAML string, opts *Options) (string, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
 	// Parse base YAML
-	baseNode, err := parser.ParseString(baseYAML)
+	baseNode, err := parser.ParseStringWithOptions(baseYAML, opts.Parser)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse base YAML: %w", err)
 	}
 
 	// Parse override YAML
-	overrideNode, err := parser.ParseString(overrideYAML)
+	overrideNode, err := parser.ParseStringWithOptions(overrideYAML, opts.Parser)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse override YAML: %w", err)
 	}
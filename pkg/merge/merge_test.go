@@ -3,6 +3,9 @@ package merge
 import (
 	"strings"
 	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
 )
 
 func TestMergeStrings(t *testing.T) {
@@ -332,6 +335,93 @@ env: production`
 	})
 }
 
+func TestNullDeletes(t *testing.T) {
+	base := `name: app
+debug: true
+image:
+  tag: latest
+  digest: sha256`
+
+	override := `debug: null
+image:
+  digest: ~
+extra: null`
+
+	t.Run("null deletes keys", func(t *testing.T) {
+		opts := DefaultOptions().WithNullDeletes(true)
+		result, err := MergeStringsWithOptions(base, override, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, removed := range []string{"debug", "digest", "extra"} {
+			if strings.Contains(result, removed) {
+				t.Errorf("expected %q to be removed, got:\n%s", removed, result)
+			}
+		}
+		if !strings.Contains(result, "tag: latest") {
+			t.Errorf("expected untouched keys to remain, got:\n%s", result)
+		}
+	})
+
+	t.Run("quoted null is a value", func(t *testing.T) {
+		opts := DefaultOptions().WithNullDeletes(true)
+		result, err := MergeStringsWithOptions(base, `debug: "null"`, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, "debug:") {
+			t.Errorf("quoted null should not delete the key, got:\n%s", result)
+		}
+	})
+}
+
+func TestMergeDocuments(t *testing.T) {
+	base := &node.DocumentNode{
+		Directives: []node.Directive{{Name: "YAML", Parameters: []string{"1.2"}}},
+		Content: &node.MappingNode{Style: node.StyleBlock, Pairs: []*node.MappingPair{
+			{Key: &node.ScalarNode{Value: "a", Style: node.StylePlain}, Value: &node.ScalarNode{Value: "1", Style: node.StylePlain}},
+		}},
+	}
+	shared := &node.ScalarNode{Value: "2", Style: node.StylePlain}
+	override := &node.DocumentNode{Content: &node.MappingNode{Style: node.StyleBlock, Pairs: []*node.MappingPair{
+		{Key: &node.ScalarNode{Value: "a", Style: node.StylePlain}, Value: &node.AliasNode{Name: "b", Target: shared}},
+	}}}
+
+	result, err := Merge(base, override)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	doc, ok := result.(*node.DocumentNode)
+	if !ok || len(doc.Directives) != 1 {
+		t.Fatalf("expected the base document to be kept, got %#v", result)
+	}
+	value := doc.Content.(*node.MappingNode).Pairs[0].Value
+	if scalar, ok := value.(*node.ScalarNode); !ok || scalar.Value != "2" {
+		t.Errorf("expected alias target to be merged, got %#v", value)
+	}
+}
+
+func TestMergeStringsParserOptions(t *testing.T) {
+	base := "# service settings\nname: web\nport: 80\n"
+	override := "port: 8080\n"
+
+	opts := DefaultOptions().WithParserOptions(&parser.Options{DiscardComments: true})
+	result, err := MergeStringsWithOptions(base, override, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(result, "#") || !strings.Contains(result, "port: 8080") {
+		t.Errorf("expected comments to be dropped while parsing, got:\n%s", result)
+	}
+
+	opts = DefaultOptions().WithParserOptions(&parser.Options{DuplicateKeys: parser.DuplicateKeysError})
+	if _, err := MergeStringsWithOptions(base, "port: 1\nport: 2\n", opts); err == nil {
+		t.Error("expected duplicate keys in the override to be rejected")
+	}
+}
+
 func TestErrorHandling(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -48,6 +48,21 @@ func (m *Merger) Merge(base, override node.Node) (node.Node, error) {
 		Path:    []string{},
 	}
 
+	// Documents merge by content; the result keeps the directives, markers
+	// and comments of the base document
+	if doc, ok := override.(*node.DocumentNode); ok {
+		override = doc.Content
+	}
+	if doc, ok := base.(*node.DocumentNode); ok {
+		content, err := m.Merge(doc.Content, override)
+		if err != nil {
+			return nil, err
+		}
+		merged := *doc
+		merged.Content = content
+		return &merged, nil
+	}
+
 	// Perform merge
 	result, err := m.strategy.Merge(base, override, ctx)
 	if err != nil {
@@ -1,6 +1,9 @@
 package merge
 
-import "github.com/elioetibr/golang-yaml/pkg/node"
+import (
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
 
 // Strategy defines how values-with-comments should be merged
 type Strategy int
@@ -41,6 +44,14 @@ type Options struct {
 
 	// KeyPriority defines which document's keys take priority for ordering
 	KeyPriority KeyPriority
+
+	// NullDeletes makes a null value in the override remove the key from the
+	// result, as in RFC 7386 merge patches (deep strategy only)
+	NullDeletes bool
+
+	// Parser configures how MergeStrings and MergeFiles parse their inputs.
+	// Nil uses parser.DefaultOptions.
+	Parser *parser.Options
 }
 
 // ArrayMergeStrategy defines how arrays should be merged
@@ -102,6 +113,18 @@ func (o *Options) WithKeyPriority(p KeyPriority) *Options {
 	return o
 }
 
+// WithNullDeletes returns options where null override values delete keys
+func (o *Options) WithNullDeletes(deletes bool) *Options {
+	o.NullDeletes = deletes
+	return o
+}
+
+// WithParserOptions returns options that parse inputs with opts
+func (o *Options) WithParserOptions(opts *parser.Options) *Options {
+	o.Parser = opts
+	return o
+}
+
 // WithOverrideEmpty returns options with the specified override empty behavior
 func (o *Options) WithOverrideEmpty(override bool) *Options {
 	o.OverrideEmpty = override
@@ -1,6 +1,9 @@
 package merge
 
-import "github.com/elioetibr/golang-yaml/pkg/node"
+import (
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
 
 // NodeProcessor handles common node processing operations
 type NodeProcessor struct{}
@@ -103,6 +106,15 @@ func (p *NodeProcessor) GetScalarValue(n node.Node) (string, bool) {
 	return "", false
 }
 
+// IsNull reports whether n is an untagged null scalar (null, ~ or empty)
+func (p *NodeProcessor) IsNull(n node.Node) bool {
+	scalar, ok := n.(*node.ScalarNode)
+	if !ok || scalar.Tag() != "" || (scalar.Style != node.StylePlain && scalar.Style != node.StyleAny) {
+		return false
+	}
+	return parser.InferTag(scalar.Value) == parser.CommonTags.Null
+}
+
 // PreserveKeyNode preserves comments from key nodes
 func (p *NodeProcessor) PreserveKeyNode(baseKey, overrideKey node.Node, opts *Options) node.Node {
 	if !opts.PreserveComments {
@@ -32,6 +32,9 @@ func (s *DeepMergeStrategy) Merge(base, override node.Node, ctx *Context) (node.
 		return override, nil
 	}
 
+	// Aliases merge as the nodes they refer to
+	base, override = node.Resolve(base), node.Resolve(override)
+
 	// Type-specific merging
 	switch baseNode := base.(type) {
 	case *node.MappingNode:
@@ -96,6 +99,10 @@ func (s *DeepMergeStrategy) mergeMappings(base *node.MappingNode, override node.
 		processedKeys[key] = true
 
 		if overridePair, exists := overrideMap[key]; exists {
+			if ctx.Options.NullDeletes && s.processor.IsNull(overridePair.Value) {
+				continue
+			}
+
 			// Key exists in override, merge values-with-comments
 			mergedValue, err := s.Merge(basePair.Value, overridePair.Value, ctx.WithPath(key))
 			if err != nil {
@@ -150,6 +157,9 @@ func (s *DeepMergeStrategy) mergeMappings(base *node.MappingNode, override node.
 		if !ok || processedKeys[key] {
 			continue
 		}
+		if ctx.Options.NullDeletes && s.processor.IsNull(overridePair.Value) {
+			continue
+		}
 
 		// Clean head comment from scalar values-with-comments
 		cleanedValue := s.processor.CleanScalarHeadComment(overridePair.Value)
@@ -31,8 +31,8 @@ func (s *ShallowMergeStrategy) Merge(base, override node.Node, ctx *Context) (no
 	}
 
 	// Only merge if both are mappings
-	baseMapping, baseOk := base.(*node.MappingNode)
-	overrideMapping, overrideOk := override.(*node.MappingNode)
+	baseMapping, baseOk := node.Resolve(base).(*node.MappingNode)
+	overrideMapping, overrideOk := node.Resolve(override).(*node.MappingNode)
 
 	if !baseOk || !overrideOk {
 		// Not both mappings, return override
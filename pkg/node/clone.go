@@ -0,0 +1,166 @@
+package node
+
+// CloneOptions controls which metadata Clone copies. Values, tags,
+// anchors, aliases and styles are always copied.
+type CloneOptions struct {
+	KeepComments  bool // comments and blank line counts on nodes and pairs
+	KeepPositions bool // line and column numbers and source ranges
+}
+
+// DefaultCloneOptions returns options that copy everything
+func DefaultCloneOptions() *CloneOptions {
+	return &CloneOptions{
+		KeepComments:  true,
+		KeepPositions: true,
+	}
+}
+
+// Clone returns a deep copy of n that shares no nodes, pairs or comment
+// groups with the original. Aliases point at the copy of their target when
+// it is part of n. A nil opts copies everything.
+func Clone(n Node, opts *CloneOptions) Node {
+	if opts == nil {
+		opts = DefaultCloneOptions()
+	}
+
+	c := &cloner{opts: opts, copies: make(map[Node]Node)}
+	result := c.cloneNode(n)
+	for _, alias := range c.aliases {
+		if target, ok := c.copies[alias.Target]; ok {
+			alias.Target = target
+		}
+	}
+	return result
+}
+
+type cloner struct {
+	opts    *CloneOptions
+	copies  map[Node]Node // anchored originals to their copies
+	aliases []*AliasNode  // copied aliases whose target may need remapping
+}
+
+func (c *cloner) cloneNode(n Node) Node {
+	var clone Node
+	switch v := n.(type) {
+	case *ScalarNode:
+		clone = &ScalarNode{
+			BaseNode: cloneBase(&v.BaseNode, c.opts),
+			Value:    v.Value,
+			Style:    v.Style,
+			Alias:    v.Alias,
+		}
+	case *SequenceNode:
+		seq := &SequenceNode{
+			BaseNode: cloneBase(&v.BaseNode, c.opts),
+			Style:    v.Style,
+		}
+		if v.Items != nil {
+			seq.Items = make([]Node, len(v.Items))
+			for i, item := range v.Items {
+				seq.Items[i] = c.cloneNode(item)
+			}
+		}
+		clone = seq
+	case *MappingNode:
+		m := &MappingNode{
+			BaseNode: cloneBase(&v.BaseNode, c.opts),
+			Style:    v.Style,
+		}
+		if v.Pairs != nil {
+			m.Pairs = make([]*MappingPair, len(v.Pairs))
+			for i, pair := range v.Pairs {
+				m.Pairs[i] = c.clonePair(pair)
+			}
+		}
+		clone = m
+	case *DocumentNode:
+		clone = &DocumentNode{
+			BaseNode:      cloneBase(&v.BaseNode, c.opts),
+			Directives:    cloneDirectives(v.Directives),
+			Content:       c.cloneNode(v.Content),
+			ExplicitStart: v.ExplicitStart,
+			ExplicitEnd:   v.ExplicitEnd,
+		}
+	case *AliasNode:
+		alias := &AliasNode{
+			BaseNode: cloneBase(&v.BaseNode, c.opts),
+			Name:     v.Name,
+			Target:   v.Target,
+		}
+		c.aliases = append(c.aliases, alias)
+		clone = alias
+	default:
+		// nil and node types unknown to this package are returned as is
+		return n
+	}
+
+	if n.Anchor() != "" {
+		c.copies[n] = clone
+	}
+	return clone
+}
+
+func (c *cloner) clonePair(pair *MappingPair) *MappingPair {
+	if pair == nil {
+		return nil
+	}
+
+	clone := &MappingPair{
+		Key:   c.cloneNode(pair.Key),
+		Value: c.cloneNode(pair.Value),
+	}
+	if c.opts.KeepComments {
+		clone.KeyComment = cloneComments(pair.KeyComment, c.opts)
+		clone.ValueComment = cloneComments(pair.ValueComment, c.opts)
+		clone.BlankLinesBefore = pair.BlankLinesBefore
+		clone.BlankLinesAfter = pair.BlankLinesAfter
+	}
+	return clone
+}
+
+func cloneDirectives(directives []Directive) []Directive {
+	if directives == nil {
+		return nil
+	}
+	clone := make([]Directive, len(directives))
+	for i, d := range directives {
+		clone[i] = Directive{Name: d.Name, Parameters: append([]string(nil), d.Parameters...)}
+	}
+	return clone
+}
+
+func cloneBase(base *BaseNode, opts *CloneOptions) BaseNode {
+	clone := BaseNode{
+		TagValue:     base.TagValue,
+		TagShorthand: base.TagShorthand,
+		AnchorValue:  base.AnchorValue,
+		StyleHint:    base.StyleHint,
+	}
+	if opts.KeepPositions {
+		clone.LineNumber = base.LineNumber
+		clone.ColumnNumber = base.ColumnNumber
+		clone.Range = base.Range
+	}
+	if opts.KeepComments {
+		clone.HeadComment = cloneComments(base.HeadComment, opts)
+		clone.LineComment = cloneComments(base.LineComment, opts)
+		clone.FootComment = cloneComments(base.FootComment, opts)
+		clone.BlankLinesBefore = base.BlankLinesBefore
+		clone.BlankLinesAfter = base.BlankLinesAfter
+	}
+	return clone
+}
+
+func cloneComments(cg *CommentGroup, opts *CloneOptions) *CommentGroup {
+	if cg == nil {
+		return nil
+	}
+	clone := &CommentGroup{
+		Comments:         append([]string(nil), cg.Comments...),
+		BlankLinesBefore: cg.BlankLinesBefore,
+	}
+	if opts.KeepPositions {
+		clone.Range = cg.Range
+	}
+	return clone
+}
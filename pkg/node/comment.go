@@ -98,8 +98,9 @@ func (cm *CommentManager) FormatCommentGroup(cg *CommentGroup, pos CommentPositi
 	return result
 }
 
-// AssociateComment associates a comment with a node based on position
-func AssociateComment(node Node, comment string, pos CommentPosition, blankLinesBefore int) {
+// AssociateComment associates a comment with a node based on position and
+// returns the group it was added to, or nil for unsupported node types
+func AssociateComment(node Node, comment string, pos CommentPosition, blankLinesBefore int) *CommentGroup {
 	// Get base node to set comment
 	var baseNode *BaseNode
 	switch n := node.(type) {
@@ -110,7 +111,7 @@ func AssociateComment(node Node, comment string, pos CommentPosition, blankLines
 	case *MappingNode:
 		baseNode = &n.BaseNode
 	default:
-		return
+		return nil
 	}
 
 	// Get or create the appropriate comment group
@@ -146,16 +147,19 @@ func AssociateComment(node Node, comment string, pos CommentPosition, blankLines
 			baseNode.FootComment = cg
 		}
 	}
+	return cg
 }
 
 // MergeCommentGroups merges multiple comment groups
 func MergeCommentGroups(groups ...*CommentGroup) *CommentGroup {
 	var allComments []string
+	var span Range
 	maxBlankLines := 0
 
 	for _, g := range groups {
 		if g != nil {
 			allComments = append(allComments, g.Comments...)
+			span = span.Union(g.Range)
 			if g.BlankLinesBefore > maxBlankLines {
 				maxBlankLines = g.BlankLinesBefore
 			}
@@ -169,5 +173,6 @@ func MergeCommentGroups(groups ...*CommentGroup) *CommentGroup {
 	return &CommentGroup{
 		Comments:         allComments,
 		BlankLinesBefore: maxBlankLines,
+		Range:            span,
 	}
 }
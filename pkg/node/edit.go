@@ -0,0 +1,318 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set stores value at path, replacing an existing value or adding a new key.
+// Missing intermediate mappings are created. value may be a Node or a Go
+// scalar; when a scalar replaces a scalar, the previous Style is kept unless
+// value is a Node, and comments of the replaced node carry over.
+func Set(root Node, path string, value interface{}) error {
+	parent, last, err := resolveParent(root, path, true)
+	if err != nil {
+		return err
+	}
+
+	newNode := toNode(value)
+	_, explicit := value.(Node)
+
+	switch p := parent.(type) {
+	case *MappingNode:
+		if last.isIndex {
+			return fmt.Errorf("%w: %q: cannot index mapping at line %d", ErrPathNotFound, path, p.Line())
+		}
+		if pair := findPair(p, last.key); pair != nil {
+			pair.Value = replaceNode(pair.Value, newNode, explicit)
+			return nil
+		}
+		p.Pairs = append(p.Pairs, &MappingPair{Key: &ScalarNode{Value: last.key, Style: StylePlain}, Value: newNode})
+	case *SequenceNode:
+		if !last.isIndex {
+			return fmt.Errorf("%w: %q: expected index into sequence at line %d", ErrPathNotFound, path, p.Line())
+		}
+		if last.index < 0 || last.index >= len(p.Items) {
+			return fmt.Errorf("%w: %q: index %d out of range at line %d", ErrPathNotFound, path, last.index, p.Line())
+		}
+		p.Items[last.index] = replaceNode(p.Items[last.index], newNode, explicit)
+	default:
+		return fmt.Errorf("%w: %q: cannot descend into scalar at line %d", ErrPathNotFound, path, parent.Line())
+	}
+	return nil
+}
+
+// Delete removes the mapping entry or sequence item at path. The head
+// comment of the removed entry goes with it, while the blank lines that
+// separated it from the previous entry are handed to the next one.
+func Delete(root Node, path string) error {
+	parent, last, err := resolveParent(root, path, false)
+	if err != nil {
+		return err
+	}
+
+	switch p := parent.(type) {
+	case *MappingNode:
+		if !last.isIndex {
+			for i, pair := range p.Pairs {
+				if isKey(pair, last.key) {
+					removePair(p, i)
+					return nil
+				}
+			}
+		}
+	case *SequenceNode:
+		if last.isIndex && last.index >= 0 && last.index < len(p.Items) {
+			removeItem(p, last.index)
+			return nil
+		}
+	}
+
+	_, err = Lookup(root, path)
+	if err == nil {
+		err = fmt.Errorf("%w: %q", ErrPathNotFound, path)
+	}
+	return err
+}
+
+// InsertAfter adds key: value to the mapping containing path, directly after the entry at path
+func InsertAfter(root Node, path string, key string, value interface{}) error {
+	return insertPair(root, path, key, value, 1)
+}
+
+// InsertBefore adds key: value to the mapping containing path, directly before the entry at path
+func InsertBefore(root Node, path string, key string, value interface{}) error {
+	return insertPair(root, path, key, value, 0)
+}
+
+// Append adds item to the end of the sequence at path, creating the
+// sequence and any missing intermediate mappings
+func Append(root Node, path string, item interface{}) error {
+	target, err := Lookup(root, path)
+	if err != nil {
+		if err := Set(root, path, &SequenceNode{Style: StyleBlock}); err != nil {
+			return err
+		}
+		if target, err = Lookup(root, path); err != nil {
+			return err
+		}
+	}
+
+	seq, ok := target.(*SequenceNode)
+	if !ok {
+		if !isNull(target) {
+			return fmt.Errorf("%q is not a sequence (line %d)", path, target.Line())
+		}
+		// An empty value such as "env:" becomes a sequence
+		seq = &SequenceNode{Style: StyleBlock}
+		if err := Set(root, path, seq); err != nil {
+			return err
+		}
+	}
+
+	seq.Items = append(seq.Items, toNode(item))
+	return nil
+}
+
+// insertPair inserts a new entry next to the one at path; offset 0 inserts
+// before it and 1 after it
+func insertPair(root Node, path string, key string, value interface{}, offset int) error {
+	parent, last, err := resolveParent(root, path, false)
+	if err != nil {
+		return err
+	}
+
+	mapping, ok := parent.(*MappingNode)
+	if !ok || last.isIndex {
+		return fmt.Errorf("%q is not a mapping entry", path)
+	}
+	if findPair(mapping, key) != nil {
+		return fmt.Errorf("key %q already exists in mapping at line %d", key, mapping.Line())
+	}
+
+	for i, pair := range mapping.Pairs {
+		if !isKey(pair, last.key) {
+			continue
+		}
+		newPair := &MappingPair{Key: &ScalarNode{Value: key, Style: StylePlain}, Value: toNode(value)}
+		at := i + offset
+		mapping.Pairs = append(mapping.Pairs, nil)
+		copy(mapping.Pairs[at+1:], mapping.Pairs[at:])
+		mapping.Pairs[at] = newPair
+		return nil
+	}
+	return fmt.Errorf("%w: %q: key %q missing at line %d", ErrPathNotFound, path, last.key, mapping.Line())
+}
+
+// resolveParent walks to the node containing the last segment of path.
+// With create set, missing or empty intermediate mapping values become mappings.
+func resolveParent(root Node, path string, create bool) (Node, pathSegment, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, pathSegment{}, err
+	}
+	if len(segments) == 0 {
+		return nil, pathSegment{}, fmt.Errorf("invalid path %q: empty", path)
+	}
+	if root == nil {
+		return nil, pathSegment{}, fmt.Errorf("%w: %q: empty document", ErrPathNotFound, path)
+	}
+
+	current := Resolve(root)
+	for _, seg := range segments[:len(segments)-1] {
+		mapping, ok := current.(*MappingNode)
+		if !create || !ok || seg.isIndex {
+			next, err := child(current, seg)
+			if err != nil {
+				return nil, pathSegment{}, fmt.Errorf("%w: %q: %s", ErrPathNotFound, path, err)
+			}
+			current = Resolve(next)
+			continue
+		}
+
+		pair := findPair(mapping, seg.key)
+		switch {
+		case pair == nil:
+			pair = &MappingPair{
+				Key:   &ScalarNode{Value: seg.key, Style: StylePlain},
+				Value: &MappingNode{Style: StyleBlock},
+			}
+			mapping.Pairs = append(mapping.Pairs, pair)
+		case isNull(pair.Value):
+			pair.Value = replaceNode(pair.Value, &MappingNode{Style: StyleBlock}, true)
+		}
+		current = Resolve(pair.Value)
+	}
+
+	return current, segments[len(segments)-1], nil
+}
+
+// replaceNode carries comments, blank lines and, for scalars, the style of
+// old over to replacement
+func replaceNode(old, replacement Node, explicit bool) Node {
+	oldBase, newBase := baseOf(old), baseOf(replacement)
+	if oldBase == nil || newBase == nil {
+		return replacement
+	}
+
+	if newBase.HeadComment == nil {
+		newBase.HeadComment = oldBase.HeadComment
+	}
+	if newBase.LineComment == nil {
+		newBase.LineComment = oldBase.LineComment
+	}
+	if newBase.FootComment == nil {
+		newBase.FootComment = oldBase.FootComment
+	}
+	if newBase.BlankLinesBefore == 0 {
+		newBase.BlankLinesBefore = oldBase.BlankLinesBefore
+	}
+	if newBase.BlankLinesAfter == 0 {
+		newBase.BlankLinesAfter = oldBase.BlankLinesAfter
+	}
+
+	oldScalar, oldOK := old.(*ScalarNode)
+	newScalar, newOK := replacement.(*ScalarNode)
+	if oldOK && newOK && !explicit {
+		// Block styles are only kept for text that still spans several lines
+		isBlock := oldScalar.Style == StyleLiteral || oldScalar.Style == StyleFolded
+		if !isBlock || strings.Contains(newScalar.Value, "\n") {
+			newScalar.Style = oldScalar.Style
+		}
+	}
+	return replacement
+}
+
+// removePair deletes the i-th entry of m, keeping the separation before it
+func removePair(m *MappingNode, i int) {
+	if i+1 < len(m.Pairs) {
+		pair, next := m.Pairs[i], m.Pairs[i+1]
+		keepSeparation(baseOf(pair.Key), baseOf(next.Key))
+		if pair.BlankLinesBefore > next.BlankLinesBefore {
+			next.BlankLinesBefore = pair.BlankLinesBefore
+		}
+	}
+	m.Pairs = append(m.Pairs[:i], m.Pairs[i+1:]...)
+}
+
+// removeItem deletes the i-th item of s, keeping the separation before it
+func removeItem(s *SequenceNode, i int) {
+	if i+1 < len(s.Items) {
+		keepSeparation(baseOf(s.Items[i]), baseOf(s.Items[i+1]))
+	}
+	s.Items = append(s.Items[:i], s.Items[i+1:]...)
+}
+
+// keepSeparation moves the blank lines before a removed node to the node that follows it
+func keepSeparation(removed, next *BaseNode) {
+	if removed == nil || next == nil {
+		return
+	}
+
+	blank := removed.BlankLinesBefore
+	if removed.HeadComment != nil && removed.HeadComment.BlankLinesBefore > blank {
+		blank = removed.HeadComment.BlankLinesBefore
+	}
+
+	if next.HeadComment != nil {
+		if blank > next.HeadComment.BlankLinesBefore {
+			next.HeadComment.BlankLinesBefore = blank
+		}
+		return
+	}
+	if blank > next.BlankLinesBefore {
+		next.BlankLinesBefore = blank
+	}
+}
+
+// toNode converts a Go value into a node
+func toNode(value interface{}) Node {
+	switch v := value.(type) {
+	case Node:
+		return v
+	case nil:
+		return &ScalarNode{Value: "null", Style: StylePlain}
+	case string:
+		return &ScalarNode{Value: v, Style: StylePlain}
+	default:
+		return &ScalarNode{Value: fmt.Sprint(v), Style: StylePlain}
+	}
+}
+
+// findPair returns the entry of m whose scalar key equals key
+func findPair(m *MappingNode, key string) *MappingPair {
+	for _, pair := range m.Pairs {
+		if isKey(pair, key) {
+			return pair
+		}
+	}
+	return nil
+}
+
+func isKey(pair *MappingPair, key string) bool {
+	k, ok := pair.Key.(*ScalarNode)
+	return ok && k.Value == key
+}
+
+// isNull reports whether n is missing or an empty/null plain scalar
+func isNull(n Node) bool {
+	if n == nil {
+		return true
+	}
+	s, ok := n.(*ScalarNode)
+	if !ok || n.Tag() != "" || (s.Style != StylePlain && s.Style != StyleAny) {
+		return false
+	}
+	switch s.Value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+func baseOf(n Node) *BaseNode {
+	if b, ok := n.(interface{ GetBase() *BaseNode }); ok {
+		return b.GetBase()
+	}
+	return nil
+}
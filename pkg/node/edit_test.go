@@ -0,0 +1,154 @@
+package node_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+const deployment = `# Image settings
+image:
+  repository: nginx
+  tag: "1.0"  # pinned version
+debug: true
+
+# Resource limits
+resources:
+  cpu: 100m
+env:
+  - name: A
+`
+
+func edit(t *testing.T, input string, fn func(root node.Node) error) string {
+	t.Helper()
+	root, err := parser.ParseString(input)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := fn(root); err != nil {
+		t.Fatalf("edit failed: %v", err)
+	}
+	output, err := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+	return output
+}
+
+func TestSet(t *testing.T) {
+	t.Run("keeps style and line comment", func(t *testing.T) {
+		output := edit(t, deployment, func(root node.Node) error {
+			return node.Set(root, "image.tag", "1.2.3")
+		})
+		if !strings.Contains(output, `tag: "1.2.3"  # pinned version`) {
+			t.Errorf("expected quoted value with comment, got:\n%s", output)
+		}
+	})
+
+	t.Run("creates intermediate mappings", func(t *testing.T) {
+		output := edit(t, deployment, func(root node.Node) error {
+			return node.Set(root, "ingress.tls.enabled", true)
+		})
+		if !strings.Contains(output, "ingress:\n  tls:\n    enabled: true") {
+			t.Errorf("expected nested mappings, got:\n%s", output)
+		}
+	})
+
+	t.Run("replaces sequence item", func(t *testing.T) {
+		output := edit(t, "ports:\n  - 80\n  - 443\n", func(root node.Node) error {
+			return node.Set(root, "ports[1]", 8443)
+		})
+		if !strings.Contains(output, "- 80\n  - 8443") {
+			t.Errorf("expected replaced item, got:\n%s", output)
+		}
+	})
+
+	t.Run("cannot descend into scalar", func(t *testing.T) {
+		root, _ := parser.ParseString(deployment)
+		err := node.Set(root, "debug.level", 1)
+		if !errors.Is(err, node.ErrPathNotFound) {
+			t.Errorf("expected ErrPathNotFound, got %v", err)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("removes entry and its head comment", func(t *testing.T) {
+		output := edit(t, deployment, func(root node.Node) error {
+			return node.Delete(root, "image")
+		})
+		if strings.Contains(output, "Image settings") || strings.Contains(output, "repository") {
+			t.Errorf("expected image entry and comment removed, got:\n%s", output)
+		}
+		if !strings.HasPrefix(output, "debug: true\n\n# Resource limits\nresources:") {
+			t.Errorf("unexpected output:\n%s", output)
+		}
+	})
+
+	t.Run("hands blank lines to the next entry", func(t *testing.T) {
+		output := edit(t, deployment, func(root node.Node) error {
+			return node.Delete(root, "resources")
+		})
+		if !strings.Contains(output, "debug: true\n\nenv:") {
+			t.Errorf("unexpected output:\n%s", output)
+		}
+
+		output = edit(t, "a: 1\n\n# section b\nb: 2\nc: 3\n", func(root node.Node) error {
+			return node.Delete(root, "b")
+		})
+		if output != "a: 1\n\nc: 3" {
+			t.Errorf("unexpected output:\n%q", output)
+		}
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		root, _ := parser.ParseString(deployment)
+		if err := node.Delete(root, "image.digest"); !errors.Is(err, node.ErrPathNotFound) {
+			t.Errorf("expected ErrPathNotFound, got %v", err)
+		}
+	})
+}
+
+func TestInsertAndAppend(t *testing.T) {
+	output := edit(t, deployment, func(root node.Node) error {
+		if err := node.InsertAfter(root, "resources", "replicas", 3); err != nil {
+			return err
+		}
+		if err := node.InsertBefore(root, "image.tag", "pullPolicy", "Always"); err != nil {
+			return err
+		}
+		if err := node.Append(root, "env", &node.MappingNode{
+			Style: node.StyleBlock,
+			Pairs: []*node.MappingPair{{
+				Key:   &node.ScalarNode{Value: "name", Style: node.StylePlain},
+				Value: &node.ScalarNode{Value: "B", Style: node.StylePlain},
+			}},
+		}); err != nil {
+			return err
+		}
+		return node.Append(root, "volumes", "data")
+	})
+
+	for _, want := range []string{
+		"repository: nginx\n  pullPolicy: Always\n  tag:",
+		"cpu: 100m\nreplicas: 3\nenv:",
+		"name: B",
+		"volumes:\n  - data",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	root, _ := parser.ParseString(deployment)
+	if err := node.InsertAfter(root, "resources", "debug", false); err == nil {
+		t.Error("expected error inserting a duplicate key")
+	}
+	if err := node.Append(root, "debug", "x"); err == nil {
+		t.Error("expected error appending to a scalar")
+	}
+}
@@ -0,0 +1,269 @@
+package node
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EqualOptions controls how Equal compares nodes. Comments, positions,
+// anchors and document directives never take part in the comparison.
+type EqualOptions struct {
+	// Semantic compares scalars by their resolved value, so 1 equals 0x1
+	// and true equals yes, and aliases by their targets. Otherwise scalar
+	// text, tag and style must match and aliases must have the same name.
+	Semantic bool
+
+	// IgnoreKeyOrder treats mappings with the same pairs in a different
+	// order as equal
+	IgnoreKeyOrder bool
+}
+
+// DefaultEqualOptions returns semantic comparison with significant key order
+func DefaultEqualOptions() *EqualOptions {
+	return &EqualOptions{
+		Semantic:       true,
+		IgnoreKeyOrder: false,
+	}
+}
+
+// Equal reports whether a and b hold the same content. A nil opts uses
+// DefaultEqualOptions.
+func Equal(a, b Node, opts *EqualOptions) bool {
+	if opts == nil {
+		opts = DefaultEqualOptions()
+	}
+	return bytes.Equal(canonical(a, opts), canonical(b, opts))
+}
+
+// Hash returns a hash of n that is stable across processes and releases.
+// Nodes that are Equal under DefaultEqualOptions hash alike, so comments,
+// positions and formatting do not affect the result.
+func Hash(n Node) uint64 {
+	h := fnv.New64a()
+	h.Write(canonical(n, DefaultEqualOptions()))
+	return h.Sum64()
+}
+
+// KeyID returns a string identifying a mapping key by its resolved value.
+// Keys that are Equal under DefaultEqualOptions, such as 1 and 0x1, have
+// the same ID.
+func KeyID(key Node) string {
+	return string(canonical(key, DefaultEqualOptions()))
+}
+
+// canonical encodes n so that two nodes are equal under opts exactly when
+// their encodings are. Every field is length-prefixed to keep the encoding
+// unambiguous.
+func canonical(n Node, opts *EqualOptions) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, n, opts)
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, n Node, opts *EqualOptions) {
+	switch v := n.(type) {
+	case *ScalarNode:
+		buf.WriteByte('s')
+		if v.Alias != "" {
+			writeField(buf, "*")
+			writeField(buf, v.Alias)
+			return
+		}
+		if opts.Semantic {
+			tag, value := resolveScalar(v)
+			writeField(buf, tag)
+			writeField(buf, value)
+			return
+		}
+		writeField(buf, v.Tag())
+		writeField(buf, v.Value)
+		writeField(buf, strconv.Itoa(int(textStyle(v.Style))))
+	case *SequenceNode:
+		buf.WriteByte('q')
+		writeField(buf, collectionTag(v.Tag(), "!!seq", opts))
+		writeLength(buf, len(v.Items))
+		for _, item := range v.Items {
+			writeCanonical(buf, item, opts)
+		}
+	case *MappingNode:
+		buf.WriteByte('m')
+		writeField(buf, collectionTag(v.Tag(), "!!map", opts))
+
+		pairs := make([][]byte, 0, len(v.Pairs))
+		for _, pair := range v.Pairs {
+			if pair == nil {
+				continue
+			}
+			var p bytes.Buffer
+			writeCanonical(&p, pair.Key, opts)
+			writeCanonical(&p, pair.Value, opts)
+			pairs = append(pairs, p.Bytes())
+		}
+		if opts.IgnoreKeyOrder {
+			sort.Slice(pairs, func(i, j int) bool { return bytes.Compare(pairs[i], pairs[j]) < 0 })
+		}
+		writeLength(buf, len(pairs))
+		for _, p := range pairs {
+			buf.Write(p)
+		}
+	case *DocumentNode:
+		// Directives and markers are syntax; only the content is compared
+		if !opts.Semantic {
+			buf.WriteByte('d')
+		}
+		writeCanonical(buf, v.Content, opts)
+	case *AliasNode:
+		if opts.Semantic && v.Target != nil {
+			writeCanonical(buf, v.Target, opts)
+			return
+		}
+		buf.WriteByte('a')
+		writeField(buf, v.Name)
+	case nil:
+		buf.WriteByte('0')
+	default:
+		// Unknown node types compare by kind and tag only
+		buf.WriteByte('?')
+		writeField(buf, strconv.Itoa(int(n.Type())))
+		writeField(buf, n.Tag())
+	}
+}
+
+func writeField(buf *bytes.Buffer, s string) {
+	writeLength(buf, len(s))
+	buf.WriteString(s)
+}
+
+func writeLength(buf *bytes.Buffer, n int) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], uint64(n))])
+}
+
+// textStyle folds styles that do not change how a scalar reads
+func textStyle(s Style) Style {
+	if s == StyleAny {
+		return StylePlain
+	}
+	return s
+}
+
+// collectionTag drops the default tag of a collection in semantic mode
+func collectionTag(tag, implicit string, opts *EqualOptions) string {
+	if !opts.Semantic {
+		return tag
+	}
+	if tag = shortTag(tag); tag == implicit || tag == "!" {
+		return ""
+	}
+	return tag
+}
+
+// shortTag writes tags from the YAML core schema in their !! form
+func shortTag(tag string) string {
+	if strings.HasPrefix(tag, "tag:yaml.org,2002:") {
+		return "!!" + strings.TrimPrefix(tag, "tag:yaml.org,2002:")
+	}
+	if strings.HasPrefix(tag, "!<tag:yaml.org,2002:") && strings.HasSuffix(tag, ">") {
+		return "!!" + strings.TrimSuffix(strings.TrimPrefix(tag, "!<tag:yaml.org,2002:"), ">")
+	}
+	return tag
+}
+
+// resolveScalar returns the tag of a scalar and a canonical form of its
+// value. Plain scalars are resolved like the parser does, with hexadecimal,
+// octal and binary integers and special floats also recognized.
+func resolveScalar(s *ScalarNode) (string, string) {
+	tag := shortTag(s.Tag())
+	plain := s.Style == StylePlain || s.Style == StyleAny
+
+	switch {
+	case tag == "!!str" || tag == "!" || (tag == "" && !plain):
+		return "!!str", s.Value
+	case tag == "":
+		for _, candidate := range []string{"!!null", "!!bool", "!!int", "!!float"} {
+			if value, ok := canonicalValue(candidate, s.Value); ok {
+				return candidate, value
+			}
+		}
+		return "!!str", s.Value
+	default:
+		if value, ok := canonicalValue(tag, s.Value); ok {
+			return tag, value
+		}
+		return tag, s.Value
+	}
+}
+
+// canonicalValue normalizes value as an instance of a core schema tag
+func canonicalValue(tag, value string) (string, bool) {
+	switch tag {
+	case "!!null":
+		switch value {
+		case "", "~", "null", "Null", "NULL":
+			return "", true
+		}
+	case "!!bool":
+		switch strings.ToLower(value) {
+		case "true", "yes", "on":
+			return "true", true
+		case "false", "no", "off":
+			return "false", true
+		}
+	case "!!int":
+		if i, ok := parseInt(value); ok {
+			return strconv.FormatInt(i, 10), true
+		}
+	case "!!float":
+		if f, ok := parseFloat(value); ok {
+			return strconv.FormatFloat(f, 'g', -1, 64), true
+		}
+	}
+	return "", false
+}
+
+func parseInt(value string) (int64, bool) {
+	value = strings.ReplaceAll(value, "_", "")
+	sign := ""
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		sign, value = value[:1], value[1:]
+	}
+
+	base := 10
+	if len(value) > 2 && value[0] == '0' {
+		switch value[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			value = value[2:]
+		}
+	}
+	if value == "" {
+		return 0, false
+	}
+
+	i, err := strconv.ParseInt(sign+value, base, 64)
+	return i, err == nil
+}
+
+func parseFloat(value string) (float64, bool) {
+	switch strings.ToLower(value) {
+	case ".inf", "+.inf":
+		return math.Inf(1), true
+	case "-.inf":
+		return math.Inf(-1), true
+	case ".nan":
+		return math.NaN(), true
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64)
+	return f, err == nil
+}
@@ -0,0 +1,129 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+func parse(t *testing.T, input string) node.Node {
+	t.Helper()
+	root, err := parser.ParseString(input)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	return root
+}
+
+func TestClone(t *testing.T) {
+	root := parse(t, deployment)
+
+	clone := node.Clone(root, nil)
+	if !node.Equal(root, clone, &node.EqualOptions{}) {
+		t.Fatal("clone differs from original")
+	}
+
+	original, _ := serializer.SerializeToString(root, serializer.DefaultOptions())
+	copied, _ := serializer.SerializeToString(clone, serializer.DefaultOptions())
+	if original != copied {
+		t.Errorf("clone serializes differently:\n%s\nwant:\n%s", copied, original)
+	}
+
+	// Editing the clone must leave the original untouched
+	if err := node.Set(clone, "image.tag", "2.0"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	clone.(*node.MappingNode).Pairs[0].Key.(*node.ScalarNode).HeadComment.Comments[0] = "# changed"
+	after, _ := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if after != original {
+		t.Errorf("original modified through clone:\n%s", after)
+	}
+}
+
+func TestCloneOptions(t *testing.T) {
+	root := parse(t, "a: &x !custom 1 # note\nb: [1, 2]\n")
+
+	clone := node.Clone(root, &node.CloneOptions{})
+	value := clone.(*node.MappingNode).Pairs[0].Value.(*node.ScalarNode)
+	if value.Tag() != "!custom" || value.Anchor() != "x" {
+		t.Errorf("tag and anchor not kept: %q %q", value.Tag(), value.Anchor())
+	}
+	if value.LineComment != nil || value.Line() != 0 || value.Column() != 0 {
+		t.Errorf("comments or positions kept: %+v", value.BaseNode)
+	}
+	if seq := clone.(*node.MappingNode).Pairs[1].Value.(*node.SequenceNode); seq.Style != node.StyleFlow {
+		t.Errorf("style not kept: %v", seq.Style)
+	}
+
+	kept := node.Clone(root, node.DefaultCloneOptions())
+	value = kept.(*node.MappingNode).Pairs[0].Value.(*node.ScalarNode)
+	if value.LineComment == nil || value.Line() != 1 {
+		t.Errorf("comments or positions dropped: %+v", value.BaseNode)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	ordered := &node.EqualOptions{Semantic: true}
+	unordered := &node.EqualOptions{Semantic: true, IgnoreKeyOrder: true}
+	textual := &node.EqualOptions{}
+
+	tests := []struct {
+		name string
+		a, b string
+		opts *node.EqualOptions
+		want bool
+	}{
+		{name: "hex and decimal", a: "a: 1", b: "a: 0x1", opts: ordered, want: true},
+		{name: "hex and decimal textually", a: "a: 1", b: "a: 0x1", opts: textual, want: false},
+		{name: "bool spellings", a: "a: yes", b: "a: true", opts: ordered, want: true},
+		{name: "null spellings", a: "a: ~", b: "a: null", opts: ordered, want: true},
+		{name: "quoted number is a string", a: "a: 1", b: `a: "1"`, opts: ordered, want: false},
+		{name: "explicit str tag", a: "a: !!str 1", b: `a: "1"`, opts: ordered, want: true},
+		{name: "float forms", a: "a: 1.50", b: "a: 1.5e0", opts: ordered, want: true},
+		{name: "int and float differ", a: "a: 1", b: "a: 1.0", opts: ordered, want: false},
+		{name: "key order significant", a: "a: 1\nb: 2", b: "b: 2\na: 1", opts: ordered, want: false},
+		{name: "key order ignored", a: "a: 1\nb: 2", b: "b: 2\na: 1", opts: unordered, want: true},
+		{name: "sequence order always matters", a: "a: [1, 2]", b: "a: [2, 1]", opts: unordered, want: false},
+		{name: "comments and style ignored", a: "# head\na: [1, 2] # line", b: "a:\n  - 1\n  - 2", opts: textual, want: true},
+		{name: "different values", a: "a: {b: 1}", b: "a: {b: 2}", opts: unordered, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := parse(t, tt.a), parse(t, tt.b)
+			if got := node.Equal(a, b, tt.opts); got != tt.want {
+				t.Errorf("Equal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := parse(t, "# comment\nname: app\nport: 0x50\n")
+	b := parse(t, "name: app\n\nport: 80 # http\n")
+	c := parse(t, "name: app\nport: 81\n")
+
+	if node.Hash(a) != node.Hash(b) {
+		t.Error("equal documents hash differently")
+	}
+	if node.Hash(a) == node.Hash(c) {
+		t.Error("different documents hash alike")
+	}
+	if node.Hash(node.Clone(a, nil)) != node.Hash(a) {
+		t.Error("clone hashes differently")
+	}
+
+	// Length prefixes keep adjacent fields from running together
+	x := parse(t, "- ab\n- c\n")
+	y := parse(t, "- a\n- bc\n")
+	if node.Hash(x) == node.Hash(y) || node.Equal(x, y, nil) {
+		t.Error("field boundaries not encoded")
+	}
+
+	// Hashes may be persisted, so the value must not change between releases
+	if got := node.Hash(parse(t, "a: 1\n")); got != 0x2e7ae4640e68903 {
+		t.Errorf("Hash changed: %#x", got)
+	}
+}
@@ -0,0 +1,93 @@
+package node
+
+// MergeKey is the key whose value is merged into the enclosing mapping
+const MergeKey = "<<"
+
+// IsMergeKey reports whether key is a plain << scalar without a tag
+func IsMergeKey(key Node) bool {
+	scalar, ok := key.(*ScalarNode)
+	if !ok || scalar.Value != MergeKey || scalar.Tag() != "" {
+		return false
+	}
+	return scalar.Style == StylePlain || scalar.Style == StyleAny
+}
+
+// MergedPairs returns the pairs of m with merge keys expanded. The value of
+// a merge key is a mapping, an alias of one, or a sequence of those. Their
+// pairs take the place of the merge key unless m defines the key itself or
+// an earlier source already provided it, comparing keys as KeyID does.
+// Sources are expanded recursively. m is not modified, and m.Pairs is
+// returned when there is nothing to merge.
+func MergedPairs(m *MappingNode) []*MappingPair {
+	return mergedPairs(m, make(map[*MappingNode]bool))
+}
+
+// mergedPairs expands each mapping at most once. Merging a source again
+// adds nothing, as every key it provides is already taken, and skipping it
+// keeps sources that merge each other many times over from taking
+// exponential time.
+func mergedPairs(m *MappingNode, visited map[*MappingNode]bool) []*MappingPair {
+	visited[m] = true
+	if !hasMergeKey(m) {
+		return m.Pairs
+	}
+
+	explicit := make(map[string]bool)
+	for _, pair := range m.Pairs {
+		if pair != nil && !IsMergeKey(pair.Key) {
+			explicit[KeyID(pair.Key)] = true
+		}
+	}
+
+	result := make([]*MappingPair, 0, len(m.Pairs))
+	merged := make(map[string]bool)
+	for _, pair := range m.Pairs {
+		if pair == nil {
+			continue
+		}
+		if !IsMergeKey(pair.Key) {
+			result = append(result, pair)
+			continue
+		}
+		for _, source := range mergeSources(pair.Value) {
+			if visited[source] {
+				continue
+			}
+			for _, sp := range mergedPairs(source, visited) {
+				id := KeyID(sp.Key)
+				if explicit[id] || merged[id] {
+					continue
+				}
+				merged[id] = true
+				result = append(result, sp)
+			}
+		}
+	}
+	return result
+}
+
+func hasMergeKey(m *MappingNode) bool {
+	for _, pair := range m.Pairs {
+		if pair != nil && IsMergeKey(pair.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSources returns the mappings named by the value of a merge key
+func mergeSources(value Node) []*MappingNode {
+	switch v := Resolve(value).(type) {
+	case *MappingNode:
+		return []*MappingNode{v}
+	case *SequenceNode:
+		sources := make([]*MappingNode, 0, len(v.Items))
+		for _, item := range v.Items {
+			if source, ok := Resolve(item).(*MappingNode); ok {
+				sources = append(sources, source)
+			}
+		}
+		return sources
+	}
+	return nil
+}
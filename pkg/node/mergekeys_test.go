@@ -0,0 +1,75 @@
+package node_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+func TestMergedPairs(t *testing.T) {
+	input := "a: &a {x: 1, y: 1}\nb: &b {y: 2, z: 2}\nc:\n  <<: [*a, *b]\n  x: 3\n"
+	root, err := parser.ParseStringWithOptions(input, &parser.Options{PreserveAliases: true})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	c := root.(*node.MappingNode).Pairs[2].Value.(*node.MappingNode)
+	var got []string
+	for _, pair := range node.MergedPairs(c) {
+		got = append(got, pair.Key.(*node.ScalarNode).Value+"="+pair.Value.(*node.ScalarNode).Value)
+	}
+	// Explicit keys win wherever they appear, then earlier sources
+	if strings.Join(got, ",") != "y=1,z=2,x=3" {
+		t.Errorf("got %v", got)
+	}
+	if len(c.Pairs) != 2 {
+		t.Error("MergedPairs modified the mapping")
+	}
+
+	if plain := root.(*node.MappingNode).Pairs[0].Value.(*node.MappingNode); &node.MergedPairs(plain)[0] != &plain.Pairs[0] {
+		t.Error("expected the pairs of a mapping without merge keys to be returned as is")
+	}
+}
+
+func TestMergedPairsRepeatedSources(t *testing.T) {
+	// Every level merges the previous one ten times over
+	var sb strings.Builder
+	sb.WriteString("m0: &m0 {k: v}\n")
+	for i := 1; i <= 40; i++ {
+		prev := fmt.Sprintf("*m%d", i-1)
+		fmt.Fprintf(&sb, "m%d: &m%d\n  <<: [%s%s]\n  k%d: v\n", i, i, strings.Repeat(prev+", ", 9), prev, i)
+	}
+	root, err := parser.ParseStringWithOptions(sb.String(), &parser.Options{PreserveAliases: true})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	pairs := root.(*node.MappingNode).Pairs
+	if merged := node.MergedPairs(pairs[len(pairs)-1].Value.(*node.MappingNode)); len(merged) != 41 {
+		t.Errorf("expected 41 pairs, got %d", len(merged))
+	}
+}
+
+func TestIsMergeKey(t *testing.T) {
+	tests := []struct {
+		key      node.Node
+		expected bool
+	}{
+		{&node.ScalarNode{Value: "<<", Style: node.StylePlain}, true},
+		{&node.ScalarNode{Value: "<<"}, true},
+		{&node.ScalarNode{Value: "<<", Style: node.StyleDoubleQuoted}, false},
+		{&node.ScalarNode{Value: "<<", Style: node.StyleSingleQuoted}, false},
+		{&node.ScalarNode{BaseNode: node.BaseNode{TagValue: "!!str"}, Value: "<<", Style: node.StylePlain}, false},
+		{&node.ScalarNode{Value: "<", Style: node.StylePlain}, false},
+		{&node.MappingNode{}, false},
+	}
+
+	for i, tt := range tests {
+		if got := node.IsMergeKey(tt.key); got != tt.expected {
+			t.Errorf("case %d: IsMergeKey = %v, want %v", i, got, tt.expected)
+		}
+	}
+}
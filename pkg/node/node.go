@@ -7,6 +7,8 @@ const (
 	NodeTypeScalar NodeType = iota
 	NodeTypeSequence
 	NodeTypeMapping
+	NodeTypeDocument
+	NodeTypeAlias
 )
 
 // Style represents the style of a node (block vs flow)
@@ -35,19 +37,83 @@ type Node interface {
 	Accept(visitor Visitor) error
 }
 
+// Position is a location in the source text. Line and Column start at 1;
+// Offset is the byte offset from the start of the input.
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// IsValid reports whether the position was recorded by the parser
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// Range is the span of source text a node or comment was parsed from. End
+// is the position just after the last character. Nodes built in code have
+// a zero Range.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// IsValid reports whether the range was recorded by the parser
+func (r Range) IsValid() bool { return r.Start.IsValid() }
+
+// Text returns the part of src covered by the range, or "" when the range
+// does not lie within src
+func (r Range) Text(src string) string {
+	if !r.IsValid() || r.Start.Offset > r.End.Offset || r.End.Offset > len(src) {
+		return ""
+	}
+	return src[r.Start.Offset:r.End.Offset]
+}
+
+// Union returns the smallest range covering r and other. Invalid ranges
+// are ignored.
+func (r Range) Union(other Range) Range {
+	if !other.IsValid() {
+		return r
+	}
+	if !r.IsValid() {
+		return other
+	}
+	if other.Start.Offset < r.Start.Offset {
+		r.Start = other.Start
+	}
+	if other.End.Offset > r.End.Offset {
+		r.End = other.End
+	}
+	return r
+}
+
+// RangeOf returns the source range of n, or a zero Range when n has none
+func RangeOf(n Node) Range {
+	if b, ok := n.(interface{ GetBase() *BaseNode }); ok {
+		return b.GetBase().Range
+	}
+	return Range{}
+}
+
 // CommentGroup represents a collection of comments
 type CommentGroup struct {
 	Comments         []string
-	BlankLinesBefore int // Number of blank lines before this comment group
+	BlankLinesBefore int   // Number of blank lines before this comment group
+	Range            Range // From the first # to the end of the last comment
 }
 
 // BaseNode contains common fields for all node types
 type BaseNode struct {
 	TagValue     string
 	AnchorValue  string
+	TagShorthand string // the tag as written, when the parser expanded TagValue
 	LineNumber   int
 	ColumnNumber int
 
+	// Range spans the node's content: the scalar text including quotes or
+	// block indicators, or a collection from its first to its last token.
+	// Anchors and tags before the content are not included.
+	Range Range
+
 	// Comment associations
 	HeadComment *CommentGroup // Comments before the node
 	LineComment *CommentGroup // Inline comment on same line
@@ -58,6 +124,9 @@ type BaseNode struct {
 	BlankLinesAfter  int // Number of blank lines after this node
 
 	StyleHint Style
+
+	// Source is the text the node was parsed from, recorded in CST mode
+	Source *Source
 }
 
 func (n *BaseNode) Tag() string        { return n.TagValue }
@@ -115,6 +184,78 @@ type MappingPair struct {
 	BlankLinesAfter  int
 }
 
+// DocumentNode is the root of a YAML document. It holds the directives,
+// explicit markers and the comments that belong to the document itself.
+type DocumentNode struct {
+	BaseNode
+	Directives    []Directive
+	Content       Node
+	ExplicitStart bool // document starts with ---
+	ExplicitEnd   bool // document ends with ...
+}
+
+func (n *DocumentNode) Type() NodeType     { return NodeTypeDocument }
+func (n *DocumentNode) GetBase() *BaseNode { return &n.BaseNode }
+
+// Accept calls VisitDocument when v implements DocumentVisitor and visits
+// the content otherwise
+func (n *DocumentNode) Accept(v Visitor) error {
+	if dv, ok := v.(DocumentVisitor); ok {
+		return dv.VisitDocument(n)
+	}
+	if n.Content == nil {
+		return nil
+	}
+	return n.Content.Accept(v)
+}
+
+// Directive is a %YAML or %TAG line preceding a document
+type Directive struct {
+	Name       string
+	Parameters []string
+}
+
+// AliasNode is an unexpanded *name reference to an anchored node
+type AliasNode struct {
+	BaseNode
+	Name   string // anchor name without the leading '*'
+	Target Node   // the anchored node, nil while unresolved
+}
+
+func (n *AliasNode) Type() NodeType     { return NodeTypeAlias }
+func (n *AliasNode) GetBase() *BaseNode { return &n.BaseNode }
+
+// Accept calls VisitAlias when v implements AliasVisitor and visits the
+// target otherwise
+func (n *AliasNode) Accept(v Visitor) error {
+	if av, ok := v.(AliasVisitor); ok {
+		return av.VisitAlias(n)
+	}
+	if n.Target == nil {
+		return nil
+	}
+	return n.Target.Accept(v)
+}
+
+// Resolve returns the node n stands for: the content of a document or the
+// target of an alias, followed until neither applies. Other nodes are
+// returned unchanged.
+func Resolve(n Node) Node {
+	for {
+		switch v := n.(type) {
+		case *DocumentNode:
+			n = v.Content
+		case *AliasNode:
+			if v.Target == nil {
+				return n
+			}
+			n = v.Target
+		default:
+			return n
+		}
+	}
+}
+
 // Visitor interface for visiting nodes (Visitor pattern)
 type Visitor interface {
 	VisitScalar(*ScalarNode) error
@@ -122,6 +263,18 @@ type Visitor interface {
 	VisitMapping(*MappingNode) error
 }
 
+// DocumentVisitor is implemented by visitors that handle documents
+// themselves rather than only their content
+type DocumentVisitor interface {
+	VisitDocument(*DocumentNode) error
+}
+
+// AliasVisitor is implemented by visitors that handle aliases themselves
+// rather than their targets
+type AliasVisitor interface {
+	VisitAlias(*AliasNode) error
+}
+
 // Builder interface for constructing nodes (Builder pattern)
 type Builder interface {
 	BuildScalar(value string, style Style) *ScalarNode
@@ -0,0 +1,117 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// countingVisitor implements only the base Visitor interface
+type countingVisitor struct{ scalars, mappings int }
+
+func (v *countingVisitor) VisitScalar(*node.ScalarNode) error     { v.scalars++; return nil }
+func (v *countingVisitor) VisitSequence(*node.SequenceNode) error { return nil }
+func (v *countingVisitor) VisitMapping(*node.MappingNode) error   { v.mappings++; return nil }
+
+// aliasVisitor also handles aliases itself
+type aliasVisitor struct {
+	countingVisitor
+	aliases []string
+}
+
+func (v *aliasVisitor) VisitAlias(a *node.AliasNode) error {
+	v.aliases = append(v.aliases, a.Name)
+	return nil
+}
+
+func TestDocumentAndAliasNodes(t *testing.T) {
+	target := &node.ScalarNode{BaseNode: node.BaseNode{AnchorValue: "x"}, Value: "1"}
+	alias := &node.AliasNode{Name: "x", Target: target}
+	doc := &node.DocumentNode{Content: alias}
+
+	if doc.Type() != node.NodeTypeDocument || alias.Type() != node.NodeTypeAlias {
+		t.Errorf("unexpected types %v %v", doc.Type(), alias.Type())
+	}
+	if node.Resolve(doc) != target {
+		t.Error("Resolve should follow the document content and the alias target")
+	}
+	if unresolved := (&node.AliasNode{Name: "y"}); node.Resolve(unresolved) != unresolved {
+		t.Error("Resolve should return an unresolved alias itself")
+	}
+
+	// Visitors without the optional interfaces see through documents and aliases
+	plain := &countingVisitor{}
+	if err := doc.Accept(plain); err != nil || plain.scalars != 1 {
+		t.Errorf("expected the target to be visited, got %d scalars (%v)", plain.scalars, err)
+	}
+
+	aware := &aliasVisitor{}
+	if err := doc.Accept(aware); err != nil || aware.scalars != 0 || len(aware.aliases) != 1 {
+		t.Errorf("expected VisitAlias only, got %d scalars and aliases %v (%v)", aware.scalars, aware.aliases, err)
+	}
+}
+
+func TestCloneRemapsAliases(t *testing.T) {
+	anchored := &node.MappingNode{BaseNode: node.BaseNode{AnchorValue: "base"}}
+	outside := &node.ScalarNode{BaseNode: node.BaseNode{AnchorValue: "other"}, Value: "v"}
+	root := &node.DocumentNode{
+		Directives:    []node.Directive{{Name: "YAML", Parameters: []string{"1.2"}}},
+		ExplicitStart: true,
+		Content: &node.SequenceNode{Items: []node.Node{
+			anchored,
+			&node.AliasNode{Name: "base", Target: anchored},
+			&node.AliasNode{Name: "other", Target: outside},
+		}},
+	}
+
+	clone := node.Clone(root, nil).(*node.DocumentNode)
+	if !clone.ExplicitStart || clone.Directives[0].Parameters[0] != "1.2" {
+		t.Errorf("document metadata not copied: %+v", clone)
+	}
+	clone.Directives[0].Parameters[0] = "1.1"
+	if root.Directives[0].Parameters[0] != "1.2" {
+		t.Error("directives shared with the original")
+	}
+
+	items := clone.Content.(*node.SequenceNode).Items
+	if items[1].(*node.AliasNode).Target != items[0] {
+		t.Error("alias should point at the copied anchor")
+	}
+	if items[2].(*node.AliasNode).Target != outside {
+		t.Error("alias to a node outside the clone should keep its target")
+	}
+}
+
+func TestWalkDocument(t *testing.T) {
+	target := &node.MappingNode{BaseNode: node.BaseNode{AnchorValue: "a"}, Pairs: []*node.MappingPair{
+		{Key: &node.ScalarNode{Value: "k"}, Value: &node.ScalarNode{Value: "v"}},
+	}}
+	doc := &node.DocumentNode{Content: &node.SequenceNode{Items: []node.Node{
+		target,
+		&node.AliasNode{Name: "a", Target: target},
+	}}}
+
+	var paths []string
+	node.Walk(doc, func(c *node.Cursor) bool {
+		if c.Parent() != nil && !c.IsKey() {
+			paths = append(paths, c.Path())
+		}
+		return true
+	}, nil)
+
+	// The alias is visited once but not followed
+	expected := []string{"", "[0]", "[0].k", "[1]"}
+	if len(paths) != len(expected) {
+		t.Fatalf("visited %v, want %v", paths, expected)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("visited %v, want %v", paths, expected)
+			break
+		}
+	}
+
+	if v, err := node.Lookup(doc, "[1].k"); err != nil || v.(*node.ScalarNode).Value != "v" {
+		t.Errorf("Lookup through document and alias: %v, %v", v, err)
+	}
+}
@@ -0,0 +1,182 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPathNotFound is returned when a path does not resolve to a node
+var ErrPathNotFound = errors.New("path not found")
+
+// Lookup returns the node at a path such as "spec.containers[0].image".
+// Keys containing dots can be written in brackets: metadata.labels["app.kubernetes.io/name"].
+func Lookup(root Node, path string) (Node, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := root
+	for i, seg := range segments {
+		if current == nil {
+			return nil, fmt.Errorf("%w: %q at %q", ErrPathNotFound, path, joinPath(segments[:i]))
+		}
+
+		next, err := child(current, seg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrPathNotFound, path, err)
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// child returns the direct child of n selected by seg. Documents and
+// aliases are looked through.
+func child(n Node, seg pathSegment) (Node, error) {
+	switch v := Resolve(n).(type) {
+	case *MappingNode:
+		if seg.isIndex {
+			return nil, fmt.Errorf("cannot index mapping at line %d", v.Line())
+		}
+		for _, pair := range v.Pairs {
+			if key, ok := pair.Key.(*ScalarNode); ok && key.Value == seg.key {
+				return pair.Value, nil
+			}
+		}
+		return nil, fmt.Errorf("key %q missing at line %d", seg.key, v.Line())
+	case *SequenceNode:
+		if !seg.isIndex {
+			return nil, fmt.Errorf("expected index into sequence at line %d", v.Line())
+		}
+		if seg.index < 0 || seg.index >= len(v.Items) {
+			return nil, fmt.Errorf("index %d out of range at line %d", seg.index, v.Line())
+		}
+		return v.Items[seg.index], nil
+	default:
+		return nil, fmt.Errorf("cannot descend into scalar at line %d", n.Line())
+	}
+}
+
+// SplitPath splits a lookup path into its keys and indexes, with indexes
+// rendered in decimal: `a.b[0]["c.d"]` becomes a, b, 0, c.d
+func SplitPath(path string) ([]string, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, len(segments))
+	for i, seg := range segments {
+		if seg.isIndex {
+			tokens[i] = strconv.Itoa(seg.index)
+		} else {
+			tokens[i] = seg.key
+		}
+	}
+	return tokens, nil
+}
+
+// pathSegment is one step of a lookup path
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// splitPath parses a lookup path into segments
+func splitPath(path string) ([]pathSegment, error) {
+	segments := make([]pathSegment, 0)
+	i := 0
+
+	for i < len(path) {
+		switch path[i] {
+		case '.':
+			i++
+		case '[':
+			end := bracketEnd(path[i:])
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed bracket", path)
+			}
+			inner := path[i+1 : i+end]
+			i += end + 1
+
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				segments = append(segments, pathSegment{key: unquoted})
+				continue
+			}
+			if len(inner) >= 2 && inner[0] == '\'' && inner[len(inner)-1] == '\'' {
+				segments = append(segments, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, inner)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, pathSegment{key: path[i : i+end]})
+			i += end
+		}
+	}
+
+	return segments, nil
+}
+
+// bracketEnd returns the offset of the bracket closing the one that starts
+// s, skipping over a quoted key, or -1 when it is not closed
+func bracketEnd(s string) int {
+	i := 1
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		quote := s[i]
+		for i++; i < len(s) && s[i] != quote; i++ {
+			if quote == '"' && s[i] == '\\' {
+				i++ // skip the escaped character
+			}
+		}
+	}
+	i = min(i, len(s))
+	if end := strings.IndexByte(s[i:], ']'); end >= 0 {
+		return i + end
+	}
+	return -1
+}
+
+// joinPath renders segments back into path syntax for error messages
+func joinPath(segments []pathSegment) string {
+	var sb strings.Builder
+	for i, seg := range segments {
+		if seg.isIndex {
+			fmt.Fprintf(&sb, "[%d]", seg.index)
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(seg.key)
+	}
+	return sb.String()
+}
+
+// JoinKey extends a lookup path with a mapping key, quoting keys that would
+// otherwise be split
+func JoinKey(path, key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]\"'") {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// JoinIndex extends a lookup path with a sequence index
+func JoinIndex(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
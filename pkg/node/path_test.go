@@ -0,0 +1,40 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+func TestJoinKeyLookup(t *testing.T) {
+	keys := []string{"plain", "a]b", `say "hi"]`, "x.y", "[0]", `back\slash]`, "it's", ""}
+
+	inner := &node.MappingNode{Style: node.StyleBlock}
+	for _, key := range keys {
+		inner.Pairs = append(inner.Pairs, &node.MappingPair{
+			Key:   &node.ScalarNode{Value: key, Style: node.StylePlain},
+			Value: &node.ScalarNode{Value: "value of " + key, Style: node.StylePlain},
+		})
+	}
+	root := &node.MappingNode{Style: node.StyleBlock, Pairs: []*node.MappingPair{
+		{Key: &node.ScalarNode{Value: "outer", Style: node.StylePlain}, Value: inner},
+	}}
+
+	for _, key := range keys {
+		path := node.JoinKey(node.JoinKey("", "outer"), key)
+		n, err := node.Lookup(root, path)
+		if err != nil {
+			t.Errorf("Lookup(%q) error: %v", path, err)
+			continue
+		}
+		if got := n.(*node.ScalarNode).Value; got != "value of "+key {
+			t.Errorf("Lookup(%q): expected the value of %q, got %q", path, key, got)
+		}
+	}
+
+	for _, path := range []string{`outer["a]b`, `outer['a]b`, "outer[a]b"} {
+		if _, err := node.Lookup(root, path); err == nil {
+			t.Errorf("Lookup(%q): expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package node
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Source is the text a node was parsed from. The parser records it in CST
+// mode (parser.Options.KeepSource) together with the state of the node at
+// that point, so the serializer can tell which nodes were edited since and
+// write the others back exactly as they were written.
+type Source struct {
+	// Text is the whole input, shared by the nodes of a parse
+	Text string
+
+	// Outer is set on the root of a document. It spans the document with
+	// its comments, directives, markers and the whitespace up to the next
+	// document, so the outer ranges of a stream cover all of its text.
+	Outer Range
+
+	state sourceState
+}
+
+// sourceState is what the serializer compares to detect edits
+type sourceState struct {
+	props    string // tag, anchor, comments and blank lines
+	content  string // value and style
+	children []Node // keys and values alternate for a mapping
+}
+
+// SourceOf returns the source recorded for n, or nil when n was built in
+// code or parsed without KeepSource
+func SourceOf(n Node) *Source {
+	if base := baseOf(n); base != nil {
+		return base.Source
+	}
+	return nil
+}
+
+// RecordSource records text as the source of root and every node below it,
+// along with their current state. outer is the range of the document
+// around root.
+func RecordSource(root Node, text string, outer Range) {
+	var record func(n Node) *Source
+	record = func(n Node) *Source {
+		base := baseOf(n)
+		if base == nil {
+			return nil
+		}
+		state := stateOf(n)
+		base.Source = &Source{Text: text, state: state}
+		for _, child := range state.children {
+			record(child)
+		}
+		return base.Source
+	}
+
+	if src := record(root); src != nil {
+		src.Outer = outer
+	}
+}
+
+// Children returns the children n had when it was parsed, with keys and
+// values alternating for a mapping
+func (s *Source) Children() []Node {
+	return s.state.children
+}
+
+// ContentEdited reports whether the value or style of n changed since it
+// was parsed. Changes to its children are not included.
+func (s *Source) ContentEdited(n Node) bool {
+	return stateOf(n).content != s.state.content
+}
+
+// PropertiesEdited reports whether the tag, anchor, comments or blank lines
+// of n differ from those it was parsed with
+func (s *Source) PropertiesEdited(n Node) bool {
+	return stateOf(n).props != s.state.props
+}
+
+// Unedited reports whether n and every node below it are as they were
+// parsed
+func Unedited(n Node) bool {
+	src := SourceOf(n)
+	if src == nil {
+		return false
+	}
+	state := stateOf(n)
+	if state.props != src.state.props || state.content != src.state.content || !slices.Equal(state.children, src.state.children) {
+		return false
+	}
+	for _, child := range state.children {
+		if child != nil && !Unedited(child) {
+			return false
+		}
+	}
+	return true
+}
+
+// ChildrenOf returns the children of n, with keys and values alternating
+// for a mapping
+func ChildrenOf(n Node) []Node {
+	return stateOf(n).children
+}
+
+// stateOf captures the parts of n that an edit changes
+func stateOf(n Node) sourceState {
+	var state sourceState
+	var content []string
+	var base *BaseNode
+	switch v := n.(type) {
+	case *ScalarNode:
+		base = &v.BaseNode
+		content = []string{strconv.Itoa(int(v.Style)), v.Value, v.Alias}
+	case *SequenceNode:
+		base = &v.BaseNode
+		content = []string{strconv.Itoa(int(v.Style))}
+		state.children = slices.Clone(v.Items)
+	case *MappingNode:
+		base = &v.BaseNode
+		content = []string{strconv.Itoa(int(v.Style))}
+		for _, pair := range v.Pairs {
+			state.children = append(state.children, pair.Key, pair.Value)
+		}
+	case *AliasNode:
+		base = &v.BaseNode
+		content = []string{v.Name}
+	case *DocumentNode:
+		base = &v.BaseNode
+		state.children = []Node{v.Content}
+	default:
+		return state
+	}
+
+	state.content = strings.Join(content, "\x00")
+	state.props = strings.Join([]string{
+		base.TagValue,
+		base.TagShorthand,
+		base.AnchorValue,
+		commentText(base.HeadComment),
+		commentText(base.LineComment),
+		commentText(base.FootComment),
+		strconv.Itoa(base.BlankLinesBefore),
+		strconv.Itoa(base.BlankLinesAfter),
+	}, "\x00")
+	return state
+}
+
+func commentText(cg *CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.Join(cg.Comments, "\n")
+}
@@ -0,0 +1,72 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+func parseSource(t *testing.T, input string) node.Node {
+	t.Helper()
+	opts := parser.DefaultOptions()
+	opts.KeepSource = true
+	root, err := parser.ParseStringWithOptions(input, opts)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	return root
+}
+
+func TestSourceEdits(t *testing.T) {
+	input := "# head\nname: app\nports: [80, 443]\n"
+	root := parseSource(t, input)
+
+	src := node.SourceOf(root)
+	if src == nil || src.Text != input {
+		t.Fatalf("expected the input as source, got %+v", src)
+	}
+	if got := src.Outer.Text(input); got != input {
+		t.Errorf("expected the outer range to cover the input, got %q", got)
+	}
+	if !node.Unedited(root) {
+		t.Fatal("expected a freshly parsed tree to be unedited")
+	}
+
+	name, _ := node.Lookup(root, "name")
+	name.(*node.ScalarNode).Value = "web"
+	if !node.SourceOf(name).ContentEdited(name) || node.SourceOf(name).PropertiesEdited(name) {
+		t.Error("expected a value edit to change the content only")
+	}
+	if node.Unedited(root) || node.SourceOf(root).ContentEdited(root) {
+		t.Error("expected the edit to show below the root but not in its own content")
+	}
+	name.(*node.ScalarNode).Value = "app"
+	if !node.Unedited(root) {
+		t.Error("expected restoring the value to undo the edit")
+	}
+
+	key := root.(*node.MappingNode).Pairs[0].Key
+	key.(*node.ScalarNode).HeadComment.Comments[0] = "# changed"
+	if !node.SourceOf(key).PropertiesEdited(key) {
+		t.Error("expected a comment edit to change the properties")
+	}
+
+	ports, _ := node.Lookup(root, "ports")
+	if err := node.Append(root, "ports", 8080); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if got := len(node.SourceOf(ports).Children()); got != 2 {
+		t.Errorf("expected the parsed children to stay recorded, got %d", got)
+	}
+}
+
+func TestSourceNotKept(t *testing.T) {
+	root := parse(t, "a: 1")
+	if node.SourceOf(root) != nil || node.Unedited(root) {
+		t.Error("expected no source without KeepSource")
+	}
+	if clone := node.Clone(parseSource(t, "a: 1"), nil); node.SourceOf(clone) != nil {
+		t.Error("expected clones to have no source")
+	}
+}
@@ -0,0 +1,26 @@
+package node
+
+// SplitTag splits a tag shorthand such as !e!foo into its handle, here
+// !e!, and its suffix. The handle is !, !! or a named handle like !e!.
+// Verbatim tags (!<...>) and full tags have no handle and are returned
+// whole as the suffix.
+func SplitTag(tag string) (handle, suffix string) {
+	if len(tag) == 0 || tag[0] != '!' || (len(tag) > 1 && tag[1] == '<') {
+		return "", tag
+	}
+	for i := 1; i < len(tag); i++ {
+		c := tag[i]
+		if c == '!' {
+			return tag[:i+1], tag[i+1:]
+		}
+		if !isWordChar(c) {
+			break
+		}
+	}
+	return "!", tag[1:]
+}
+
+// isWordChar reports whether c may appear in a named tag handle
+func isWordChar(c byte) bool {
+	return c == '-' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
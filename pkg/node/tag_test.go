@@ -0,0 +1,30 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+func TestSplitTag(t *testing.T) {
+	tests := []struct {
+		tag, handle, suffix string
+	}{
+		{"!foo", "!", "foo"},
+		{"!!str", "!!", "str"},
+		{"!e!foo", "!e!", "foo"},
+		{"!my-app!a%21b", "!my-app!", "a%21b"},
+		{"!a.b!c", "!", "a.b!c"},
+		{"!", "!", ""},
+		{"!<tag:example.com,2024:foo>", "", "!<tag:example.com,2024:foo>"},
+		{"tag:yaml.org,2002:str", "", "tag:yaml.org,2002:str"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		handle, suffix := node.SplitTag(tt.tag)
+		if handle != tt.handle || suffix != tt.suffix {
+			t.Errorf("SplitTag(%q): expected %q, %q, got %q, %q", tt.tag, tt.handle, tt.suffix, handle, suffix)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package node
+
+// WalkFunc is called for each node visited by Walk. Returning false from
+// the pre hook skips the node's children and its post hook; returning false
+// from the post hook stops the walk.
+type WalkFunc func(c *Cursor) bool
+
+// Cursor describes the node being visited by Walk and allows it to be
+// replaced, deleted or given siblings.
+type Cursor struct {
+	node       Node
+	parent     Node
+	pair       *MappingPair
+	isKey      bool
+	parentPath string
+	iter       *iterator // position within the parent; nil for the root
+	set        func(Node)
+	deleted    bool
+}
+
+// iterator tracks the position of a walk over sequence items or mapping
+// pairs; step is the amount added to index once the current child is done
+type iterator struct {
+	index int
+	step  int
+}
+
+// Walk traverses root depth-first, calling pre before and post after the
+// children of each node; either hook may be nil. Mapping keys are visited
+// before their values. Documents are entered, but aliases are not followed
+// to their targets. Walk returns the root, which differs from the argument
+// if a hook replaced it.
+//
+// Nodes added with Replace are walked, as are items and pairs inserted
+// after the current one. Nodes inserted before the current one are not.
+func Walk(root Node, pre, post WalkFunc) Node {
+	w := &walker{pre: pre, post: post}
+	w.apply(&Cursor{node: root, set: func(n Node) { root = n }})
+	return root
+}
+
+// Node returns the current node, or nil after Delete
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the sequence, mapping or document containing the current
+// node, or nil at the root
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Pair returns the mapping entry holding the current node, or nil when the
+// parent is not a mapping
+func (c *Cursor) Pair() *MappingPair { return c.pair }
+
+// IsKey reports whether the current node is the key of Pair
+func (c *Cursor) IsKey() bool { return c.isKey }
+
+// Index returns the position of the current node among the parent's items,
+// or of its entry among the parent's pairs. It is -1 at the root.
+func (c *Cursor) Index() int {
+	if c.iter == nil {
+		return -1
+	}
+	return c.iter.index
+}
+
+// Path returns the location of the current node in the syntax accepted by
+// Lookup. A key has the same path as its value.
+func (c *Cursor) Path() string {
+	switch c.parent.(type) {
+	case *SequenceNode:
+		return JoinIndex(c.parentPath, c.iter.index)
+	case *MappingNode:
+		return JoinKey(c.parentPath, keyText(c.pair.Key))
+	default:
+		return c.parentPath
+	}
+}
+
+// Replace substitutes n for the current node. Comments of the replaced node
+// are not carried over. The children of n are walked when Replace is called
+// from the pre hook.
+func (c *Cursor) Replace(n Node) {
+	if c.deleted {
+		panic("node: Replace called after Delete")
+	}
+	c.set(n)
+	c.node = n
+}
+
+// Delete removes the current node. Deleting a mapping key or value removes
+// the whole entry. Delete panics at the root and on document content.
+func (c *Cursor) Delete() {
+	if c.iter == nil {
+		panic("node: Delete requires a node inside a sequence or mapping")
+	}
+	if c.deleted {
+		return
+	}
+
+	switch p := c.parent.(type) {
+	case *SequenceNode:
+		removeItem(p, c.iter.index)
+	case *MappingNode:
+		removePair(p, c.iter.index)
+	}
+	c.iter.step--
+	c.node = nil
+	c.deleted = true
+}
+
+// InsertBefore inserts n into the parent sequence before the current node.
+// It panics when the parent is not a sequence.
+func (c *Cursor) InsertBefore(n Node) {
+	seq := c.sequence("InsertBefore")
+	seq.Items = insertAt(seq.Items, c.iter.index, n)
+	c.iter.index++
+}
+
+// InsertAfter inserts n into the parent sequence after the current node.
+// It panics when the parent is not a sequence.
+func (c *Cursor) InsertAfter(n Node) {
+	seq := c.sequence("InsertAfter")
+	seq.Items = insertAt(seq.Items, c.iter.index+1, n)
+}
+
+// InsertPairBefore inserts pair into the parent mapping before the current
+// entry. It panics when the parent is not a mapping.
+func (c *Cursor) InsertPairBefore(pair *MappingPair) {
+	m := c.mapping("InsertPairBefore")
+	m.Pairs = append(m.Pairs, nil)
+	copy(m.Pairs[c.iter.index+1:], m.Pairs[c.iter.index:])
+	m.Pairs[c.iter.index] = pair
+	c.iter.index++
+}
+
+// InsertPairAfter inserts pair into the parent mapping after the current
+// entry. It panics when the parent is not a mapping.
+func (c *Cursor) InsertPairAfter(pair *MappingPair) {
+	m := c.mapping("InsertPairAfter")
+	i := c.iter.index + 1
+	m.Pairs = append(m.Pairs, nil)
+	copy(m.Pairs[i+1:], m.Pairs[i:])
+	m.Pairs[i] = pair
+}
+
+func (c *Cursor) sequence(method string) *SequenceNode {
+	seq, ok := c.parent.(*SequenceNode)
+	if !ok || c.deleted {
+		panic("node: " + method + " requires a node inside a sequence")
+	}
+	return seq
+}
+
+func (c *Cursor) mapping(method string) *MappingNode {
+	m, ok := c.parent.(*MappingNode)
+	if !ok || c.deleted {
+		panic("node: " + method + " requires a node inside a mapping")
+	}
+	return m
+}
+
+func insertAt(items []Node, i int, n Node) []Node {
+	items = append(items, nil)
+	copy(items[i+1:], items[i:])
+	items[i] = n
+	return items
+}
+
+// keyText returns the text used for a key in paths
+func keyText(key Node) string {
+	if scalar, ok := key.(*ScalarNode); ok {
+		return scalar.Value
+	}
+	return ""
+}
+
+type walker struct {
+	pre, post WalkFunc
+	stopped   bool
+}
+
+// apply visits the node under c and its children
+func (w *walker) apply(c *Cursor) {
+	if c.node == nil {
+		return
+	}
+	if w.pre != nil && !w.pre(c) {
+		return
+	}
+	if c.deleted {
+		return
+	}
+
+	path := c.Path()
+	switch n := c.node.(type) {
+	case *SequenceNode:
+		w.walkSequence(n, path)
+	case *MappingNode:
+		w.walkMapping(n, path)
+	case *DocumentNode:
+		w.apply(&Cursor{
+			node:       n.Content,
+			parent:     n,
+			parentPath: path,
+			set:        func(content Node) { n.Content = content },
+		})
+	}
+	if w.stopped || c.deleted {
+		return
+	}
+
+	if w.post != nil && !w.post(c) {
+		w.stopped = true
+	}
+}
+
+func (w *walker) walkSequence(seq *SequenceNode, path string) {
+	it := &iterator{}
+	for it.index < len(seq.Items) && !w.stopped {
+		it.step = 1
+		w.apply(&Cursor{
+			node:       seq.Items[it.index],
+			parent:     seq,
+			parentPath: path,
+			iter:       it,
+			set:        func(n Node) { seq.Items[it.index] = n },
+		})
+		it.index += it.step
+	}
+}
+
+func (w *walker) walkMapping(m *MappingNode, path string) {
+	it := &iterator{}
+	for it.index < len(m.Pairs) && !w.stopped {
+		it.step = 1
+		pair := m.Pairs[it.index]
+
+		key := &Cursor{
+			node:       pair.Key,
+			parent:     m,
+			pair:       pair,
+			isKey:      true,
+			parentPath: path,
+			iter:       it,
+			set:        func(n Node) { pair.Key = n },
+		}
+		w.apply(key)
+
+		if !key.deleted && !w.stopped {
+			w.apply(&Cursor{
+				node:       pair.Value,
+				parent:     m,
+				pair:       pair,
+				parentPath: path,
+				iter:       it,
+				set:        func(n Node) { pair.Value = n },
+			})
+		}
+		it.index += it.step
+	}
+}
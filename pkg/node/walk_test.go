@@ -0,0 +1,138 @@
+package node_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+func TestWalkOrderAndContext(t *testing.T) {
+	root := parse(t, "a:\n  b: 1\nc: [x, y]\n")
+
+	var events []string
+	node.Walk(root, func(c *node.Cursor) bool {
+		role := "value"
+		if c.IsKey() {
+			role = "key"
+		} else if c.Pair() == nil {
+			role = "item"
+		}
+		if c.Parent() == nil {
+			role = "root"
+		}
+		events = append(events, "pre "+role+" "+c.Path())
+		return true
+	}, func(c *node.Cursor) bool {
+		if c.Parent() == nil || c.Node().Type() != node.NodeTypeScalar {
+			events = append(events, "post "+c.Path())
+		}
+		return true
+	})
+
+	expected := []string{
+		"pre root ",
+		"pre key a", "pre value a",
+		"pre key a.b", "pre value a.b",
+		"post a",
+		"pre key c", "pre value c",
+		"pre item c[0]", "pre item c[1]",
+		"post c",
+		"post ",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(events, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestWalkSkipAndStop(t *testing.T) {
+	root := parse(t, "a:\n  b: 1\nc:\n  d: 2\ne: 3\n")
+
+	var visited []string
+	node.Walk(root, func(c *node.Cursor) bool {
+		if !c.IsKey() && c.Parent() != nil {
+			visited = append(visited, c.Path())
+		}
+		return c.Path() != "a" // skip the children of a
+	}, func(c *node.Cursor) bool {
+		return c.IsKey() || c.Path() != "c" // stop after the value of c
+	})
+
+	expected := []string{"a", "c", "c.d"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("visited %v, want %v", visited, expected)
+	}
+}
+
+func TestWalkMutation(t *testing.T) {
+	input := `# dropped with its entry
+debug: true
+# image settings
+image:
+  tag: latest # replaced with the node
+ports:
+  - 80
+  - 443
+  - 8080
+`
+	root := parse(t, input)
+
+	root = node.Walk(root, func(c *node.Cursor) bool {
+		switch {
+		case c.Path() == "debug" && c.IsKey():
+			c.Delete()
+		case c.Path() == "image.tag" && !c.IsKey():
+			c.Replace(&node.ScalarNode{Value: "1.2.3", Style: node.StylePlain})
+		case c.Path() == "image.tag":
+			c.InsertPairBefore(&node.MappingPair{
+				Key:   &node.ScalarNode{Value: "repository", Style: node.StylePlain},
+				Value: &node.ScalarNode{Value: "nginx", Style: node.StylePlain},
+			})
+		case c.Path() == "ports[1]":
+			c.InsertBefore(&node.ScalarNode{Value: "81", Style: node.StylePlain})
+		case c.Path() == "ports[3]":
+			c.Delete()
+		}
+		return true
+	}, nil)
+
+	output, err := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+	expected := "# image settings\nimage:\n  repository: nginx\n  tag: 1.2.3\nports:\n  - 80\n  - 81\n  - 443"
+	if output != expected {
+		t.Errorf("got:\n%q\nwant:\n%q", output, expected)
+	}
+}
+
+func TestWalkReplaceRoot(t *testing.T) {
+	root := parse(t, "a: 1\n")
+
+	replacement := &node.SequenceNode{Items: []node.Node{&node.ScalarNode{Value: "x"}}}
+	var items int
+	result := node.Walk(root, func(c *node.Cursor) bool {
+		if c.Parent() == nil {
+			c.Replace(replacement)
+		} else {
+			items++
+		}
+		return true
+	}, nil)
+
+	if result != replacement || items != 1 {
+		t.Errorf("root not replaced or new children not walked: %v, %d items", result, items)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Delete on the root to panic")
+		}
+	}()
+	node.Walk(root, func(c *node.Cursor) bool {
+		c.Delete()
+		return true
+	}, nil)
+}
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/elioetibr/golang-yaml/pkg/errors"
 	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
 )
 
 func TestAnchorAndAlias(t *testing.T) {
@@ -131,6 +134,9 @@ func TestTags(t *testing.T) {
 				if value.Tag() != "!Person" {
 					t.Errorf("Expected !Person tag, got %q", value.Tag())
 				}
+				if person, ok := value.(*node.MappingNode); !ok || len(person.Pairs) != 2 {
+					t.Errorf("Expected the tagged mapping to hold name and age, got %#v", value)
+				}
 			},
 		},
 		{
@@ -279,6 +285,30 @@ service2:
 	}
 }
 
+// TestQuotedMergeKey checks that a quoted << is an ordinary key, as
+// node.IsMergeKey decides
+func TestQuotedMergeKey(t *testing.T) {
+	for _, input := range []string{
+		"base: &b {x: 1}\nm:\n  \"<<\": *b\n",
+		"base: &b {x: 1}\nm:\n  '<<': *b\n",
+	} {
+		for _, preserve := range []bool{false, true} {
+			root, err := ParseStringWithOptions(input, &Options{PreserveAliases: preserve})
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			m, _ := node.Lookup(root, "m")
+			pairs := m.(*node.MappingNode).Pairs
+			if len(pairs) != 1 || pairs[0].Key.(*node.ScalarNode).Value != node.MergeKey {
+				t.Errorf("%q: expected the key kept as an ordinary key, got %d pairs", input, len(pairs))
+			}
+			if x, _ := node.Lookup(root, "m.x"); x != nil {
+				t.Errorf("%q: expected nothing merged", input)
+			}
+		}
+	}
+}
+
 func TestDirectives(t *testing.T) {
 	input := `%YAML 1.2
 %TAG ! tag:example.com,2014:
@@ -331,9 +361,14 @@ func TestTagResolution(t *testing.T) {
 		{"!!float", "tag:yaml.org,2002:float"},
 		{"!!bool", "tag:yaml.org,2002:bool"},
 		{"!custom", "!custom"},
+		{"!<tag:example.com,2024:foo>", "tag:example.com,2024:foo"},
+		{"!e!foo", "tag:example.com,2024:foo"},
+		{"!x!foo", "!x!foo"},
+		{"!", "!"},
 		{"", ""},
 	}
 
+	resolver.AddTagDirective("!e!", "tag:example.com,2024:")
 	for _, tt := range tests {
 		resolved := resolver.ResolveTag(tt.tag)
 		if resolved != tt.expected {
@@ -342,6 +377,105 @@ func TestTagResolution(t *testing.T) {
 	}
 }
 
+// TestTagDirectives checks that %TAG handles resolve the tags of their own
+// document only, and that the tags keep the shorthand they were written as
+func TestTagDirectives(t *testing.T) {
+	input := "%TAG !e! tag:example.com,2024:\n%TAG ! tag:local.org,1:\n%TAG !! tag:other.org,1:\n---\n" +
+		"a: !e!foo 1\nb: !<tag:x.org,1:y> 2\nc: !!str 3\nd: !bar 4\ne: {k: !e!v x}\nf: [!e!w y]\n" +
+		"---\nh: !!str 6\ni: !bar 7\n"
+	stream, err := ParseStreamWithOptions(input, &Options{ResolveTags: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(stream.Documents) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(stream.Documents))
+	}
+
+	tests := []struct {
+		doc       int
+		path      string
+		tag       string
+		shorthand string
+	}{
+		{0, "a", "tag:example.com,2024:foo", "!e!foo"},
+		{0, "b", "tag:x.org,1:y", "!<tag:x.org,1:y>"},
+		{0, "c", "tag:other.org,1:str", "!!str"},
+		{0, "d", "tag:local.org,1:bar", "!bar"},
+		{0, "e.k", "tag:example.com,2024:v", "!e!v"},
+		{0, "f[0]", "tag:example.com,2024:w", "!e!w"},
+		{1, "h", "tag:yaml.org,2002:str", "!!str"},
+		{1, "i", "!bar", ""},
+	}
+
+	for _, tt := range tests {
+		n, err := node.Lookup(stream.Documents[tt.doc].Root, tt.path)
+		if err != nil {
+			t.Fatalf("Lookup %s error: %v", tt.path, err)
+		}
+		base := n.(*node.ScalarNode).BaseNode
+		if base.TagValue != tt.tag || base.TagShorthand != tt.shorthand {
+			t.Errorf("%s: expected tag %q written %q, got %q written %q", tt.path, tt.tag, tt.shorthand, base.TagValue, base.TagShorthand)
+		}
+	}
+
+	// Tags are kept as written without ResolveTags
+	root, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if a, _ := node.Lookup(root, "a"); a.Tag() != "!e!foo" {
+		t.Errorf("Expected the tag as written, got %q", a.Tag())
+	}
+}
+
+// TestUndefinedTagHandle checks that a %TAG directive does not carry over
+// to the next document, which reports its handle as undefined
+func TestUndefinedTagHandle(t *testing.T) {
+	input := "%TAG !e! tag:example.com,2024:\n---\na: !e!foo 1\n---\nb: 2\nc: !e!foo 3\n"
+	_, err := ParseStreamWithOptions(input, &Options{ResolveTags: true})
+	yamlErr, ok := err.(*errors.YAMLError)
+	if !ok {
+		t.Fatalf("Expected a *errors.YAMLError, got %v", err)
+	}
+	if yamlErr.Message != `undefined tag handle "!e!"` || yamlErr.Position.Line != 6 || yamlErr.Position.Column != 4 {
+		t.Errorf("Expected an undefined tag handle at 6:4, got %v", yamlErr)
+	}
+
+	// Tags are kept as written without ResolveTags
+	if _, err := ParseStream(input); err != nil {
+		t.Errorf("Expected no error without ResolveTags, got %v", err)
+	}
+}
+
+// TestTagDirectivesOverResolver checks that a %TAG directive overrides a
+// handle of the resolver for its document only, across documents and
+// separate parses sharing the options
+func TestTagDirectivesOverResolver(t *testing.T) {
+	resolver := NewTagResolver()
+	resolver.AddTagDirective("!e!", "tag:default.org,1:")
+	opts := &Options{TagResolver: resolver, ResolveTags: true}
+
+	stream, err := ParseStreamWithOptions("%TAG !e! tag:example.com,2024:\n---\na: !e!foo x\n---\na: !e!foo y\n", opts)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	later, err := ParseStringWithOptions("a: !e!foo z\n", opts)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	roots := []node.Node{stream.Documents[0].Root, stream.Documents[1].Root, later}
+	tags := []string{"tag:example.com,2024:foo", "tag:default.org,1:foo", "tag:default.org,1:foo"}
+	for i, root := range roots {
+		if a, _ := node.Lookup(root, "a"); a.Tag() != tags[i] {
+			t.Errorf("Document %d: expected tag %q, got %q", i+1, tags[i], a.Tag())
+		}
+	}
+	if resolver.ResolveTag("!e!foo") != "tag:default.org,1:foo" {
+		t.Error("Expected the resolver to keep its own handle")
+	}
+}
+
 func TestTagInference(t *testing.T) {
 	tests := []struct {
 		value    string
@@ -514,3 +648,280 @@ content: here
 		t.Error("Expected third document to be empty")
 	}
 }
+
+func TestExpandedAliasesDefineAnchorOnce(t *testing.T) {
+	input := `defaults: &defaults
+  timeout: 30
+service:
+  <<: *defaults
+  name: api
+copy: *defaults`
+
+	root, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	output, err := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	if strings.Count(output, "&defaults") != 1 {
+		t.Errorf("Expected the anchor to be written once:\n%s", output)
+	}
+	if _, err := ParseString(output); err != nil {
+		t.Errorf("Output does not parse back: %v\n%s", err, output)
+	}
+}
+
+func TestDocumentNode(t *testing.T) {
+	stream, err := ParseStream("%YAML 1.2\n---\nkey: value\n...\n")
+	if err != nil {
+		t.Fatalf("ParseStream error: %v", err)
+	}
+
+	doc := stream.Documents[0].Node()
+	if !doc.ExplicitStart || !doc.ExplicitEnd || len(doc.Directives) != 1 {
+		t.Errorf("Document metadata lost: %+v", doc)
+	}
+
+	output, err := serializer.SerializeToString(doc, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	if output != "%YAML 1.2\n---\nkey: value\n...\n" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestPreserveAliases(t *testing.T) {
+	input := "defaults: &defaults\n  timeout: 30\n  retries: 3\nname: !custom &name app\nservice:\n  <<: *defaults\n  retries: 5\n  label: *name\nmixins:\n  <<: [*defaults]\n"
+
+	root, err := ParseStringWithOptions(input, &Options{PreserveAliases: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	service := root.(*node.MappingNode).Pairs[2].Value.(*node.MappingNode)
+	if len(service.Pairs) != 3 || !node.IsMergeKey(service.Pairs[0].Key) {
+		t.Fatalf("Merge key should stay in place, got %d pairs", len(service.Pairs))
+	}
+	alias, ok := service.Pairs[0].Value.(*node.AliasNode)
+	if !ok || alias.Name != "defaults" || alias.Target != root.(*node.MappingNode).Pairs[0].Value {
+		t.Fatalf("Expected an alias to the anchored node, got %#v", service.Pairs[0].Value)
+	}
+	if alias.Line() != 6 {
+		t.Errorf("Alias position not recorded, line %d", alias.Line())
+	}
+
+	// Explicit keys win over merged ones and merged pairs take the place of <<
+	merged := node.MergedPairs(service)
+	var keys []string
+	for _, pair := range merged {
+		keys = append(keys, pair.Key.(*node.ScalarNode).Value+"="+node.Resolve(pair.Value).(*node.ScalarNode).Value)
+	}
+	if strings.Join(keys, ",") != "timeout=30,retries=5,label=app" {
+		t.Errorf("Unexpected merged pairs: %v", keys)
+	}
+
+	opts := serializer.DefaultOptions()
+	opts.EmitTags = true
+	output, err := serializer.SerializeToString(root, opts)
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	for _, want := range []string{"defaults: &defaults\n", "name: &name !custom app\n", "  <<: *defaults\n", "  label: *name\n", "  <<: [*defaults]"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Output missing %q:\n%s", want, output)
+		}
+	}
+
+	if _, err := ParseStringWithOptions("a: *missing\n", &Options{PreserveAliases: true}); err == nil {
+		t.Error("Expected an error for an undefined alias")
+	}
+}
+
+func TestParserOptions(t *testing.T) {
+	input := "base: &base {a: 1}\n# about the service\nservice:\n  <<: *base\n  port: !!int 80\n"
+
+	t.Run("defaults", func(t *testing.T) {
+		root, err := ParseStringWithOptions(input, nil)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		service := root.(*node.MappingNode).Pairs[1]
+		if len(service.Value.(*node.MappingNode).Pairs) != 2 || service.Key.(*node.ScalarNode).HeadComment == nil {
+			t.Error("Expected merged keys and attached comments by default")
+		}
+	})
+
+	t.Run("merge keys disabled", func(t *testing.T) {
+		root, err := ParseStringWithOptions(input, &Options{DisableMergeKeys: true})
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		pairs := root.(*node.MappingNode).Pairs[1].Value.(*node.MappingNode).Pairs
+		if pairs[0].Key.(*node.ScalarNode).Value != "<<" {
+			t.Fatalf("Expected << to stay an ordinary key")
+		}
+		if _, ok := pairs[0].Value.(*node.MappingNode); !ok {
+			t.Errorf("Expected the alias under << to be expanded, got %T", pairs[0].Value)
+		}
+	})
+
+	t.Run("comments discarded", func(t *testing.T) {
+		root, err := ParseStringWithOptions(input, &Options{DiscardComments: true})
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		node.Walk(root, func(c *node.Cursor) bool {
+			if b, ok := c.Node().(interface{ GetBase() *node.BaseNode }); ok && b.GetBase().HeadComment != nil {
+				t.Errorf("Unexpected comment at %s", c.Path())
+			}
+			return true
+		}, nil)
+	})
+
+	t.Run("tags resolved", func(t *testing.T) {
+		root, err := ParseStringWithOptions(input, &Options{ResolveTags: true})
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		port, _ := node.Lookup(root, "service.port")
+		if port.Tag() != "tag:yaml.org,2002:int" {
+			t.Errorf("Expected the full tag, got %q", port.Tag())
+		}
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		for _, dup := range []string{"a: 1\nb: 2\na: 3\n", "{a: 1, a: 2}\n"} {
+			if _, err := ParseStringWithOptions(dup, &Options{DuplicateKeys: DuplicateKeysError}); err == nil {
+				t.Errorf("Expected an error for %q", dup)
+			}
+			if _, err := ParseString(dup); err != nil {
+				t.Errorf("Duplicate keys should be allowed by default: %v", err)
+			}
+		}
+	})
+
+	t.Run("custom tag resolver", func(t *testing.T) {
+		resolver := NewTagResolver()
+		docs, err := ParseAllDocumentsWithOptions("%TAG !e! tag:example.com,2024:\n---\na: 1\n---\nb: 2\nb: 3\n", &Options{
+			TagResolver:   resolver,
+			DuplicateKeys: DuplicateKeysError,
+		})
+		if err == nil {
+			t.Errorf("Expected options to apply to every document, got %d documents", len(docs))
+		}
+		if _, ok := resolver.tagShorthands["!e!"]; ok {
+			t.Error("Expected the TAG directive to leave the custom resolver unchanged")
+		}
+	})
+}
+
+// TestSharedTagResolver checks that one resolver serves several parses
+// without collecting the %TAG directives of earlier documents
+func TestSharedTagResolver(t *testing.T) {
+	resolver := NewTagResolver()
+	resolver.RegisterCustomHandler("tag:example.com,2024:foo", func(value string) (interface{}, error) {
+		return "foo:" + value, nil
+	})
+	opts := &Options{TagResolver: resolver, ResolveTags: true}
+
+	first, err := ParseStringWithOptions("%TAG !e! tag:example.com,2024:\n---\na: !e!foo x\n", opts)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if value, _ := node.Lookup(first, "a"); value == nil || value.Tag() != "tag:example.com,2024:foo" {
+		t.Errorf("Expected the directive to resolve the tag, got %v", value)
+	}
+	if _, err := ParseStringWithOptions("a: !e!foo x\n", opts); err == nil || !strings.Contains(err.Error(), "undefined tag handle") {
+		t.Errorf("Expected the handle to be undefined in a later parse, got %v", err)
+	}
+
+	if len(resolver.tagShorthands) != len(NewTagResolver().tagShorthands) {
+		t.Errorf("Expected the resolver handles to be unchanged, got %v", resolver.tagShorthands)
+	}
+	if handler, ok := resolver.Handler("tag:example.com,2024:foo"); !ok {
+		t.Error("Expected the handler to stay registered")
+	} else if v, _ := handler("x"); v != "foo:x" {
+		t.Errorf("Expected foo:x, got %v", v)
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		dup     errors.Position // zero when the input has no duplicate
+		first   errors.Position
+		message string
+	}{
+		{
+			name:    "block mapping",
+			input:   "a: 1\nb: 2\na: 3\n",
+			dup:     errors.Position{Line: 3, Column: 1, Offset: 10},
+			first:   errors.Position{Line: 1, Column: 1, Offset: 0},
+			message: `duplicate key "a" (first defined at line 1, column 1)`,
+		},
+		{
+			name:  "flow mapping",
+			input: "{a: 1, a: 2}\n",
+			dup:   errors.Position{Line: 1, Column: 8, Offset: 7},
+			first: errors.Position{Line: 1, Column: 2, Offset: 1},
+		},
+		{
+			name:  "resolved integers",
+			input: "1: one\n0x1: also one\n",
+			dup:   errors.Position{Line: 2, Column: 1, Offset: 7},
+			first: errors.Position{Line: 1, Column: 1, Offset: 0},
+		},
+		{
+			name:  "repeated merge key",
+			input: "a: &a {x: 1}\nb: &b {y: 2}\nc:\n  <<: *a\n  <<: *b\n",
+			dup:   errors.Position{Line: 5, Column: 3, Offset: 40},
+			first: errors.Position{Line: 4, Column: 3, Offset: 31},
+		},
+		{name: "integer and string", input: "1: one\n\"1\": also one\n"},
+		{name: "nested mappings", input: "a: {x: 1}\nb: {x: 2}\n"},
+		{name: "merged key overridden", input: "base: &b {x: 1, y: 2}\nc:\n  <<: *b\n  x: 3\n"},
+		{name: "merged key overridden by resolved value", input: "base: &b {1: a}\nc:\n  <<: *b\n  0x1: b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, preserve := range []bool{false, true} {
+				opts := StrictOptions()
+				opts.PreserveAliases = preserve
+				_, err := ParseStringWithOptions(tt.input, opts)
+				if tt.dup.Line == 0 {
+					if err != nil {
+						t.Errorf("Unexpected error (preserve=%v): %v", preserve, err)
+					}
+					continue
+				}
+
+				yamlErr, ok := err.(*errors.YAMLError)
+				if !ok {
+					t.Fatalf("Expected a *errors.YAMLError (preserve=%v), got %v", preserve, err)
+				}
+				if yamlErr.Type != errors.ErrorTypeParser {
+					t.Errorf("Expected a parser error, got %v", yamlErr.Type)
+				}
+				if yamlErr.Position != tt.dup {
+					t.Errorf("Expected the duplicate at %+v, got %+v", tt.dup, yamlErr.Position)
+				}
+				if len(yamlErr.Related) != 1 || yamlErr.Related[0] != tt.first {
+					t.Errorf("Expected the first key at %+v, got %+v", tt.first, yamlErr.Related)
+				}
+				if tt.message != "" && yamlErr.Message != tt.message {
+					t.Errorf("Expected message %q, got %q", tt.message, yamlErr.Message)
+				}
+			}
+
+			if _, err := ParseString(tt.input); err != nil {
+				t.Errorf("Duplicate keys should be allowed by default: %v", err)
+			}
+		})
+	}
+}
@@ -2,12 +2,15 @@ package parser
 
 import (
 	"fmt"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
 	"github.com/elioetibr/golang-yaml/pkg/node"
 )
 
 // AnchorRegistry tracks anchor definitions and resolves aliases
 type AnchorRegistry struct {
 	anchors map[string]node.Node
+	budget  *budget // limits expansions when set by a parser
 }
 
 // NewAnchorRegistry creates a new anchor registry
@@ -31,21 +34,46 @@ func (r *AnchorRegistry) RegisterAnchor(name string, n node.Node) error {
 	return nil
 }
 
+// undefinedAliasError reports an alias without a matching anchor
+type undefinedAliasError string
+
+func (e undefinedAliasError) Error() string {
+	return fmt.Sprintf("undefined alias %q", string(e))
+}
+
 // ResolveAlias resolves an alias to its anchored node
 func (r *AnchorRegistry) ResolveAlias(name string) (node.Node, error) {
+	return r.resolveAlias(name, 1)
+}
+
+// resolveAlias copies the node anchored as name for an alias at depth,
+// provided the copy stays within the parser's limits
+func (r *AnchorRegistry) resolveAlias(name string, depth int) (node.Node, error) {
 	if name == "" {
 		return nil, fmt.Errorf("alias name cannot be empty")
 	}
 
 	n, exists := r.anchors[name]
 	if !exists {
-		return nil, fmt.Errorf("undefined alias %q", name)
+		return nil, undefinedAliasError(name)
+	}
+
+	if r.budget != nil {
+		if err := r.budget.expand(name, n, depth); err != nil {
+			return nil, err
+		}
 	}
 
 	// Clone the node to avoid shared references issues
 	return cloneNode(n), nil
 }
 
+// Lookup returns the node registered under an anchor name without copying it
+func (r *AnchorRegistry) Lookup(name string) (node.Node, bool) {
+	n, exists := r.anchors[name]
+	return n, exists
+}
+
 // HasAnchor checks if an anchor is defined
 func (r *AnchorRegistry) HasAnchor(name string) bool {
 	_, exists := r.anchors[name]
@@ -57,71 +85,29 @@ func (r *AnchorRegistry) Clear() {
 	r.anchors = make(map[string]node.Node)
 }
 
-// cloneNode creates a deep copy of a node
+// cloneNode copies an anchored node for an alias. Comments and anchors stay
+// with the original, so serializing the expanded tree defines each anchor
+// once; tags, styles and positions are kept.
 func cloneNode(n node.Node) node.Node {
-	if n == nil {
-		return nil
-	}
-
-	builder := &node.DefaultBuilder{}
-
-	switch v := n.(type) {
-	case *node.ScalarNode:
-		clone := builder.BuildScalar(v.Value, v.Style)
-		// Copy anchor if present
-		if anchor := getNodeAnchor(v); anchor != "" {
-			setNodeAnchor(clone, anchor)
-		}
-		return clone
-
-	case *node.SequenceNode:
-		items := make([]node.Node, len(v.Items))
-		for i, item := range v.Items {
-			items[i] = cloneNode(item)
-		}
-		clone := builder.BuildSequence(items, v.Style)
-		if anchor := getNodeAnchor(v); anchor != "" {
-			setNodeAnchor(clone, anchor)
-		}
-		return clone
-
-	case *node.MappingNode:
-		pairs := make([]*node.MappingPair, len(v.Pairs))
-		for i, pair := range v.Pairs {
-			pairs[i] = &node.MappingPair{
-				Key:   cloneNode(pair.Key),
-				Value: cloneNode(pair.Value),
-			}
-		}
-		clone := builder.BuildMapping(pairs, v.Style)
-		if anchor := getNodeAnchor(v); anchor != "" {
-			setNodeAnchor(clone, anchor)
-		}
-		return clone
-
-	default:
-		return n // Fallback for unknown types
-	}
-}
-
-// Helper functions to get/set anchors on nodes
-// These would need to be implemented based on how anchors are stored in BaseNode
-
-func getNodeAnchor(n node.Node) string {
-	// Get anchor from node using the interface method
-	return n.Anchor()
-}
-
-func setNodeAnchor(n node.Node, anchor string) {
-	// Set anchor on node using the interface method
-	n.SetAnchor(anchor)
+	clone := node.Clone(n, &node.CloneOptions{KeepPositions: true})
+	node.Walk(clone, func(c *node.Cursor) bool {
+		c.Node().SetAnchor("")
+		return true
+	}, nil)
+	return clone
 }
 
 // MergeKey represents the YAML merge key (<<)
-const MergeKey = "<<"
+//
+// Deprecated: use node.MergeKey, and node.IsMergeKey to recognise one.
+const MergeKey = node.MergeKey
 
 // ResolveMergeKeys processes merge keys in a mapping node
 func ResolveMergeKeys(n node.Node, registry *AnchorRegistry) error {
+	return resolveMergeKeys(n, registry, 1)
+}
+
+func resolveMergeKeys(n node.Node, registry *AnchorRegistry, depth int) error {
 	mapping, ok := n.(*node.MappingNode)
 	if !ok {
 		return nil // Not a mapping, nothing to merge
@@ -130,29 +116,40 @@ func ResolveMergeKeys(n node.Node, registry *AnchorRegistry) error {
 	var mergedPairs []*node.MappingPair
 	var mergeSources []node.Node
 
+	// resolve expands one alias of a merge key; merged values sit one level
+	// below the mapping
+	resolve := func(alias *node.ScalarNode) error {
+		source, err := registry.resolveAlias(alias.Alias, depth)
+		if err != nil {
+			return errors.New("merge key alias resolution: "+err.Error(), errors.Position{
+				Line:   alias.Line(),
+				Column: alias.Column(),
+				Offset: alias.Range.Start.Offset,
+			}, errors.ErrorTypeParser)
+		}
+		mergeSources = append(mergeSources, source)
+		return nil
+	}
+
 	// First pass: collect merge sources and regular pairs
 	for _, pair := range mapping.Pairs {
-		if scalar, ok := pair.Key.(*node.ScalarNode); ok && scalar.Value == MergeKey {
+		if node.IsMergeKey(pair.Key) {
 			// This is a merge key
 			switch v := pair.Value.(type) {
 			case *node.ScalarNode:
 				// Alias reference
 				if v.Alias != "" {
-					source, err := registry.ResolveAlias(v.Alias)
-					if err != nil {
-						return fmt.Errorf("merge key alias resolution: %w", err)
+					if err := resolve(v); err != nil {
+						return err
 					}
-					mergeSources = append(mergeSources, source)
 				}
 			case *node.SequenceNode:
 				// Multiple merge sources
 				for _, item := range v.Items {
 					if scalar, ok := item.(*node.ScalarNode); ok && scalar.Alias != "" {
-						source, err := registry.ResolveAlias(scalar.Alias)
-						if err != nil {
-							return fmt.Errorf("merge key alias resolution: %w", err)
+						if err := resolve(scalar); err != nil {
+							return err
 						}
-						mergeSources = append(mergeSources, source)
 					}
 				}
 			}
@@ -163,22 +160,19 @@ func ResolveMergeKeys(n node.Node, registry *AnchorRegistry) error {
 	}
 
 	// Second pass: merge sources (in order, earlier sources have lower precedence)
+	// Keys are compared by resolved value, so an explicit 0x1 overrides a merged 1
 	mergedKeys := make(map[string]bool)
 	for _, pair := range mergedPairs {
-		if scalar, ok := pair.Key.(*node.ScalarNode); ok {
-			mergedKeys[scalar.Value] = true
-		}
+		mergedKeys[node.KeyID(pair.Key)] = true
 	}
 
 	// Add pairs from merge sources if keys don't exist
 	for _, source := range mergeSources {
 		if sourceMapping, ok := source.(*node.MappingNode); ok {
 			for _, sourcePair := range sourceMapping.Pairs {
-				if scalar, ok := sourcePair.Key.(*node.ScalarNode); ok {
-					if !mergedKeys[scalar.Value] {
-						mergedPairs = append(mergedPairs, sourcePair)
-						mergedKeys[scalar.Value] = true
-					}
+				if id := node.KeyID(sourcePair.Key); !mergedKeys[id] {
+					mergedPairs = append(mergedPairs, sourcePair)
+					mergedKeys[id] = true
 				}
 			}
 		}
@@ -189,7 +183,7 @@ func ResolveMergeKeys(n node.Node, registry *AnchorRegistry) error {
 
 	// Recursively resolve merge keys in nested mappings
 	for _, pair := range mapping.Pairs {
-		if err := ResolveMergeKeys(pair.Value, registry); err != nil {
+		if err := resolveMergeKeys(pair.Value, registry, depth+1); err != nil {
 			return err
 		}
 	}
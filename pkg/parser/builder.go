@@ -0,0 +1,304 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/lexer"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// treeBuilder builds node trees from the events of a parser. It registers
+// anchors, expands aliases and merge keys within the parser's limits, and
+// checks mappings for duplicate keys.
+type treeBuilder struct {
+	p           *Parser
+	firstOnly   bool // stop after the first document
+	docs        []*Document
+	doc         *Document
+	root        node.Node
+	stack       []*frame
+	mergeValues int // merge key values being built, whose aliases stay unresolved
+
+	// Source text of the input in KeepSource mode
+	source    *string
+	nextStart node.Position // where the source of the next document starts
+}
+
+// frame is a collection waiting for its End event
+type frame struct {
+	start      *Event
+	items      []node.Node
+	pairs      []*node.MappingPair
+	key        node.Node
+	hasKey     bool
+	mergeValue bool // the value being built belongs to a merge key
+}
+
+func newTreeBuilder(p *Parser) *treeBuilder {
+	return &treeBuilder{p: p, docs: make([]*Document, 0)}
+}
+
+// handle adds one event to the tree and reports whether more are wanted
+func (b *treeBuilder) handle(ev *Event) bool {
+	switch ev.Type {
+	case EventDocumentStart:
+		b.p.anchorRegistry.Clear()
+		b.doc = &Document{Directives: ev.Directives, ExplicitStart: ev.Explicit}
+		b.root = nil
+		b.stack = b.stack[:0]
+		b.mergeValues = 0
+	case EventDocumentEnd:
+		b.endDocument(ev)
+		return !b.firstOnly
+	case EventScalar:
+		b.add(b.scalar(ev))
+	case EventAlias:
+		b.add(b.alias(ev))
+	case EventSequenceStart:
+		b.stack = append(b.stack, &frame{start: ev, items: make([]node.Node, 0)})
+	case EventMappingStart:
+		b.stack = append(b.stack, &frame{start: ev, pairs: make([]*node.MappingPair, 0)})
+	case EventSequenceEnd, EventMappingEnd:
+		if len(b.stack) > 0 {
+			f := b.stack[len(b.stack)-1]
+			b.stack = b.stack[:len(b.stack)-1]
+			b.add(b.collection(f, ev))
+		}
+	}
+	return true
+}
+
+// add places a finished node in the collection being built, or makes it
+// the root
+func (b *treeBuilder) add(n node.Node) {
+	if len(b.stack) == 0 {
+		b.root = n
+		return
+	}
+
+	f := b.stack[len(b.stack)-1]
+	if f.start.Type == EventSequenceStart {
+		if n != nil {
+			f.items = append(f.items, n)
+		}
+		return
+	}
+
+	if !f.hasKey {
+		f.key, f.hasKey = n, true
+		if node.IsMergeKey(n) && !b.p.options.DisableMergeKeys {
+			f.mergeValue = true
+			b.mergeValues++
+		}
+		return
+	}
+
+	f.pairs = append(f.pairs, &node.MappingPair{Key: f.key, Value: n})
+	f.key, f.hasKey = nil, false
+	if f.mergeValue {
+		f.mergeValue = false
+		b.mergeValues--
+	}
+}
+
+func (b *treeBuilder) scalar(ev *Event) node.Node {
+	if ev.absent {
+		return nil
+	}
+	n := b.p.nodeBuilder.BuildScalar(ev.Value, ev.Style)
+	b.finish(n, &n.BaseNode, ev, ev)
+	return n
+}
+
+func (b *treeBuilder) collection(f *frame, end *Event) node.Node {
+	if f.start.Type == EventSequenceStart {
+		n := b.p.nodeBuilder.BuildSequence(f.items, f.start.Style)
+		b.finish(n, &n.BaseNode, f.start, end)
+		return n
+	}
+
+	b.p.checkDuplicateKeys(f.pairs)
+	n := b.p.nodeBuilder.BuildMapping(f.pairs, f.start.Style)
+	b.finish(n, &n.BaseNode, f.start, end)
+	return n
+}
+
+// finish sets the position, comments and properties of n from the events
+// that start and end it. A line comment on the start event wins.
+func (b *treeBuilder) finish(n node.Node, base *node.BaseNode, start, end *Event) {
+	base.LineNumber = start.Range.Start.Line
+	base.ColumnNumber = start.Range.Start.Column
+	base.Range = end.Range
+	base.HeadComment = end.HeadComment
+	base.LineComment = end.LineComment
+	if start.LineComment != nil {
+		base.LineComment = start.LineComment
+	}
+
+	if start.Anchor != "" {
+		n.SetAnchor(start.Anchor)
+		if err := b.p.anchorRegistry.RegisterAnchor(start.Anchor, n); err != nil {
+			b.p.addErrorAt(err.Error(), n)
+		}
+	}
+	if start.Tag != "" {
+		n.SetTag(start.Tag)
+		base.TagShorthand = start.TagShorthand
+	}
+}
+
+// alias builds the node of an alias: an AliasNode when aliases are
+// preserved, a placeholder for ResolveMergeKeys under a merge key, and a
+// copy of the anchored node otherwise
+func (b *treeBuilder) alias(ev *Event) node.Node {
+	registry := b.p.anchorRegistry
+	var n node.Node
+	switch {
+	case b.p.options.preservesAliases():
+		target, ok := registry.Lookup(ev.Value)
+		if !ok {
+			b.p.addErrorAtPosition(undefinedAliasError(ev.Value).Error(), eventPosition(ev))
+			return nil
+		}
+		aliasNode := &node.AliasNode{Name: ev.Value, Target: target}
+		setEventPosition(&aliasNode.BaseNode, ev)
+		return aliasNode
+	case b.mergeValues > 0:
+		placeholder := &node.ScalarNode{Style: node.StylePlain, Alias: ev.Value}
+		setEventPosition(&placeholder.BaseNode, ev)
+		n = placeholder
+	default:
+		resolved, err := registry.resolveAlias(ev.Value, len(b.stack)+1)
+		if err != nil {
+			if _, undefined := err.(undefinedAliasError); undefined {
+				b.p.addErrorAtPosition(err.Error(), eventPosition(ev))
+			} else {
+				b.p.limitErrorAt(err.Error(), eventPosition(ev))
+			}
+			return nil
+		}
+		n = resolved
+	}
+
+	if ev.LineComment != nil {
+		switch v := n.(type) {
+		case *node.ScalarNode:
+			v.LineComment = ev.LineComment
+		case *node.SequenceNode:
+			v.LineComment = ev.LineComment
+		case *node.MappingNode:
+			v.LineComment = ev.LineComment
+		}
+	}
+	return n
+}
+
+func (b *treeBuilder) endDocument(ev *Event) {
+	doc := b.doc
+	if doc == nil {
+		doc = &Document{Directives: make([]Directive, 0)}
+	}
+	doc.Root = b.root
+	doc.ExplicitEnd = ev.Explicit
+	doc.Range = ev.Range
+
+	if b.root != nil {
+		addComments(b.root, ev.HeadComment, ev.LineComment)
+
+		// Resolve merge keys after parsing
+		if b.p.options.expandsMergeKeys() {
+			if err := ResolveMergeKeys(b.root, b.p.anchorRegistry); err != nil {
+				b.p.errors = append(b.p.errors, asYAMLError(err))
+			}
+		}
+	}
+
+	if b.p.options.KeepSource {
+		b.recordSource()
+	}
+
+	b.docs = append(b.docs, doc)
+	b.doc = nil
+}
+
+// recordSource records the input on the nodes of the document just built.
+// The document's outer range reaches up to the first token or comment of
+// the next document, or to the end of the input.
+func (b *treeBuilder) recordSource() {
+	if b.source == nil {
+		text, ok := b.p.lexer.Input()
+		if !ok {
+			return
+		}
+		b.source = &text
+		b.nextStart = node.Position{Line: 1, Column: 1}
+		if strings.HasPrefix(text, "\uFEFF") {
+			b.nextStart.Offset = len("\uFEFF")
+		}
+	}
+	text := *b.source
+
+	end := len(text)
+	if b.p.current != nil && b.p.current.Type != lexer.TokenEOF {
+		end = b.p.current.Offset
+	}
+	for _, tok := range b.p.commentQueue {
+		end = min(end, tok.Offset)
+	}
+	end = max(end, b.nextStart.Offset)
+
+	start := b.nextStart
+	b.nextStart = positionAfter(start, text[start.Offset:end])
+	if b.root != nil {
+		node.RecordSource(b.root, text, node.Range{Start: start, End: b.nextStart})
+	}
+}
+
+// positionAfter returns the position reached by reading text from pos
+func positionAfter(pos node.Position, text string) node.Position {
+	pos.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Column = len(text) - i
+	} else {
+		pos.Column += len(text)
+	}
+	return pos
+}
+
+// addComments adds comment groups to those of n
+func addComments(n node.Node, head, line *node.CommentGroup) {
+	var base *node.BaseNode
+	switch v := n.(type) {
+	case *node.ScalarNode:
+		base = &v.BaseNode
+	case *node.SequenceNode:
+		base = &v.BaseNode
+	case *node.MappingNode:
+		base = &v.BaseNode
+	default:
+		return
+	}
+	if head != nil {
+		base.HeadComment = node.MergeCommentGroups(base.HeadComment, head)
+	}
+	if line != nil {
+		base.LineComment = node.MergeCommentGroups(base.LineComment, line)
+	}
+}
+
+func setEventPosition(base *node.BaseNode, ev *Event) {
+	base.LineNumber = ev.Range.Start.Line
+	base.ColumnNumber = ev.Range.Start.Column
+	base.Range = ev.Range
+}
+
+// eventPosition returns the start of ev as an error position
+func eventPosition(ev *Event) errors.Position {
+	start := ev.Range.Start
+	if !start.IsValid() {
+		return errors.Position{Line: 1, Column: 1}
+	}
+	return errors.Position{Line: start.Line, Column: start.Column, Offset: start.Offset}
+}
@@ -18,12 +18,27 @@ type Document struct {
 	// Whether the document has explicit start/end markers
 	ExplicitStart bool
 	ExplicitEnd   bool
+
+	// Range spans the directives, markers and content of the document
+	Range node.Range
 }
 
 // Directive represents a YAML directive
-type Directive struct {
-	Name       string
-	Parameters []string
+type Directive = node.Directive
+
+// Node returns the document as a node.DocumentNode
+func (d *Document) Node() *node.DocumentNode {
+	return &node.DocumentNode{
+		BaseNode: node.BaseNode{
+			LineNumber:   d.Range.Start.Line,
+			ColumnNumber: d.Range.Start.Column,
+			Range:        d.Range,
+		},
+		Directives:    d.Directives,
+		Content:       d.Root,
+		ExplicitStart: d.ExplicitStart,
+		ExplicitEnd:   d.ExplicitEnd,
+	}
 }
 
 // Stream represents a stream of YAML documents
@@ -44,34 +59,14 @@ func ParseStream(input string) (*Stream, error) {
 
 // ParseStream parses multiple YAML documents from the input
 func (p *Parser) ParseStream() (*Stream, error) {
-	stream := &Stream{
-		Documents: make([]*Document, 0),
-	}
-
-	// Initialize by reading the first two tokens
-	if err := p.advance(); err != nil {
-		return nil, err
-	}
-	if err := p.advance(); err != nil {
-		return nil, err
-	}
-
-	for p.current != nil && p.current.Type != lexer.TokenEOF {
-		doc, err := p.parseDocument()
-		if err != nil {
-			return nil, err
-		}
-		if doc != nil {
-			stream.Documents = append(stream.Documents, doc)
-		}
-
-		// Check for more documents
-		if p.current == nil || p.current.Type == lexer.TokenEOF {
-			break
-		}
-	}
+	b := newTreeBuilder(p)
+	p.run(b.handle)
+	stream := &Stream{Documents: b.docs}
 
 	if len(p.errors) > 0 {
+		if p.options.Recover {
+			return stream, p.errors[0]
+		}
 		return nil, p.errors[0]
 	}
 
@@ -79,47 +74,61 @@ func (p *Parser) ParseStream() (*Stream, error) {
 }
 
 // parseDocument parses a single YAML document
-func (p *Parser) parseDocument() (*Document, error) {
-	doc := &Document{
-		Directives: make([]Directive, 0),
+func (p *Parser) parseDocument() {
+	p.budget.reset()
+	if p.current != nil {
+		p.docStart = p.current.Offset
 	}
 
-	// Clear anchor registry for new document
-	p.anchorRegistry.Clear()
+	var start *lexer.Token
+	if p.current != nil && p.current.Type != lexer.TokenEOF {
+		start = p.current
+	}
+
+	// %TAG directives apply to their own document only
+	p.tagHandles = nil
 
 	// Parse directives
+	directives := make([]Directive, 0)
 	for p.current != nil && p.current.Type == lexer.TokenDirective {
 		directive := p.parseDirective()
 		if directive != nil {
-			doc.Directives = append(doc.Directives, *directive)
+			directives = append(directives, *directive)
 		}
 	}
 
+	startEvent := &Event{Type: EventDocumentStart, Directives: directives}
+	if start != nil {
+		startEvent.Range = tokenRange(start)
+	}
+
 	// Check for document start marker
 	if p.current != nil && p.current.Type == lexer.TokenDocumentStart {
-		doc.ExplicitStart = true
+		startEvent.Explicit = true
 		p.advance()
 	}
+	p.emit(startEvent)
 
 	// Parse the document content
+	endEvent := &Event{Type: EventDocumentEnd}
 	if p.current != nil && p.current.Type != lexer.TokenDocumentEnd && p.current.Type != lexer.TokenEOF {
-		doc.Root = p.parseNode(0)
+		p.parseNode(0)
+		p.checkDocumentEnd()
+
+		// Comments left at the end belong to the root node
+		p.takeComments(endEvent, 0)
 	}
 
 	// Check for document end marker
 	if p.current != nil && p.current.Type == lexer.TokenDocumentEnd {
-		doc.ExplicitEnd = true
+		endEvent.Explicit = true
 		p.advance()
 	}
 
-	// Apply merge keys if present
-	if doc.Root != nil {
-		if err := ResolveMergeKeys(doc.Root, p.anchorRegistry); err != nil {
-			return nil, err
-		}
+	if start != nil && p.lastEnd.Offset > start.Offset {
+		endEvent.Range = node.Range{Start: tokenStart(start), End: p.lastEnd}
 	}
-
-	return doc, nil
+	p.emit(endEvent)
 }
 
 // parseDirective parses a YAML directive
@@ -160,10 +169,10 @@ func (p *Parser) parseDirective() *Directive {
 		if len(directive.Parameters) >= 2 {
 			handle := directive.Parameters[0]
 			prefix := directive.Parameters[1]
-			// Register with tag resolver if available
-			if p.tagResolver != nil {
-				p.tagResolver.AddTagDirective(handle, prefix)
+			if p.tagHandles == nil {
+				p.tagHandles = make(map[string]string)
 			}
+			p.tagHandles[handle] = prefix
 		}
 	}
 
@@ -195,17 +204,5 @@ func ParseFirstDocument(input string) (node.Node, error) {
 
 // ParseAllDocuments parses all documents and returns their root nodes
 func ParseAllDocuments(input string) ([]node.Node, error) {
-	stream, err := ParseStream(input)
-	if err != nil {
-		return nil, err
-	}
-
-	nodes := make([]node.Node, 0, len(stream.Documents))
-	for _, doc := range stream.Documents {
-		if doc.Root != nil {
-			nodes = append(nodes, doc.Root)
-		}
-	}
-
-	return nodes, nil
+	return ParseAllDocumentsWithOptions(input, nil)
 }
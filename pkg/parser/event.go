@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/lexer"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// EventType identifies the kind of an Event
+type EventType int
+
+const (
+	EventStreamStart EventType = iota
+	EventStreamEnd
+	EventDocumentStart
+	EventDocumentEnd
+	EventSequenceStart
+	EventSequenceEnd
+	EventMappingStart
+	EventMappingEnd
+	EventScalar
+	EventAlias
+)
+
+func (t EventType) String() string {
+	names := map[EventType]string{
+		EventStreamStart:   "STREAM_START",
+		EventStreamEnd:     "STREAM_END",
+		EventDocumentStart: "DOCUMENT_START",
+		EventDocumentEnd:   "DOCUMENT_END",
+		EventSequenceStart: "SEQUENCE_START",
+		EventSequenceEnd:   "SEQUENCE_END",
+		EventMappingStart:  "MAPPING_START",
+		EventMappingEnd:    "MAPPING_END",
+		EventScalar:        "SCALAR",
+		EventAlias:         "ALIAS",
+	}
+	if name, ok := names[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", t)
+}
+
+// Event is one step of a parse: the start or end of the stream, a document
+// or a collection, a scalar, or an alias. Mappings hold their keys and
+// values as alternating nodes.
+type Event struct {
+	Type EventType
+
+	// Value is the text of a scalar or the anchor name an alias refers to
+	Value string
+
+	// Tag and Anchor are the properties written before a scalar or a
+	// collection, set on its Scalar or Start event
+	Tag    string
+	Anchor string
+
+	// TagShorthand is the tag as written when ResolveTags expanded Tag,
+	// such as !e!foo for tag:example.com,2024:foo
+	TagShorthand string
+
+	// Style is the style of a scalar, or StyleBlock or StyleFlow for a
+	// collection
+	Style node.Style
+
+	// Range locates the event in the source. The End event of a
+	// collection or document spans all of it.
+	Range node.Range
+
+	// HeadComment and LineComment are the comments attached to the node
+	// the event describes. The End event of a collection may add to those
+	// of its Start event, and a DocumentEnd event carries the comments
+	// left at the end of the document, which belong to its root node.
+	HeadComment *node.CommentGroup
+	LineComment *node.CommentGroup
+
+	// Directives are the directives of a DocumentStart event
+	Directives []Directive
+
+	// Explicit marks a DocumentStart written as --- or a DocumentEnd
+	// written as ...
+	Explicit bool
+
+	// absent marks the empty scalar standing in for a node that is
+	// missing altogether; the tree builder leaves it nil
+	absent bool
+}
+
+// EventReader reads the events of a YAML stream one at a time, without
+// building a tree. Aliases are reported as written and not expanded.
+type EventReader struct {
+	parser *Parser
+	next   func() (*Event, bool)
+	stop   func()
+	err    error
+}
+
+// NewEventReader creates an event reader for r with the default options
+func NewEventReader(r io.Reader) *EventReader {
+	return NewEventReaderWithOptions(r, nil)
+}
+
+// NewEventReaderWithOptions creates an event reader for r. The input is read
+// as events are requested, so streams of any length are parsed in bounded
+// memory. Options that apply to trees, such as PreserveAliases and
+// DuplicateKeys, are ignored.
+//
+// A nil opts uses DefaultOptions without MaxNodes and MaxAliasExpansions:
+// the reader holds no tree and expands no aliases, so documents of any
+// size stream through. MaxNodes still applies when set explicitly.
+func NewEventReaderWithOptions(r io.Reader, opts *Options) *EventReader {
+	l := lexer.NewLexer(r)
+	if err := l.Initialize(); err != nil {
+		return &EventReader{err: err}
+	}
+
+	if opts == nil {
+		opts = DefaultOptions()
+		opts.MaxNodes = 0
+		opts.MaxAliasExpansions = 0
+	}
+
+	p := NewParserWithOptions(l, opts)
+	next, stop := iter.Pull(p.Events())
+	return &EventReader{parser: p, next: next, stop: stop}
+}
+
+// Next returns the next event. It returns io.EOF after the StreamEnd event
+// or once the reader is closed. Without Options.Recover the first syntax
+// or limit error ends the events and is returned instead.
+func (r *EventReader) Next() (*Event, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	ev, ok := r.next()
+	if !r.parser.options.Recover && len(r.parser.errors) > 0 {
+		r.err = r.parser.errors[0]
+		r.stop()
+		return nil, r.err
+	}
+	if !ok {
+		r.err = io.EOF
+		return nil, r.err
+	}
+	return ev, nil
+}
+
+// Close stops reading. The rest of the input is not parsed.
+func (r *EventReader) Close() error {
+	if r.stop != nil {
+		r.stop()
+	}
+	if r.err == nil {
+		r.err = io.EOF
+	}
+	return nil
+}
+
+// Errors returns the errors found so far. With Options.Recover these are
+// every error of the events read.
+func (r *EventReader) Errors() []*errors.YAMLError {
+	if r.parser == nil {
+		return nil
+	}
+	return r.parser.errors
+}
+
+// Events returns the events of the parse. Stopping the iteration stops the
+// parser; the rest of the input is not read.
+func (p *Parser) Events() iter.Seq[*Event] {
+	return func(yield func(*Event) bool) {
+		p.run(yield)
+	}
+}
+
+// run parses the stream, passing each event to yield until it returns false
+func (p *Parser) run(yield func(*Event) bool) {
+	p.yield = yield
+
+	// Read the first two tokens
+	p.advance()
+	p.advance()
+
+	p.emit(&Event{Type: EventStreamStart})
+	for p.current != nil && p.current.Type != lexer.TokenEOF {
+		p.parseDocument()
+	}
+	p.emit(&Event{Type: EventStreamEnd, Range: node.Range{Start: p.lastEnd, End: p.lastEnd}})
+}
+
+// emit passes ev to the consumer. Once the consumer declines further
+// events the parser halts.
+func (p *Parser) emit(ev *Event) {
+	p.events++
+	if p.stopped || p.yield == nil {
+		return
+	}
+	if !p.yield(ev) {
+		p.stopped = true
+		p.halt(tokenPosition(p.current))
+	}
+}
+
+// nodeProps are the properties parsed ahead of a node, waiting for its
+// first event
+type nodeProps struct {
+	anchor       string
+	tag          string
+	tagShorthand string
+	lineComment  *node.CommentGroup // comment after the key of a block mapping value
+}
+
+// nodeEvent starts the event of a node at tok, taking the pending
+// properties
+func (p *Parser) nodeEvent(typ EventType, tok *lexer.Token) *Event {
+	ev := &Event{
+		Type:         typ,
+		Anchor:       p.props.anchor,
+		Tag:          p.props.tag,
+		TagShorthand: p.props.tagShorthand,
+		LineComment:  p.props.lineComment,
+	}
+	if tok != nil {
+		ev.Range = tokenRange(tok)
+	}
+	p.props = nodeProps{}
+	return ev
+}
+
+// takeComments attaches the queued comments that belong to a node starting
+// at line to ev, or every queued comment when line is 0. A line comment
+// already on ev is kept.
+func (p *Parser) takeComments(ev *Event, line int) {
+	if len(p.commentQueue) == 0 {
+		return
+	}
+	scratch := &node.ScalarNode{}
+	scratch.LineNumber = line
+	p.associateComments(scratch)
+	ev.HeadComment = scratch.HeadComment
+	if ev.LineComment == nil {
+		ev.LineComment = scratch.LineComment
+	}
+}
@@ -0,0 +1,243 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/lexer"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// formatEvent renders the parts of an event the tests compare
+func formatEvent(ev *Event) string {
+	s := fmt.Sprintf("%s@%d:%d", ev.Type, ev.Range.Start.Line, ev.Range.Start.Column)
+	if ev.Value != "" {
+		s += " " + ev.Value
+	}
+	if ev.Tag != "" {
+		s += " tag=" + ev.Tag
+	}
+	if ev.Anchor != "" {
+		s += " anchor=" + ev.Anchor
+	}
+	if ev.Type == EventScalar && ev.Style != node.StylePlain {
+		s += fmt.Sprintf(" style=%d", ev.Style)
+	}
+	if ev.Type == EventSequenceStart || ev.Type == EventMappingStart {
+		s += fmt.Sprintf(" style=%d", ev.Style)
+	}
+	if ev.HeadComment != nil {
+		s += fmt.Sprintf(" head=%q", ev.HeadComment.Comments)
+	}
+	if ev.LineComment != nil {
+		s += fmt.Sprintf(" line=%q", ev.LineComment.Comments)
+	}
+	if ev.Explicit {
+		s += " explicit"
+	}
+	return s
+}
+
+func TestEventReader(t *testing.T) {
+	input := "# head\nname: &n !!str 'web' # line\nports: [80, 443]\nalias: *n\n---\n- x\n"
+	want := []string{
+		"STREAM_START@0:0",
+		"DOCUMENT_START@2:1",
+		fmt.Sprintf("MAPPING_START@2:1 style=%d", node.StyleBlock),
+		`SCALAR@2:1 name head=["# head"]`,
+		fmt.Sprintf(`SCALAR@2:16 web tag=!!str anchor=n style=%d line=["# line"]`, node.StyleSingleQuoted),
+		"SCALAR@3:1 ports",
+		fmt.Sprintf("SEQUENCE_START@3:8 style=%d", node.StyleFlow),
+		"SCALAR@3:9 80",
+		"SCALAR@3:13 443",
+		"SEQUENCE_END@3:8",
+		"SCALAR@4:1 alias",
+		"ALIAS@4:8 n",
+		"MAPPING_END@2:1",
+		"DOCUMENT_END@2:1",
+		"DOCUMENT_START@5:1 explicit",
+		fmt.Sprintf("SEQUENCE_START@6:1 style=%d", node.StyleBlock),
+		"SCALAR@6:3 x",
+		"SEQUENCE_END@6:1",
+		"DOCUMENT_END@5:1",
+		"STREAM_END@6:4",
+	}
+
+	r := NewEventReader(strings.NewReader(input))
+	var got []string
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+		got = append(got, formatEvent(ev))
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected events:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the stream, got %v", err)
+	}
+}
+
+func TestEventReaderEndRanges(t *testing.T) {
+	r := NewEventReader(strings.NewReader("a:\n  b: [1, 2]\n"))
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			break
+		}
+		if ev.Type == EventMappingEnd && ev.Range.Start.Line == 1 {
+			if ev.Range.End != (node.Position{Line: 2, Column: 12, Offset: 14}) {
+				t.Errorf("Expected the mapping to end after ], got %+v", ev.Range.End)
+			}
+			return
+		}
+	}
+	t.Error("Expected an end event for the outer mapping")
+}
+
+func TestEventReaderEarlyTermination(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&sb, "key%d: value%d\n", i, i)
+	}
+
+	input := strings.NewReader(sb.String())
+	r := NewEventReader(input)
+	var value string
+	for value == "" {
+		ev, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+		if ev.Type == EventScalar && ev.Value == "key2" {
+			next, err := r.Next()
+			if err != nil {
+				t.Fatalf("Next error: %v", err)
+			}
+			value = next.Value
+		}
+	}
+	r.Close()
+
+	if value != "value2" {
+		t.Errorf("Expected value2, got %q", value)
+	}
+	if line := r.parser.lastEnd.Line; line > 5 {
+		t.Errorf("Expected parsing to stop near the key, it reached line %d", line)
+	}
+	if unread := input.Len(); unread < sb.Len()/2 {
+		t.Errorf("Expected most of the input to stay unread, %d of %d bytes left", unread, sb.Len())
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF after Close, got %v", err)
+	}
+
+	// Breaking out of Events stops the parser the same way
+	p := NewParser(lexer.NewLexerFromString(sb.String()))
+	count := 0
+	for range p.Events() {
+		if count++; count == 10 {
+			break
+		}
+	}
+	if p.lastEnd.Line > 5 {
+		t.Errorf("Expected parsing to stop after the loop, it reached line %d", p.lastEnd.Line)
+	}
+}
+
+func TestEventReaderErrors(t *testing.T) {
+	r := NewEventReaderWithOptions(strings.NewReader("a: 1\nb: 2\n"), &Options{MaxNodes: 3})
+	var err error
+	for err == nil {
+		_, err = r.Next()
+	}
+	if yamlErr, ok := err.(*errors.YAMLError); !ok || yamlErr.Position.Line != 2 {
+		t.Errorf("Expected an error on line 2, got %v", err)
+	}
+
+	input := "a: 1\n- x\nb: 2\n"
+	r = NewEventReaderWithOptions(strings.NewReader(input), &Options{Recover: true})
+	var scalars []string
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next error in recovering mode: %v", err)
+		}
+		if ev.Type == EventScalar {
+			scalars = append(scalars, ev.Value)
+		}
+	}
+	if strings.Join(scalars, " ") != "a 1 b 2" {
+		t.Errorf("Expected the events around the error, got %v", scalars)
+	}
+	if len(r.Errors()) != 1 {
+		t.Errorf("Expected one error, got %v", r.Errors())
+	}
+}
+
+func TestEventReaderStreamsPastNodeLimit(t *testing.T) {
+	items := DefaultOptions().MaxNodes + 1
+	r := NewEventReader(strings.NewReader(strings.Repeat("- x\n", items)))
+	scalars := 0
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next error after %d scalars: %v", scalars, err)
+		}
+		if ev.Type == EventScalar {
+			scalars++
+		}
+	}
+	if scalars != items {
+		t.Errorf("Expected %d scalars, got %d", items, scalars)
+	}
+}
+
+func TestTreeFromEvents(t *testing.T) {
+	// Aliases stay events; the tree builder expands them
+	input := "base: &b {x: 1}\nuse:\n  <<: *b\n  y: 2\ncopy: *b\n"
+	r := NewEventReader(strings.NewReader(input))
+	aliases := 0
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			break
+		}
+		if ev.Type == EventAlias {
+			aliases++
+		}
+	}
+	if aliases != 2 {
+		t.Errorf("Expected 2 alias events, got %d", aliases)
+	}
+
+	root, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	for path, want := range map[string]string{"use.x": "1", "use.y": "2", "copy.x": "1"} {
+		n, err := node.Lookup(root, path)
+		if err != nil {
+			t.Errorf("Lookup %s: %v", path, err)
+			continue
+		}
+		if scalar, ok := n.(*node.ScalarNode); !ok || scalar.Value != want {
+			t.Errorf("Expected %s to be %s, got %v", path, want, n)
+		}
+	}
+}
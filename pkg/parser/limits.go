@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// budget tracks the work done for one document against the limits in
+// Options
+type budget struct {
+	opts       *Options
+	expansions int
+	nodes      int
+	sizes      map[node.Node]treeSize
+}
+
+// treeSize is the number of nodes in a subtree and its height
+type treeSize struct {
+	nodes  int
+	height int
+}
+
+func newBudget(opts *Options) *budget {
+	return &budget{opts: opts, sizes: make(map[node.Node]treeSize)}
+}
+
+// reset starts counting for a new document
+func (b *budget) reset() {
+	b.expansions = 0
+	b.nodes = 0
+	b.sizes = make(map[node.Node]treeSize)
+}
+
+// addNodes counts n new nodes
+func (b *budget) addNodes(n int) error {
+	b.nodes += n
+	if max := b.opts.MaxNodes; max > 0 && b.nodes > max {
+		return fmt.Errorf("document exceeds the maximum of %d nodes", max)
+	}
+	return nil
+}
+
+// expand checks that target may be copied for an alias at depth and
+// counts the copy
+func (b *budget) expand(name string, target node.Node, depth int) error {
+	b.expansions++
+	if max := b.opts.MaxAliasExpansions; max > 0 && b.expansions > max {
+		return fmt.Errorf("alias *%s exceeds the maximum of %d alias expansions", name, max)
+	}
+
+	size := b.size(target)
+	if max := b.opts.MaxDepth; max > 0 && depth+size.height-1 > max {
+		return fmt.Errorf("alias *%s exceeds the maximum nesting depth of %d", name, max)
+	}
+	return b.addNodes(size.nodes)
+}
+
+// size measures a subtree. Anchored subtrees do not change once parsed,
+// so their sizes are cached.
+func (b *budget) size(n node.Node) treeSize {
+	if size, ok := b.sizes[n]; ok {
+		return size
+	}
+
+	size := treeSize{nodes: 1, height: 1}
+	add := func(child node.Node) {
+		if child == nil {
+			return
+		}
+		s := b.size(child)
+		size.nodes += s.nodes
+		size.height = max(size.height, s.height+1)
+	}
+	switch v := n.(type) {
+	case *node.SequenceNode:
+		for _, item := range v.Items {
+			add(item)
+		}
+	case *node.MappingNode:
+		for _, pair := range v.Pairs {
+			add(pair.Key)
+			add(pair.Value)
+		}
+	case nil:
+		size = treeSize{}
+	}
+
+	if n != nil && n.Anchor() != "" {
+		b.sizes[n] = size
+	}
+	return size
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+)
+
+// laughs builds a document where each level aliases the previous one ten
+// times, expanding to 10^levels scalars
+func laughs(levels int) string {
+	var sb strings.Builder
+	sb.WriteString("l0: &l0 lol\n")
+	for i := 1; i <= levels; i++ {
+		name := "l" + string(rune('0'+i))
+		prev := "*l" + string(rune('0'+i-1))
+		sb.WriteString(name + ": &" + name + " [" + strings.Repeat(prev+", ", 9) + prev + "]\n")
+	}
+	return sb.String()
+}
+
+func TestAliasExpansionLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    *Options
+		message string
+		line    int
+	}{
+		{
+			name:    "billion laughs with defaults",
+			input:   laughs(9),
+			opts:    DefaultOptions(),
+			message: "maximum of 1000000 nodes",
+		},
+		{
+			name:    "expansions",
+			input:   "a: &a x\nb: [*a, *a, *a]\n",
+			opts:    &Options{MaxAliasExpansions: 2},
+			message: "alias *a exceeds the maximum of 2 alias expansions",
+			line:    2,
+		},
+		{
+			name:    "nodes",
+			input:   laughs(2),
+			opts:    &Options{MaxNodes: 50},
+			message: "maximum of 50 nodes",
+			line:    3,
+		},
+		{
+			name:    "depth",
+			input:   "a:\n  b:\n    c: [[[1]]]\n",
+			opts:    &Options{MaxDepth: 5},
+			message: "maximum nesting depth of 5",
+			line:    3,
+		},
+		{
+			name:    "depth through an alias",
+			input:   "a: &a [[1]]\nb:\n  c: *a\n",
+			opts:    &Options{MaxDepth: 4},
+			message: "alias *a exceeds the maximum nesting depth of 4",
+			line:    3,
+		},
+		{
+			name:    "merge key",
+			input:   "a: &a {x: 1}\nb:\n  <<: *a\nc:\n  <<: *a\n",
+			opts:    &Options{MaxAliasExpansions: 1},
+			message: "merge key alias resolution: alias *a exceeds the maximum of 1 alias expansions",
+			line:    5,
+		},
+		{
+			name:    "document size",
+			input:   "a: 1\nb: 2\nc: 3\n",
+			opts:    &Options{MaxDocumentSize: 10},
+			message: "maximum size of 10 bytes",
+			line:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStringWithOptions(tt.input, tt.opts)
+			var yamlErr *errors.YAMLError
+			if !stderrors.As(err, &yamlErr) {
+				t.Fatalf("Expected a *errors.YAMLError, got %v", err)
+			}
+			if !strings.Contains(yamlErr.Message, tt.message) || yamlErr.Type != errors.ErrorTypeParser {
+				t.Errorf("Unexpected error: %v", yamlErr)
+			}
+			if tt.line > 0 && yamlErr.Position.Line != tt.line {
+				t.Errorf("Expected the error on line %d, got %d", tt.line, yamlErr.Position.Line)
+			}
+		})
+	}
+}
+
+func TestLimitsAllowOrdinaryDocuments(t *testing.T) {
+	if _, err := ParseString(laughs(3)); err != nil {
+		t.Errorf("Expected 1000 expanded scalars to parse with the defaults: %v", err)
+	}
+
+	// Limits apply per document in a stream
+	opts := &Options{MaxAliasExpansions: 1, MaxDocumentSize: 20}
+	if _, err := ParseStreamWithOptions("a: &a 1\nb: *a\n---\na: &a 1\nb: *a\n", opts); err != nil {
+		t.Errorf("Expected each document to stay within the limits: %v", err)
+	}
+
+	// Preserved aliases are not expanded while parsing
+	if _, err := ParseStringWithOptions(laughs(9), &Options{PreserveAliases: true, MaxNodes: 1000}); err != nil {
+		t.Errorf("Expected preserved aliases to stay within the limits: %v", err)
+	}
+}
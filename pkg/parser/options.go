@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/lexer"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// Options configures how the parser builds the node tree
+type Options struct {
+	// PreserveAliases keeps *alias references as node.AliasNode values
+	// pointing at their anchored node and leaves merge keys (<<) in place,
+	// so serializing the tree re-emits &anchor, *alias and <<: as written.
+	// Aliases and merge keys are then resolved when the tree is decoded.
+	PreserveAliases bool
+
+	// DisableMergeKeys treats << as an ordinary key instead of merging the
+	// mappings it refers to
+	DisableMergeKeys bool
+
+	// DuplicateKeys decides what happens when a mapping repeats a key
+	DuplicateKeys DuplicateKeyPolicy
+
+	// DiscardComments drops comments instead of attaching them to nodes
+	DiscardComments bool
+
+	// ResolveTags expands tags such as !!int to their full form, here
+	// tag:yaml.org,2002:int, on the nodes. Tags are kept as written
+	// otherwise.
+	ResolveTags bool
+
+	// TagResolver supplies the tag handlers and the default tag handles.
+	// Parsing never modifies it, so one resolver can be shared: the %TAG
+	// directives of a document apply to that document only and are kept
+	// in Document.Directives. The decoder uses its handlers for tagged
+	// scalars decoded into interface values. Nil uses a new NewTagResolver
+	// for each parser.
+	TagResolver *TagResolver
+
+	// KeepSource parses a concrete syntax tree: every node records the
+	// text it was parsed from (node.SourceOf), and the serializer writes
+	// the nodes that were not edited since back byte for byte, reformatting
+	// only what changed. It implies PreserveAliases. Input read from an
+	// io.Reader is not kept.
+	KeepSource bool
+
+	// Recover reports syntax errors and keeps parsing after them. The
+	// parser skips to the next line indented no deeper than the collection
+	// holding the error, or to the next document marker, and carries on
+	// from there. Parse and ParseStream then return the partial tree along
+	// with the first error, and Errors returns all of them. Without it,
+	// misplaced content ends the collection holding it, as it always has.
+	Recover bool
+
+	// Limits guard against documents such as "billion laughs" that expand
+	// a few bytes of aliases into huge trees. Exceeding one stops parsing
+	// with a positioned *errors.YAMLError. Zero disables a limit.
+	MaxAliasExpansions int // aliases and merge sources expanded per document
+	MaxNodes           int // nodes per document, counting expanded copies
+	MaxDepth           int // nesting depth of nodes
+	MaxDocumentSize    int // bytes of source text per document
+}
+
+// DuplicateKeyPolicy decides how the parser treats a repeated mapping key
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysAllow keeps every pair; when decoding, later pairs
+	// shadow earlier ones
+	DuplicateKeysAllow DuplicateKeyPolicy = iota
+	// DuplicateKeysError reports a repeated key as a parser error
+	DuplicateKeysError
+)
+
+// DefaultOptions returns options that expand aliases and merge keys while
+// parsing, with limits that ordinary documents stay well below. The size
+// of a document is not limited.
+func DefaultOptions() *Options {
+	return &Options{
+		PreserveAliases:    false,
+		DisableMergeKeys:   false,
+		DuplicateKeys:      DuplicateKeysAllow,
+		DiscardComments:    false,
+		ResolveTags:        false,
+		KeepSource:         false,
+		Recover:            false,
+		TagResolver:        nil,
+		MaxAliasExpansions: 10000,
+		MaxNodes:           1000000,
+		MaxDepth:           10000,
+		MaxDocumentSize:    0,
+	}
+}
+
+// StrictOptions returns DefaultOptions with duplicate mapping keys
+// reported as errors
+func StrictOptions() *Options {
+	opts := DefaultOptions()
+	opts.DuplicateKeys = DuplicateKeysError
+	return opts
+}
+
+// NewParserWithOptions creates a parser configured by opts. A nil opts uses
+// DefaultOptions.
+func NewParserWithOptions(l *lexer.Lexer, opts *Options) *Parser {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	p := NewParser(l)
+	p.options = opts
+	p.budget.opts = opts
+	if opts.TagResolver != nil {
+		p.tagResolver = opts.TagResolver
+	}
+	return p
+}
+
+// ParseStringWithOptions parses a YAML string with the given options
+func ParseStringWithOptions(input string, opts *Options) (node.Node, error) {
+	l := lexer.NewLexerFromString(input)
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+
+	return NewParserWithOptions(l, opts).Parse()
+}
+
+// ParseStringRecover parses a YAML string in recovering mode and returns
+// the partial tree with every error found. The errors are nil when the
+// input is valid.
+func ParseStringRecover(input string, opts *Options) (node.Node, []*errors.YAMLError) {
+	p, err := newRecoveringParser(input, opts)
+	if err != nil {
+		return nil, []*errors.YAMLError{asYAMLError(err)}
+	}
+	root, _ := p.Parse()
+	return root, p.recoveredErrors()
+}
+
+// ParseStreamRecover parses a multi-document YAML string in recovering
+// mode and returns the documents it could read with every error found
+func ParseStreamRecover(input string, opts *Options) (*Stream, []*errors.YAMLError) {
+	p, err := newRecoveringParser(input, opts)
+	if err != nil {
+		return nil, []*errors.YAMLError{asYAMLError(err)}
+	}
+	stream, _ := p.ParseStream()
+	return stream, p.recoveredErrors()
+}
+
+func newRecoveringParser(input string, opts *Options) (*Parser, error) {
+	l := lexer.NewLexerFromString(input)
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	recovering := *opts
+	recovering.Recover = true
+	return NewParserWithOptions(l, &recovering), nil
+}
+
+func (p *Parser) recoveredErrors() []*errors.YAMLError {
+	if len(p.errors) == 0 {
+		return nil
+	}
+	return p.errors
+}
+
+// ParseStreamWithOptions parses a multi-document YAML string with the given
+// options
+func ParseStreamWithOptions(input string, opts *Options) (*Stream, error) {
+	l := lexer.NewLexerFromString(input)
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+
+	return NewParserWithOptions(l, opts).ParseStream()
+}
+
+// ParseAllDocumentsWithOptions parses every document of a YAML string with
+// the given options and returns their root nodes
+func ParseAllDocumentsWithOptions(input string, opts *Options) ([]node.Node, error) {
+	stream, err := ParseStreamWithOptions(input, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]node.Node, 0, len(stream.Documents))
+	for _, doc := range stream.Documents {
+		if doc.Root != nil {
+			nodes = append(nodes, doc.Root)
+		}
+	}
+
+	return nodes, nil
+}
+
+// preservesAliases reports whether aliases are kept as node.AliasNode
+func (o *Options) preservesAliases() bool {
+	return o.PreserveAliases || o.KeepSource
+}
+
+// expandsMergeKeys reports whether merge keys are resolved while parsing
+func (o *Options) expandsMergeKeys() bool {
+	return !o.preservesAliases() && !o.DisableMergeKeys
+}
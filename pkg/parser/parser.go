@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -21,34 +22,52 @@ type Parser struct {
 	commentQueue   []*lexer.Token
 	anchorRegistry *AnchorRegistry
 	tagResolver    *TagResolver
-	inMergeKey     bool
+	tagHandles     map[string]string // %TAG handles of the current document
+	options        *Options
+	lastEnd        node.Position // end of the last token consumed
+	budget         *budget
+	depth          int  // nesting depth of the node being parsed
+	docStart       int  // offset where the current document starts
+	halted         bool // parsing stopped; the rest of the input is ignored
+	blockIndent    int  // column of the innermost block collection
+	yield          func(*Event) bool
+	events         int       // events emitted so far
+	props          nodeProps // properties of the next node
+	stopped        bool      // the consumer wants no more events
 }
 
 // NewParser creates a new parser instance
 func NewParser(l *lexer.Lexer) *Parser {
-	return &Parser{
+	p := &Parser{
 		lexer:          l,
 		indentStack:    []int{0},
 		nodeBuilder:    &node.DefaultBuilder{},
 		errors:         make([]*errors.YAMLError, 0),
 		anchorRegistry: NewAnchorRegistry(),
 		tagResolver:    NewTagResolver(),
+		options:        DefaultOptions(),
 	}
+	p.budget = newBudget(p.options)
+	p.anchorRegistry.budget = p.budget
+	return p
 }
 
-// Parse parses the input and returns the root node
+// Parse parses the first document of the input and returns its root node.
+// The rest of the input is not read.
 func (p *Parser) Parse() (node.Node, error) {
-	// Initialize by reading the first two tokens
-	if err := p.advance(); err != nil {
-		return nil, err
-	}
-	if err := p.advance(); err != nil {
-		return nil, err
-	}
+	b := newTreeBuilder(p)
+	b.firstOnly = true
+	p.run(b.handle)
 
-	root := p.parseDocumentNode()
+	var root node.Node
+	if len(b.docs) > 0 {
+		root = b.docs[0].Root
+	}
 
 	if len(p.errors) > 0 {
+		if p.options.Recover {
+			return root, p.errors[0]
+		}
 		return nil, p.errors[0]
 	}
 
@@ -57,6 +76,9 @@ func (p *Parser) Parse() (node.Node, error) {
 
 // advance moves to the next token
 func (p *Parser) advance() error {
+	if p.halted {
+		return nil
+	}
 	if p.peek == nil && p.current != nil {
 		// Only set current to nil if we're at EOF
 		if p.current.Type == lexer.TokenEOF {
@@ -64,11 +86,27 @@ func (p *Parser) advance() error {
 			return nil
 		}
 	}
+	if p.current != nil && p.current.Type != lexer.TokenEOF {
+		p.lastEnd = tokenEnd(p.current)
+	}
 	p.current = p.peek
+	p.checkDocumentSize()
 
 	for {
 		token, err := p.lexer.NextToken()
 		if err != nil {
+			_, syntax := err.(*errors.YAMLError)
+			if syntax && !p.options.Recover {
+				// Only a recovering parser reports lexer syntax errors
+				return err
+			}
+			// The lexer has moved past the offending input, so a
+			// recovering parser can carry on with the next token
+			p.errors = append(p.errors, asYAMLError(err))
+			if p.options.Recover {
+				continue
+			}
+			p.halt(p.errors[len(p.errors)-1].Position)
 			return err
 		}
 
@@ -80,7 +118,9 @@ func (p *Parser) advance() error {
 
 		// Queue comments for later association
 		if token.Type == lexer.TokenComment {
-			p.commentQueue = append(p.commentQueue, token)
+			if !p.options.DiscardComments {
+				p.commentQueue = append(p.commentQueue, token)
+			}
 			continue // Skip comments for now
 		}
 
@@ -91,139 +131,119 @@ func (p *Parser) advance() error {
 	return nil
 }
 
-// parseDocumentNode parses a YAML document node
-func (p *Parser) parseDocumentNode() node.Node {
-	// Handle document markers
-	if p.current != nil && p.current.Type == lexer.TokenDocumentStart {
-		p.advance()
-	}
-
-	// Parse the document content
-	root := p.parseNode(0)
-
-	// Associate any leading comments with the root node
-	if root != nil && len(p.commentQueue) > 0 {
-		p.associateComments(root)
-	}
-
-	// Handle document end marker
-	if p.current != nil && p.current.Type == lexer.TokenDocumentEnd {
-		p.advance()
-	}
-
-	// Resolve merge keys after parsing
-	if root != nil {
-		ResolveMergeKeys(root, p.anchorRegistry)
-	}
-
-	return root
-}
-
-// parseNode parses any YAML node at the given indentation level
-func (p *Parser) parseNode(indent int) node.Node {
+// parseNode parses any YAML node at the given indentation level. It emits
+// exactly one node, an absent one when there is nothing to parse.
+func (p *Parser) parseNode(indent int) {
 	if p.current == nil || p.current.Type == lexer.TokenEOF {
-		return nil
+		p.emitAbsent()
+		return
 	}
 
-	// Don't process pending comments here - they should be handled elsewhere
+	p.depth++
+	defer func() { p.depth-- }()
+	if max := p.options.MaxDepth; max > 0 && p.depth > max {
+		p.limitError(fmt.Sprintf("document exceeds the maximum nesting depth of %d", max), p.current)
+		p.emitAbsent()
+		return
+	}
 
 	// Check for anchor definition
 	var anchor string
-	anchorLine := 0
+	propsLine := 0
 	if p.current.Type == lexer.TokenAnchor {
 		anchor = p.current.Value
-		anchorLine = p.current.Line
+		propsLine = p.current.Line
 		p.advance() // skip anchor token
 	}
 
 	// Check for tag
 	var tag string
-	if p.current.Type == lexer.TokenTag {
-		tag = p.current.Value
+	var tagTok *lexer.Token
+	if p.current != nil && p.current.Type == lexer.TokenTag {
+		tag, tagTok = p.current.Value, p.current
+		propsLine = p.current.Line
 		p.advance() // skip tag token
 	}
 
+	// The anchor may also follow the tag
+	if anchor == "" && p.current != nil && p.current.Type == lexer.TokenAnchor {
+		anchor = p.current.Value
+		propsLine = p.current.Line
+		p.advance() // skip anchor token
+	}
+
 	// After processing anchor/tag, if we're on a new line, use the current indentation
-	if p.current != nil && anchorLine > 0 && p.current.Line > anchorLine {
+	if p.current != nil && propsLine > 0 && p.current.Line > propsLine {
 		indent = p.current.Column
 	}
 
+	// An anchor or tag may end the input
+	if p.current == nil {
+		p.emitAbsent()
+		return
+	}
+
 	// Check for alias reference
 	if p.current.Type == lexer.TokenAlias {
-		alias := p.current.Value
+		ev := p.nodeEvent(EventAlias, p.current)
+		ev.Value = p.current.Value
 		p.advance() // skip alias token
+		p.emit(ev)
+		return
+	}
 
-		// For merge keys, keep the alias unresolved
-		// It will be resolved later by ResolveMergeKeys
-		if p.inMergeKey {
-			scalarNode := &node.ScalarNode{
-				Value: "",
-				Style: node.StylePlain,
-				Alias: alias,
-			}
-			return scalarNode
+	if tag != "" && p.options.ResolveTags {
+		if resolved := resolveTag(tag, p.tagHandles, p.tagResolver.tagShorthands); resolved != tag {
+			p.props.tagShorthand = tag
+			tag = resolved
+		} else if handle, _ := node.SplitTag(tag); isNamedHandle(handle) {
+			// A named handle is only defined by a %TAG directive of its
+			// own document or by the resolver
+			p.addErrorAtToken(fmt.Sprintf("undefined tag handle %q", handle), tagTok)
 		}
-
-		// Resolve the alias
-		aliasNode, err := p.anchorRegistry.ResolveAlias(alias)
-		if err != nil {
-			p.addError(err.Error())
-			return nil
-		}
-		return aliasNode
 	}
+	p.props.anchor, p.props.tag = anchor, tag
 
-	var n node.Node
-
+	emitted := p.events
 	switch p.current.Type {
 	case lexer.TokenFlowSequenceStart:
-		n = p.parseFlowSequence()
+		p.parseFlowSequence()
 	case lexer.TokenFlowMappingStart:
-		n = p.parseFlowMapping()
+		p.parseFlowMapping()
 	case lexer.TokenSequenceEntry:
-		n = p.parseBlockSequence(indent)
+		p.parseBlockSequence(indent)
 	case lexer.TokenMappingKey:
-		n = p.parseBlockMapping(indent)
+		p.parseBlockMapping(indent)
 	case lexer.TokenPlainScalar, lexer.TokenSingleQuotedScalar,
 		lexer.TokenDoubleQuotedScalar, lexer.TokenLiteralScalar,
 		lexer.TokenFoldedScalar:
 		// Check if this scalar is a key in a mapping (colon on same line)
 		if p.peek != nil && p.peek.Type == lexer.TokenMappingValue && p.current.Line == p.peek.Line {
-			n = p.parseBlockMapping(indent)
+			p.parseBlockMapping(indent)
 		} else {
-			n = p.parseScalar()
+			p.parseScalar()
 		}
 	default:
 		// Try to parse as block mapping (key: value pattern)
 		if p.isBlockMappingStart() {
-			n = p.parseBlockMapping(indent)
+			p.parseBlockMapping(indent)
 		}
 	}
 
-	// Set anchor and tag if present
-	if n != nil {
-		if anchor != "" {
-			n.SetAnchor(anchor)
-			// Register the anchor
-			if err := p.anchorRegistry.RegisterAnchor(anchor, n); err != nil {
-				p.addError(err.Error())
-			}
-		}
-		if tag != "" {
-			n.SetTag(tag)
-		}
+	if p.events == emitted {
+		p.emitAbsent()
 	}
-
-	return n
 }
 
 // parseScalar parses a scalar value
-func (p *Parser) parseScalar() node.Node {
+func (p *Parser) parseScalar() {
 	if p.current == nil {
-		return nil
+		p.emitAbsent()
+		return
 	}
 
 	var style node.Style
+	tok := p.current
 	value := p.current.Value
 
 	switch p.current.Style {
@@ -245,21 +265,34 @@ func (p *Parser) parseScalar() node.Node {
 		style = node.StylePlain
 	}
 
-	n := p.nodeBuilder.BuildScalar(value, style)
+	ev := p.nodeEvent(EventScalar, tok)
+	ev.Value, ev.Style = value, style
+	p.countNode(tok)
+	if tok.Unterminated && p.options.Recover {
+		p.addErrorAtToken("unterminated quoted scalar", tok)
+	}
 
 	// Associate comments
-	p.associateComments(n)
+	p.takeComments(ev, tok.Line)
 
 	p.advance()
-	return n
+	p.emit(ev)
 }
 
 // parseBlockSequence parses a block-style sequence
-func (p *Parser) parseBlockSequence(indent int) node.Node {
-	items := make([]node.Node, 0)
+func (p *Parser) parseBlockSequence(indent int) {
+	start := p.current
+	p.startCollection(EventSequenceStart, start, node.StyleBlock)
+	defer p.enterBlock(start.Column)()
 
-	for p.current != nil && p.current.Type == lexer.TokenSequenceEntry {
-		if p.current.Type == lexer.TokenEOF {
+	for p.current != nil {
+		if p.current.Type != lexer.TokenSequenceEntry {
+			// A key at the column of the entries ends a sequence nested
+			// in a mapping; anything else there or deeper is misplaced
+			atKey := p.current.Column == start.Column && (p.isBlockMappingStart() || p.current.Type == lexer.TokenMappingKey)
+			if !atKey && p.unexpected(start.Column) && p.syntaxError(p.current, start.Column) {
+				continue
+			}
 			break
 		}
 
@@ -267,38 +300,40 @@ func (p *Parser) parseBlockSequence(indent int) node.Node {
 		if p.current.Column < indent {
 			break
 		}
+		if p.current != start && p.misindented(start.Column) {
+			if p.indentError(p.current, start.Column) {
+				continue
+			}
+			break
+		}
 
 		currentIndent := p.current.Column
 		p.advance() // skip '-'
 
 		// Parse the sequence item
-		var item node.Node
 		if p.current != nil && p.current.Type != lexer.TokenEOF {
 			// Check if this is an empty item (next token is another sequence entry at same or less indentation)
 			if p.current.Type == lexer.TokenSequenceEntry && p.current.Column <= currentIndent {
 				// It's the next item at the same or parent level, so this is an empty item
-				item = p.nodeBuilder.BuildScalar("", node.StylePlain)
+				p.emitEmpty()
 			} else {
 				// Parse the actual content (could be nested or scalar)
-				item = p.parseNode(p.current.Column)
+				p.parseNode(p.current.Column)
 			}
 		} else {
 			// Empty item at EOF
-			item = p.nodeBuilder.BuildScalar("", node.StylePlain)
-		}
-		if item != nil {
-			items = append(items, item)
+			p.emitEmpty()
 		}
 	}
 
-	seq := p.nodeBuilder.BuildSequence(items, node.StyleBlock)
-	p.associateComments(seq)
-	return seq
+	p.endCollection(EventSequenceEnd, start)
 }
 
 // parseBlockMapping parses a block-style mapping
-func (p *Parser) parseBlockMapping(indent int) node.Node {
-	pairs := make([]*node.MappingPair, 0)
+func (p *Parser) parseBlockMapping(indent int) {
+	start := p.current
+	p.startCollection(EventMappingStart, start, node.StyleBlock)
+	defer p.enterBlock(start.Column)()
 
 	for p.current != nil {
 		if p.current.Type == lexer.TokenEOF {
@@ -309,154 +344,77 @@ func (p *Parser) parseBlockMapping(indent int) node.Node {
 		if p.current.Column < indent && indent > 0 {
 			break
 		}
+		isKey := p.current.Type == lexer.TokenMappingKey || p.isBlockMappingStart()
+		if isKey && p.current != start && p.misindented(start.Column) {
+			if p.indentError(p.current, start.Column) {
+				continue
+			}
+			break
+		}
 
-		// Parse explicit key
 		if p.current.Type == lexer.TokenMappingKey {
+			// Parse explicit key
 			p.advance() // skip '?'
-			var key node.Node
 			if p.current != nil {
 				// Parse the key directly as a scalar to avoid recursive mapping detection
-				key = p.parseScalar()
+				p.parseScalar()
 			} else {
-				key = p.nodeBuilder.BuildScalar("", node.StylePlain)
-			}
-
-			// Expect ':' for value
-			if p.current != nil && p.current.Type == lexer.TokenMappingValue {
-				p.advance() // skip ':'
-
-				// Check for inline comment after colon but before value
-				var inlineComment *lexer.Token
-				if len(p.commentQueue) > 0 {
-					for i, comment := range p.commentQueue {
-						if comment.IsInline {
-							inlineComment = comment
-							// Remove from queue
-							p.commentQueue = append(p.commentQueue[:i], p.commentQueue[i+1:]...)
-							break
-						}
-					}
-				}
-
-				// Check if this is a merge key
-				isMergeKey := false
-				if scalarKey, ok := key.(*node.ScalarNode); ok && scalarKey.Value == "<<" {
-					isMergeKey = true
-					p.inMergeKey = true
-				}
-
-				var value node.Node
-				if p.current != nil {
-					value = p.parseNode(p.current.Column)
-				} else {
-					value = p.nodeBuilder.BuildScalar("", node.StylePlain)
-				}
-
-				// If value is a mapping and we have an inline comment, associate it
-				if inlineComment != nil {
-					if mapping, ok := value.(*node.MappingNode); ok {
-						mapping.LineComment = &node.CommentGroup{
-							Comments: []string{inlineComment.Value},
-						}
-					}
-				}
-
-				// Reset merge key flag
-				if isMergeKey {
-					p.inMergeKey = false
-				}
-
-				pairs = append(pairs, &node.MappingPair{Key: key, Value: value})
+				p.emitEmpty()
 			}
 		} else if p.isBlockMappingStart() {
 			// Parse implicit key (plain scalar followed by ':')
-			key := p.parseScalar()
-
-			if p.current != nil && p.current.Type == lexer.TokenMappingValue {
-				p.advance() // skip ':'
-
-				// Check for inline comment after colon but before value
-				var inlineComment *lexer.Token
-				if len(p.commentQueue) > 0 {
-					// Check if there's an inline comment on the same line as the colon
-					for i, comment := range p.commentQueue {
-						if comment.IsInline {
-							inlineComment = comment
-							// Remove from queue
-							p.commentQueue = append(p.commentQueue[:i], p.commentQueue[i+1:]...)
-							break
-						}
-					}
-				}
-
-				// Check if this is a merge key
-				isMergeKey := false
-				if scalarKey, ok := key.(*node.ScalarNode); ok && scalarKey.Value == "<<" {
-					isMergeKey = true
-					p.inMergeKey = true
-				}
-
-				var value node.Node
-				if p.current != nil {
-					value = p.parseNode(p.current.Column)
-				} else {
-					value = p.nodeBuilder.BuildScalar("", node.StylePlain)
-				}
-
-				// If we have an inline comment, associate it with the value
-				if inlineComment != nil {
-					if mapping, ok := value.(*node.MappingNode); ok {
-						// Store the inline comment in the mapping's LineComment
-						mapping.LineComment = &node.CommentGroup{
-							Comments: []string{inlineComment.Value},
-						}
-					} else if seq, ok := value.(*node.SequenceNode); ok {
-						// Store the inline comment in the sequence's LineComment
-						seq.LineComment = &node.CommentGroup{
-							Comments: []string{inlineComment.Value},
-						}
-					} else if scalar, ok := value.(*node.ScalarNode); ok {
-						// Store the inline comment in the scalar's LineComment
-						scalar.LineComment = &node.CommentGroup{
-							Comments: []string{inlineComment.Value},
-						}
-					}
-				}
+			p.parseScalar()
+		} else {
+			if p.unexpected(start.Column) && p.syntaxError(p.current, start.Column) {
+				continue
+			}
+			break
+		}
 
-				// Reset merge key flag
-				if isMergeKey {
-					p.inMergeKey = false
-				}
+		// Expect ':' for value
+		if p.current == nil || p.current.Type != lexer.TokenMappingValue {
+			p.emitAbsent()
+			continue
+		}
+		p.advance() // skip ':'
 
-				pairs = append(pairs, &node.MappingPair{Key: key, Value: value})
+		// An inline comment after the colon belongs to the value
+		for i, comment := range p.commentQueue {
+			if comment.IsInline {
+				p.props.lineComment = commentGroup(comment)
+				p.commentQueue = append(p.commentQueue[:i], p.commentQueue[i+1:]...)
+				break
 			}
+		}
+
+		if p.current != nil {
+			p.parseNode(p.current.Column)
 		} else {
-			break
+			p.emitEmpty()
 		}
 	}
 
-	mapping := p.nodeBuilder.BuildMapping(pairs, node.StyleBlock)
-	p.associateComments(mapping)
-	return mapping
+	p.endCollection(EventMappingEnd, start)
 }
 
 // parseFlowSequence parses a flow-style sequence [a, b, c]
-func (p *Parser) parseFlowSequence() node.Node {
+func (p *Parser) parseFlowSequence() {
+	start := p.current
+	p.startCollection(EventSequenceStart, start, node.StyleFlow)
 	p.advance() // skip '['
 	p.inFlow++
 
-	items := make([]node.Node, 0)
-
 	for p.current != nil && p.current.Type != lexer.TokenFlowSequenceEnd {
-		if p.current.Type == lexer.TokenEOF {
+		if p.current.Type == lexer.TokenEOF || p.flowCut(start) {
 			// Be lenient with unclosed flow sequences
 			break
 		}
 
-		// Parse item
-		item := p.parseNode(0)
-		if item != nil {
-			items = append(items, item)
+		// Parse item, skipping a token that cannot start one
+		tok := p.current
+		p.parseNode(0)
+		if p.current == tok {
+			p.advance()
 		}
 
 		// Handle comma
@@ -473,23 +431,23 @@ func (p *Parser) parseFlowSequence() node.Node {
 
 	if p.current != nil && p.current.Type == lexer.TokenFlowSequenceEnd {
 		p.advance() // skip ']'
+	} else {
+		p.unclosed("flow sequence", start)
 	}
 
 	p.inFlow--
-	seq := p.nodeBuilder.BuildSequence(items, node.StyleFlow)
-	p.associateComments(seq)
-	return seq
+	p.endCollection(EventSequenceEnd, start)
 }
 
 // parseFlowMapping parses a flow-style mapping {a: 1, b: 2}
-func (p *Parser) parseFlowMapping() node.Node {
+func (p *Parser) parseFlowMapping() {
+	start := p.current
+	p.startCollection(EventMappingStart, start, node.StyleFlow)
 	p.advance() // skip '{'
 	p.inFlow++
 
-	pairs := make([]*node.MappingPair, 0)
-
 	for p.current != nil && p.current.Type != lexer.TokenFlowMappingEnd {
-		if p.current.Type == lexer.TokenEOF {
+		if p.current.Type == lexer.TokenEOF || p.flowCut(start) {
 			// Be lenient with unclosed flow mappings
 			break
 		}
@@ -506,10 +464,9 @@ func (p *Parser) parseFlowMapping() node.Node {
 		}
 
 		// Parse key - could be any scalar
-		var key node.Node
 		switch p.current.Type {
 		case lexer.TokenPlainScalar, lexer.TokenSingleQuotedScalar, lexer.TokenDoubleQuotedScalar:
-			key = p.parseScalar()
+			p.parseScalar()
 		default:
 			// Unexpected token - advance and check for EOF
 			p.advance()
@@ -524,16 +481,22 @@ func (p *Parser) parseFlowMapping() node.Node {
 		if p.current != nil && p.current.Type == lexer.TokenMappingValue {
 			p.advance() // skip ':'
 
-			// Parse value - could be any scalar
-			var value node.Node
-			switch p.current.Type {
+			// Parse value - a scalar, an alias or a collection, with its
+			// properties
+			var next lexer.TokenType = lexer.TokenEOF
+			if p.current != nil {
+				next = p.current.Type
+			}
+			switch next {
 			case lexer.TokenPlainScalar, lexer.TokenSingleQuotedScalar, lexer.TokenDoubleQuotedScalar:
-				value = p.parseScalar()
+				p.parseScalar()
+			case lexer.TokenTag, lexer.TokenAnchor, lexer.TokenAlias, lexer.TokenFlowSequenceStart, lexer.TokenFlowMappingStart:
+				p.parseNode(0)
 			default:
-				value = p.nodeBuilder.BuildScalar("", node.StylePlain)
+				p.emitEmpty()
 			}
-
-			pairs = append(pairs, &node.MappingPair{Key: key, Value: value})
+		} else {
+			p.emitAbsent()
 		}
 
 		// Handle comma - already handled in loop condition
@@ -550,16 +513,76 @@ func (p *Parser) parseFlowMapping() node.Node {
 
 	if p.current != nil && p.current.Type == lexer.TokenFlowMappingEnd {
 		p.advance() // skip '}'
+	} else {
+		p.unclosed("flow mapping", start)
 	}
 
 	p.inFlow--
-	mapping := p.nodeBuilder.BuildMapping(pairs, node.StyleFlow)
-	p.associateComments(mapping)
-	return mapping
+	p.endCollection(EventMappingEnd, start)
 }
 
 // Helper methods
 
+// startCollection emits the Start event of a collection at tok
+func (p *Parser) startCollection(typ EventType, tok *lexer.Token, style node.Style) {
+	ev := p.nodeEvent(typ, tok)
+	ev.Style = style
+	p.emit(ev)
+}
+
+// endCollection emits the End event of a collection that starts at tok
+// and ends with the last token consumed
+func (p *Parser) endCollection(typ EventType, tok *lexer.Token) {
+	ev := &Event{Type: typ, Range: tokenRange(tok)}
+	if p.lastEnd.Offset > ev.Range.End.Offset {
+		ev.Range.End = p.lastEnd
+	}
+	p.countNode(tok)
+	p.takeComments(ev, tok.Line)
+	p.emit(ev)
+}
+
+// emitEmpty emits the null scalar of an omitted value. Its range is empty
+// and placed after the last token consumed.
+func (p *Parser) emitEmpty() {
+	ev := p.nodeEvent(EventScalar, nil)
+	ev.Style = node.StylePlain
+	p.countNode(p.current)
+	if p.lastEnd.IsValid() {
+		ev.Range = node.Range{Start: p.lastEnd, End: p.lastEnd}
+	}
+	p.emit(ev)
+}
+
+// emitAbsent emits the stand-in for a node that is missing altogether
+func (p *Parser) emitAbsent() {
+	ev := p.nodeEvent(EventScalar, nil)
+	ev.Style = node.StylePlain
+	ev.Range = node.Range{Start: p.lastEnd, End: p.lastEnd}
+	ev.absent = true
+	p.emit(ev)
+}
+
+func tokenStart(tok *lexer.Token) node.Position {
+	return node.Position{Line: tok.Line, Column: tok.Column, Offset: tok.Offset}
+}
+
+func tokenEnd(tok *lexer.Token) node.Position {
+	return node.Position{Line: tok.EndLine, Column: tok.EndCol, Offset: tok.EndOffset}
+}
+
+func tokenRange(tok *lexer.Token) node.Range {
+	return node.Range{Start: tokenStart(tok), End: tokenEnd(tok)}
+}
+
+// commentGroup builds the group of a single comment token
+func commentGroup(tok *lexer.Token) *node.CommentGroup {
+	return &node.CommentGroup{
+		Comments: []string{tok.Value},
+		Range:    tokenRange(tok),
+	}
+}
+
 func (p *Parser) isBlockMappingStart() bool {
 	// Check if current token is a scalar followed by ':' on the same line
 	if p.current == nil || p.peek == nil {
@@ -584,13 +607,35 @@ func (p *Parser) processPendingComments() {
 	p.commentQueue = nil
 }
 
+// associateComments attaches queued comments to n. The parser reads one
+// token ahead, so the queue may already hold comments that follow n; those
+// stay queued for the next node.
 func (p *Parser) associateComments(n node.Node) {
-	// Associate pending comments with the node
+	if len(p.commentQueue) == 0 || n == nil {
+		return
+	}
+
+	var current, pending []*lexer.Token
+	for _, comment := range p.commentQueue {
+		if !comment.IsInline && n.Line() > 0 && comment.Line > n.Line() {
+			pending = append(pending, comment)
+		} else {
+			current = append(current, comment)
+		}
+	}
+	p.commentQueue = current
+	p.attachComments(n)
+	p.commentQueue = pending
+}
+
+// attachComments attaches every queued comment to n
+func (p *Parser) attachComments(n node.Node) {
 	if len(p.commentQueue) > 0 && n != nil {
 		// Associate all non-inline comments as head comments
 		for _, comment := range p.commentQueue {
 			if !comment.IsInline {
-				node.AssociateComment(n, comment.Value, node.CommentPositionAbove, comment.BlankLinesBefore)
+				cg := node.AssociateComment(n, comment.Value, node.CommentPositionAbove, comment.BlankLinesBefore)
+				extendCommentRange(cg, comment)
 			}
 		}
 
@@ -598,7 +643,8 @@ func (p *Parser) associateComments(n node.Node) {
 		if p.current != nil {
 			for _, comment := range p.commentQueue {
 				if comment.IsInline && comment.Line == p.current.Line {
-					node.AssociateComment(n, comment.Value, node.CommentPositionInline, 0)
+					cg := node.AssociateComment(n, comment.Value, node.CommentPositionInline, 0)
+					extendCommentRange(cg, comment)
 				}
 			}
 		}
@@ -607,6 +653,13 @@ func (p *Parser) associateComments(n node.Node) {
 	}
 }
 
+// extendCommentRange adds the span of a comment token to its group
+func extendCommentRange(cg *node.CommentGroup, tok *lexer.Token) {
+	if cg != nil {
+		cg.Range = cg.Range.Union(tokenRange(tok))
+	}
+}
+
 // ParseString is a convenience method to parse a YAML string
 func ParseString(input string) (node.Node, error) {
 	l := lexer.NewLexerFromString(input)
@@ -647,22 +700,236 @@ func (p *Parser) Errors() []*errors.YAMLError {
 	return p.errors
 }
 
-func (p *Parser) addError(msg string) {
-	pos := errors.Position{
-		Line:   1,
-		Column: 1,
-		Offset: 0,
+// limitError reports an exceeded limit at tok and stops parsing
+func (p *Parser) limitError(msg string, tok *lexer.Token) {
+	p.limitErrorAt(msg, tokenPosition(tok))
+}
+
+// limitErrorAt reports an exceeded limit at pos and stops parsing
+func (p *Parser) limitErrorAt(msg string, pos errors.Position) {
+	if p.halted {
+		return
+	}
+	p.addErrorAtPosition(msg, pos)
+	p.halt(pos)
+}
+
+// halt stops parsing at pos by making the rest of the input look empty, so
+// every loop winds down
+func (p *Parser) halt(pos errors.Position) {
+	eof := &lexer.Token{Type: lexer.TokenEOF, Line: pos.Line, Column: pos.Column, Offset: pos.Offset}
+	p.current, p.peek = eof, eof
+	p.halted = true
+}
+
+// enterBlock makes col the column of the innermost block collection and
+// returns a func restoring the previous one
+func (p *Parser) enterBlock(col int) func() {
+	saved := p.blockIndent
+	p.blockIndent = col
+	return func() { p.blockIndent = saved }
+}
+
+// unexpected reports whether a recovering parser should report the current
+// token as content that a block collection at column col cannot hold,
+// rather than the end of it. Tokens left of col belong to an enclosing
+// collection. Without Options.Recover the collection just ends there.
+func (p *Parser) unexpected(col int) bool {
+	if !p.options.Recover || p.current == nil || p.inFlow > 0 || p.halted || p.current.Column < col {
+		return false
+	}
+	switch p.current.Type {
+	case lexer.TokenEOF, lexer.TokenDocumentStart, lexer.TokenDocumentEnd, lexer.TokenDirective:
+		return false
+	}
+	return true
+}
+
+// misindented reports whether a recovering parser finds the current token
+// starting a line deeper than the entries of the block collection at col,
+// where it would otherwise be read as one more entry. A line already
+// reported, such as one indented with tabs, is left alone.
+func (p *Parser) misindented(col int) bool {
+	if !p.unexpected(col+1) || p.current.Line <= p.lastEnd.Line {
+		return false
+	}
+	return len(p.errors) == 0 || p.errors[len(p.errors)-1].Position.Line != p.current.Line
+}
+
+// syntaxError reports the unexpected token tok, then skips ahead to the
+// first token on a later line indented at most col, or to the next
+// document marker, and reports whether the collection at col can carry on
+// from there.
+func (p *Parser) syntaxError(tok *lexer.Token, col int) bool {
+	p.addErrorAtToken(fmt.Sprintf("unexpected %q", tokenText(tok)), tok)
+	return p.skipLines(tok, col)
+}
+
+// indentError reports tok as mis-indented and skips ahead like syntaxError
+func (p *Parser) indentError(tok *lexer.Token, col int) bool {
+	p.addErrorAtToken(fmt.Sprintf("bad indentation of %q", tokenText(tok)), tok)
+	return p.skipLines(tok, col)
+}
+
+// skipLines skips ahead from tok to the first token on a later line
+// indented at most col, or to the next document marker, and reports
+// whether the collection at col can carry on from there
+func (p *Parser) skipLines(tok *lexer.Token, col int) bool {
+	for p.current != nil && !p.halted {
+		switch p.current.Type {
+		case lexer.TokenEOF, lexer.TokenDocumentStart, lexer.TokenDocumentEnd, lexer.TokenDirective:
+			return false
+		}
+		if p.current.Line > tok.EndLine && p.current.Column <= col {
+			return true
+		}
+		p.advance()
+	}
+	return false
+}
+
+// tokenText returns tok as written, or its type when it has no text
+func tokenText(tok *lexer.Token) string {
+	if tok.Value == "" {
+		return tok.Type.String()
+	}
+	return tok.Value
+}
+
+// flowCut reports whether a recovering parser should end the flow
+// collection opened by start before the current token, leaving it to the
+// block collection around: at a document marker, or on a later line
+// indented no deeper than that block collection
+func (p *Parser) flowCut(start *lexer.Token) bool {
+	if !p.options.Recover || p.current == nil || p.current.Line <= start.Line {
+		return false
+	}
+	switch p.current.Type {
+	case lexer.TokenDocumentStart, lexer.TokenDocumentEnd, lexer.TokenDirective:
+		return true
+	}
+	return p.current.Column <= p.blockIndent
+}
+
+// unclosed reports, when recovering, a collection opened by start that
+// ended without its closing bracket
+func (p *Parser) unclosed(what string, start *lexer.Token) {
+	if p.options.Recover && !p.halted {
+		p.addErrorAtToken("unclosed "+what, start)
+	}
+}
+
+// checkDocumentEnd reports content after the root node of a document,
+// which a recovering parser skips up to the next document marker
+func (p *Parser) checkDocumentEnd() {
+	for p.unexpected(0) {
+		p.syntaxError(p.current, 0)
+	}
+}
+
+// countNode counts a parsed node against the node limit
+func (p *Parser) countNode(tok *lexer.Token) {
+	if err := p.budget.addNodes(1); err != nil {
+		p.limitError(err.Error(), tok)
+	}
+}
+
+// checkDocumentSize stops parsing once the current token ends beyond the
+// size limit of its document. Markers and directives are not counted, as
+// they may be read before the next document resets the start.
+func (p *Parser) checkDocumentSize() {
+	max := p.options.MaxDocumentSize
+	tok := p.current
+	if max <= 0 || tok == nil || tok.Type == lexer.TokenDocumentStart || tok.Type == lexer.TokenDirective {
+		return
 	}
+	if tok.EndOffset-p.docStart > max {
+		p.limitError(fmt.Sprintf("document exceeds the maximum size of %d bytes", max), tok)
+	}
+}
 
-	if p.current != nil {
-		pos.Line = p.current.Line
-		pos.Column = p.current.Column
-		pos.Offset = p.current.Offset
+// checkDuplicateKeys reports keys that repeat an earlier key of the same
+// mapping when the options ask for it. Keys are compared by resolved value,
+// so 1 and 0x1 are the same key. Keys brought in through << may be
+// overridden and are not checked, but a repeated << is a duplicate.
+func (p *Parser) checkDuplicateKeys(pairs []*node.MappingPair) {
+	if p.options.DuplicateKeys != DuplicateKeysError {
+		return
 	}
 
+	seen := make(map[string]node.Node)
+	for _, pair := range pairs {
+		if pair == nil || pair.Key == nil {
+			continue
+		}
+		id := node.KeyID(pair.Key)
+		first, ok := seen[id]
+		if !ok {
+			seen[id] = pair.Key
+			continue
+		}
+		firstPos := nodePosition(first)
+		msg := fmt.Sprintf("duplicate key %s (first defined at line %d, column %d)",
+			describeKey(pair.Key), firstPos.Line, firstPos.Column)
+		err := errors.New(msg, nodePosition(pair.Key), errors.ErrorTypeParser)
+		err.Related = []errors.Position{firstPos}
+		p.errors = append(p.errors, err)
+	}
+}
+
+// describeKey names a mapping key in an error message
+func describeKey(key node.Node) string {
+	switch k := key.(type) {
+	case *node.ScalarNode:
+		return fmt.Sprintf("%q", k.Value)
+	case *node.AliasNode:
+		return "*" + k.Name
+	case *node.SequenceNode:
+		return "sequence"
+	case *node.MappingNode:
+		return "mapping"
+	}
+	return "key"
+}
+
+// nodePosition returns the start of n as an error position
+func nodePosition(n node.Node) errors.Position {
+	start := node.RangeOf(n).Start
+	return errors.Position{Line: n.Line(), Column: n.Column(), Offset: start.Offset}
+}
+
+// addErrorAtToken records a parser error at the position of tok
+func (p *Parser) addErrorAtToken(msg string, tok *lexer.Token) {
+	p.addErrorAtPosition(msg, tokenPosition(tok))
+}
+
+// addErrorAtPosition records a parser error at pos
+func (p *Parser) addErrorAtPosition(msg string, pos errors.Position) {
 	p.errors = append(p.errors, errors.New(msg, pos, errors.ErrorTypeParser))
 }
 
+// tokenPosition returns the start of tok as an error position
+func tokenPosition(tok *lexer.Token) errors.Position {
+	if tok == nil {
+		return errors.Position{Line: 1, Column: 1}
+	}
+	return errors.Position{Line: tok.Line, Column: tok.Column, Offset: tok.Offset}
+}
+
+// addErrorAt records a parser error at the position of n
+func (p *Parser) addErrorAt(msg string, n node.Node) {
+	p.addErrorAtPosition(msg, nodePosition(n))
+}
+
+// asYAMLError returns err as a parser error, keeping its position if it
+// has one
+func asYAMLError(err error) *errors.YAMLError {
+	if yamlErr, ok := err.(*errors.YAMLError); ok {
+		return yamlErr
+	}
+	return errors.New(err.Error(), errors.Position{Line: 1, Column: 1}, errors.ErrorTypeParser)
+}
+
 // stripBlockScalarIndent strips the common indentation from block scalar content
 func (p *Parser) stripBlockScalarIndent(value string) string {
 	if value == "" {
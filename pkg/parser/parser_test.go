@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/elioetibr/golang-yaml/pkg/lexer"
 	"github.com/elioetibr/golang-yaml/pkg/node"
 )
 
@@ -205,6 +207,39 @@ key3: value3`,
 	}
 }
 
+func TestParseBlockScalarFollowedByKey(t *testing.T) {
+	input := `steps:
+  - run: |
+      go vet ./...
+
+      go test ./...
+    name: test
+after: done`
+
+	root, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	mapping := root.(*node.MappingNode)
+	if len(mapping.Pairs) != 2 {
+		t.Fatalf("Expected 2 top-level pairs, got %d", len(mapping.Pairs))
+	}
+
+	step := mapping.Pairs[0].Value.(*node.SequenceNode).Items[0].(*node.MappingNode)
+	if len(step.Pairs) != 2 {
+		t.Fatalf("Expected 2 step pairs, got %d", len(step.Pairs))
+	}
+
+	run := step.Pairs[0].Value.(*node.ScalarNode)
+	if run.Value != "go vet ./...\n\ngo test ./...\n" {
+		t.Errorf("Unexpected literal value %q", run.Value)
+	}
+	if name := step.Pairs[1].Value.(*node.ScalarNode).Value; name != "test" {
+		t.Errorf("Expected name 'test', got %q", name)
+	}
+}
+
 func TestParseFlowSequence(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -438,6 +473,18 @@ key2: value2`,
 				if len(mapping.Pairs) != 2 {
 					t.Errorf("Expected 2 pairs, got %d", len(mapping.Pairs))
 				}
+				// Comments belong to the key that follows them, not the previous value
+				key2 := mapping.Pairs[1].Key.(*node.ScalarNode)
+				if key2.HeadComment == nil || key2.HeadComment.Comments[0] != "# Above key2" {
+					t.Errorf("Expected head comment on key2, got %+v", key2.HeadComment)
+				}
+				value1 := mapping.Pairs[0].Value.(*node.ScalarNode)
+				if value1.HeadComment != nil {
+					t.Errorf("Expected no head comment on value1, got %+v", value1.HeadComment)
+				}
+				if value1.LineComment == nil || value1.LineComment.Comments[0] != "# inline comment" {
+					t.Errorf("Expected inline comment on value1, got %+v", value1.LineComment)
+				}
 			},
 		},
 		{
@@ -558,3 +605,115 @@ func TestParseErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestSourceRanges(t *testing.T) {
+	src := "# service\nname: \"web app\" # quoted\nports: [80, 443]\nscript: |\n  run\n  stop\nnested:\n  key: &k value\n  ref: *k\n"
+
+	root, err := ParseStringWithOptions(src, &Options{PreserveAliases: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	m := root.(*node.MappingNode)
+	text := func(n node.Node) string { return node.RangeOf(n).Text(src) }
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"root", text(m), strings.TrimSuffix(src[strings.Index(src, "name"):], "\n")},
+		{"key", text(m.Pairs[0].Key), "name"},
+		{"quoted", text(m.Pairs[0].Value), `"web app"`},
+		{"head comment", m.Pairs[0].Key.(*node.ScalarNode).HeadComment.Range.Text(src), "# service"},
+		{"line comment", m.Pairs[0].Value.(*node.ScalarNode).LineComment.Range.Text(src), "# quoted"},
+		{"flow", text(m.Pairs[1].Value), "[80, 443]"},
+		{"flow item", text(m.Pairs[1].Value.(*node.SequenceNode).Items[1]), "443"},
+		{"literal", text(m.Pairs[2].Value), "|\n  run\n  stop"},
+		{"nested", text(m.Pairs[3].Value), "key: &k value\n  ref: *k"},
+		{"anchored", text(m.Pairs[3].Value.(*node.MappingNode).Pairs[0].Value), "value"},
+		{"alias", text(m.Pairs[3].Value.(*node.MappingNode).Pairs[1].Value), "*k"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	literal := node.RangeOf(m.Pairs[2].Value)
+	if literal.Start != (node.Position{Line: 4, Column: 9, Offset: 60}) || literal.End.Line != 6 || literal.End.Column != 7 {
+		t.Errorf("Unexpected literal range %+v", literal)
+	}
+
+	seq, err := ParseString("- a\n-\n- c\n")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if empty := node.RangeOf(seq.(*node.SequenceNode).Items[1]); empty.Start != empty.End || empty.Start.Offset != 5 {
+		t.Errorf("Empty item should have an empty range after its dash, got %+v", empty)
+	}
+}
+
+func TestDocumentRange(t *testing.T) {
+	src := "a: 1\n---\nb: 2\n...\n"
+	stream, err := ParseStream(src)
+	if err != nil {
+		t.Fatalf("ParseStream error: %v", err)
+	}
+	if len(stream.Documents) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(stream.Documents))
+	}
+	if got := stream.Documents[0].Node().Range.Text(src); got != "a: 1" {
+		t.Errorf("First document range %q", got)
+	}
+	if got := stream.Documents[1].Node().Range.Text(src); got != "---\nb: 2\n..." {
+		t.Errorf("Second document range %q", got)
+	}
+}
+
+func TestKeepSource(t *testing.T) {
+	src := "\uFEFF# one\na: &x 1\nb: *x\n---   # two\nc:\n  <<: {d: 2}\n...\n# three\n--- 3\n"
+	stream, err := ParseStreamWithOptions(src, &Options{KeepSource: true})
+	if err != nil {
+		t.Fatalf("ParseStream error: %v", err)
+	}
+
+	// The outer ranges cover the stream after its byte order mark, with
+	// each comment in the document it is attached to
+	wantOuter := []string{"# one\na: &x 1\nb: *x\n", "---   # two\nc:\n  <<: {d: 2}\n...\n# three\n", "--- 3\n"}
+	for i, doc := range stream.Documents {
+		source := node.SourceOf(doc.Root)
+		if source == nil {
+			t.Fatalf("Document %d has no source", i)
+		}
+		if got := source.Outer.Text(source.Text); got != wantOuter[i] {
+			t.Errorf("Document %d outer text %q, want %q", i, got, wantOuter[i])
+		}
+	}
+	if end := node.SourceOf(stream.Documents[2].Root).Outer.End; end != (node.Position{Line: 10, Column: 1, Offset: len(src)}) {
+		t.Errorf("Unexpected end of the last document %+v", end)
+	}
+
+	// Aliases and merge keys stay as written
+	first := stream.Documents[0].Root.(*node.MappingNode)
+	if _, ok := first.Pairs[1].Value.(*node.AliasNode); !ok {
+		t.Errorf("Expected an alias node, got %T", first.Pairs[1].Value)
+	}
+	second := stream.Documents[1].Root.(*node.MappingNode).Pairs[0].Value.(*node.MappingNode)
+	if key := second.Pairs[0].Key.(*node.ScalarNode).Value; key != MergeKey {
+		t.Errorf("Expected the merge key to stay, got %q", key)
+	}
+
+	// Input read from a reader is not kept
+	p := NewParserWithOptions(lexer.NewLexer(strings.NewReader("a: 1")), &Options{KeepSource: true})
+	if err := p.lexer.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+	root, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if node.SourceOf(root) != nil {
+		t.Error("Expected no source for reader input")
+	}
+}
@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+func TestParseStringRecover(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		errors []string // "line:column message"
+		output string
+	}{
+		{
+			name:   "entry inside a mapping",
+			input:  "a: 1\n- x\nb: 2\n",
+			errors: []string{`2:1 unexpected "-"`},
+			output: "a: 1\nb: 2",
+		},
+		{
+			name:   "scalar without a value",
+			input:  "a: 1\nb\nc: 2\n",
+			errors: []string{`2:1 unexpected "b"`},
+			output: "a: 1\nc: 2",
+		},
+		{
+			name:   "scalar inside a sequence",
+			input:  "- x\nfoo\n- y\n",
+			errors: []string{`2:1 unexpected "foo"`},
+			output: "- x\n- y",
+		},
+		{
+			name:   "nested mapping resumes at its parent",
+			input:  "a:\n  b: 1\n  - x\n    - y\n  c: 2\nd: 3\n",
+			errors: []string{`3:3 unexpected "-"`},
+			output: "a:\n  b: 1\n  c: 2\nd: 3",
+		},
+		{
+			name:  "several errors",
+			input: "a: 1\n- x\nb: *missing\nc\nd: 4\n",
+			errors: []string{
+				`2:1 unexpected "-"`,
+				`3:4 undefined alias "missing"`,
+				`4:1 unexpected "c"`,
+			},
+			output: "a: 1\nb: \nd: 4",
+		},
+		{
+			name:   "tab indentation",
+			input:  "a: 1\n\tb: 2\nc: 3\n",
+			errors: []string{"2:1 tabs are not allowed for indentation (YAML 1.2.2 spec section 6.1)"},
+			output: "a: 1\nb: 2\nc: 3",
+		},
+		{
+			name:   "content after the root",
+			input:  "- x\nfoo: 1\nbar: 2\n",
+			errors: []string{`2:1 unexpected "foo"`},
+			output: "- x",
+		},
+		{
+			name:   "unclosed flow mapping",
+			input:  "a: {x: 1\nb: 2\n",
+			errors: []string{"1:4 unclosed flow mapping"},
+			output: "a: {x: 1}\nb: 2",
+		},
+		{
+			name:   "unclosed flow sequence",
+			input:  "a:\n  b: [1, 2\n  c: 2\nd: 3\n",
+			errors: []string{"2:6 unclosed flow sequence"},
+			output: "a:\n  b: [1, 2]\n  c: 2\nd: 3",
+		},
+		{
+			name:   "unclosed flow collections at the end",
+			input:  "a: [{x: 1",
+			errors: []string{"1:5 unclosed flow mapping", "1:4 unclosed flow sequence"},
+			output: "a: [{x: 1}]",
+		},
+		{
+			name:   "unterminated quote",
+			input:  "a: \"unterminated\nb: 2",
+			errors: []string{"1:4 unterminated quoted scalar"},
+			output: "a: \"unterminated\\nb: 2\"",
+		},
+		{
+			name:   "flow collections over several lines",
+			input:  "a: [1,\n  2]\nb: {x: 1,\n  y: 2}\n",
+			output: "a: [1, 2]\nb: {x: 1, y: 2}",
+		},
+		{
+			name:   "sequence as a mapping value",
+			input:  "a:\n- x\nb: 1\n",
+			output: "a:\n  - x\nb: 1",
+		},
+		{
+			name:   "key indented deeper than its mapping",
+			input:  "key: value\n  bad: indent\nc: 3\n",
+			errors: []string{`2:3 bad indentation of "bad"`},
+			output: "key: value\nc: 3",
+		},
+		{
+			name:   "key at the column of a nested sequence",
+			input:  "a:\n  - 1\n  b: 2\nc: 3\n",
+			errors: []string{`3:3 bad indentation of "b"`},
+			output: "a:\n  - 1\nc: 3",
+		},
+		{
+			name:   "key between two indentation levels",
+			input:  "a:\n    b: 1\n  c: 2\nd: 3\n",
+			errors: []string{`3:3 bad indentation of "c"`},
+			output: "a:\n  b: 1\nd: 3",
+		},
+		{
+			name:   "entry indented deeper than its sequence",
+			input:  "- a\n  - b\n- c\n",
+			errors: []string{`2:3 bad indentation of "-"`},
+			output: "- a\n- c",
+		},
+		{
+			name:   "tagged collections on the next line",
+			input:  "a: !!map\n  b: 1\nc: !!seq\n  - 2\n",
+			output: "a:\n  b: 1\nc:\n  - 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, errs := ParseStringRecover(tt.input, nil)
+			if got := formatErrors(errs); fmt.Sprint(got) != fmt.Sprint(tt.errors) {
+				t.Errorf("Expected errors %q, got %q", tt.errors, got)
+			}
+			output, err := serializer.SerializeToString(root, nil)
+			if err != nil {
+				t.Fatalf("Serialize error: %v", err)
+			}
+			if output != tt.output {
+				t.Errorf("Expected partial tree:\n%s\ngot:\n%s", tt.output, output)
+			}
+		})
+	}
+}
+
+// TestParseStringWithoutRecover checks that misplaced content ends a
+// collection without an error unless the parser recovers
+func TestParseStringWithoutRecover(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"a: 1\n- b\n", "a: 1"},
+		{"- a\nb: 1\n", "- a"},
+		{"a: 1\n}\n", "a: 1"},
+		{"a: 1\nb\nc: 2\n", "a: 1"},
+		{"a: 1\n\tb: 2\nc: 3\n", "a: 1"},
+		{"key: value\n  bad: indent\n", "key: value\nbad: indent"},
+		{"a:\n  - 1\n  b: 2\n", "a:\n  - 1\nb: 2"},
+	}
+
+	for _, tt := range tests {
+		root, err := ParseString(tt.input)
+		if err != nil {
+			t.Errorf("ParseString(%q) error: %v", tt.input, err)
+			continue
+		}
+		if output, _ := serializer.SerializeToString(root, nil); output != tt.output {
+			t.Errorf("ParseString(%q): expected %q, got %q", tt.input, tt.output, output)
+		}
+	}
+
+	// Errors other than syntax errors still stop parsing
+	if _, err := ParseString("a: 1\nb: *missing\nc: 3\n"); fmt.Sprint(formatErrors(asErrors(err))) != `[2:4 undefined alias "missing"]` {
+		t.Errorf("Expected the undefined alias error, got %v", err)
+	}
+}
+
+func TestParseStreamRecover(t *testing.T) {
+	input := "- x\nfoo: 1\n---\na: 1\n- z\nb: 2\n...\n---\nc: 3\n"
+	stream, errs := ParseStreamRecover(input, nil)
+	want := []string{`2:1 unexpected "foo"`, `5:1 unexpected "-"`}
+	if got := formatErrors(errs); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected errors %q, got %q", want, got)
+	}
+	if stream == nil || len(stream.Documents) != 3 {
+		t.Fatalf("Expected every document to be parsed, got %+v", stream)
+	}
+	for i, want := range []string{"- x", "a: 1\nb: 2", "c: 3"} {
+		if output, _ := serializer.SerializeToString(stream.Documents[i].Root, nil); output != want {
+			t.Errorf("Document %d: expected %q, got %q", i, want, output)
+		}
+	}
+
+	if _, errs := ParseStreamRecover("a: 1\n---\nb: 2\n", nil); errs != nil {
+		t.Errorf("Expected no errors for a valid stream, got %v", errs)
+	}
+}
+
+func formatErrors(errs []*errors.YAMLError) []string {
+	var out []string
+	for _, err := range errs {
+		out = append(out, fmt.Sprintf("%d:%d %s", err.Position.Line, err.Position.Column, err.Message))
+	}
+	return out
+}
+
+func asErrors(err error) []*errors.YAMLError {
+	if err == nil {
+		return nil
+	}
+	return []*errors.YAMLError{asYAMLError(err)}
+}
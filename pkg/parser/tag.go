@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
 )
 
 // TagResolver handles YAML tag resolution
@@ -70,44 +72,47 @@ func (tr *TagResolver) RegisterCustomHandler(tag string, handler TagHandler) {
 	tr.customHandlers[tag] = handler
 }
 
-// ResolveTag resolves a tag to its full form
+// ResolveTag resolves a tag to its full form: the URI of a verbatim tag
+// (!<...>), or the prefix of its handle followed by its suffix for a
+// shorthand (!local, !!str, !e!foo). The handles are those of %TAG
+// directives added to the resolver, with ! standing for itself and !!
+// for tag:yaml.org,2002: by default. A tag with an unknown handle, the
+// non-specific tag ! and a full tag are returned as is.
 func (tr *TagResolver) ResolveTag(tag string) string {
-	if tag == "" {
-		return ""
-	}
+	return resolveTag(tag, tr.tagShorthands)
+}
 
-	// Check if it's a verbatim tag (starts with !)
+// resolveTag resolves tag with the first of scopes that declares its
+// handle
+func resolveTag(tag string, scopes ...map[string]string) string {
 	if strings.HasPrefix(tag, "!<") && strings.HasSuffix(tag, ">") {
-		// Verbatim tag: !<tag:example.com,2014:something>
 		return tag[2 : len(tag)-1]
 	}
 
-	// Check if it's a local tag (!something)
-	if strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!") {
-		// Local tag, return as-is
+	handle, suffix := node.SplitTag(tag)
+	if handle == "" || tag == "!" {
 		return tag
 	}
-
-	// Check for shorthand
-	if shorthand, exists := tr.tagShorthands[tag]; exists {
-		return shorthand
-	}
-
-	// Check for partial match (e.g., !!str)
-	for short, full := range tr.tagShorthands {
-		if tag == short {
-			return full
-		}
-		// Handle secondary tags like !!myapp:custom
-		if strings.HasPrefix(tag, short) {
-			suffix := tag[len(short):]
-			return full + suffix
+	for _, handles := range scopes {
+		if prefix, ok := handles[handle]; ok {
+			return prefix + suffix
 		}
 	}
-
 	return tag
 }
 
+// isNamedHandle reports whether handle is a named handle such as !e!,
+// rather than the primary ! or secondary !! handle
+func isNamedHandle(handle string) bool {
+	return len(handle) > 2 && strings.HasPrefix(handle, "!") && strings.HasSuffix(handle, "!")
+}
+
+// Handler returns the handler registered for a tag after resolving it
+func (tr *TagResolver) Handler(tag string) (TagHandler, bool) {
+	handler, exists := tr.customHandlers[tr.ResolveTag(tag)]
+	return handler, exists
+}
+
 // ProcessTaggedValue processes a value according to its tag
 func (tr *TagResolver) ProcessTaggedValue(tag, value string) (interface{}, error) {
 	resolvedTag := tr.ResolveTag(tag)
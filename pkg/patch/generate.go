@@ -0,0 +1,167 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elioetibr/golang-yaml/pkg/diff"
+	"github.com/elioetibr/golang-yaml/pkg/merge"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// Generate returns a JSON Patch turning from into to. Sequences are
+// compared item by item and mapping key order is ignored, as JSON Patch
+// cannot express it.
+func Generate(from, to node.Node) (Patch, error) {
+	changes := diff.Diff(from, to, &diff.Options{
+		ArrayMergeStrategy: merge.ArrayMergeByIndex,
+		IgnoreKeyOrder:     true,
+	})
+
+	var p Patch
+	var removals Patch // trailing sequence removals, emitted highest index first
+	var removalParent string
+	flush := func() {
+		for i := len(removals) - 1; i >= 0; i-- {
+			p = append(p, removals[i])
+		}
+		removals = nil
+	}
+
+	for _, c := range changes {
+		pointer, err := pointerFromPath(c.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c.Type {
+		case diff.Added:
+			flush()
+			p = append(p, Operation{Op: "add", Path: pointer.String(), Value: c.New})
+		case diff.Removed:
+			op := Operation{Op: "remove", Path: pointer.String()}
+			if _, isSeq := node.Resolve(parentOf(from, pointer)).(*node.SequenceNode); isSeq {
+				if parent := pointer.parent().String(); parent != removalParent {
+					flush()
+					removalParent = parent
+				}
+				removals = append(removals, op)
+				continue
+			}
+			flush()
+			p = append(p, op)
+		case diff.Modified:
+			flush()
+			p = append(p, Operation{Op: "replace", Path: pointer.String(), Value: c.New})
+		}
+	}
+	flush()
+	return p, nil
+}
+
+// MarshalJSON encodes the patch as an RFC 6902 document
+func (p Patch) MarshalJSON() ([]byte, error) {
+	type jsonOperation struct {
+		Op    string          `json:"op"`
+		From  string          `json:"from,omitempty"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+
+	ops := make([]jsonOperation, 0, len(p))
+	for _, op := range p {
+		out := jsonOperation{Op: op.Op, From: op.From, Path: op.Path}
+		if op.Value != nil {
+			out.Value = json.RawMessage(jsonText(op.Value))
+		}
+		ops = append(ops, out)
+	}
+	return json.Marshal(ops)
+}
+
+// Overlay returns a document that turns base into target when merged over
+// base with OverlayMergeOptions. Removed keys appear as null. Values are
+// copied from target together with their comments.
+//
+// Changes a merge cannot express are reported as errors: replacing a
+// collection with a value of another kind, and setting a key to null.
+func Overlay(base, target node.Node) (node.Node, error) {
+	changes := diff.Diff(base, target, &diff.Options{
+		ArrayMergeStrategy: merge.ArrayReplace,
+		IgnoreKeyOrder:     true,
+	})
+
+	var overlay node.Node = &node.MappingNode{Style: node.StyleBlock}
+	for _, c := range changes {
+		if c.Type == diff.Modified && c.Old.Type() != c.New.Type() {
+			return nil, fmt.Errorf("%s: cannot change %s to %s in a merge overlay", displayPath(c.Path), kindName(c.Old), kindName(c.New))
+		}
+		if (c.Type == diff.Added || c.Type == diff.Modified) && isNull(c.New) {
+			return nil, fmt.Errorf("%s: null values cannot be set by a merge overlay", displayPath(c.Path))
+		}
+
+		if c.Path == "" {
+			// Only scalar documents differ at the root
+			overlay = node.Clone(c.New, nil)
+			continue
+		}
+
+		var value interface{} = node.Clone(c.New, nil)
+		if c.Type == diff.Removed {
+			value = &node.ScalarNode{Value: "null", Style: node.StylePlain}
+		}
+		if err := node.Set(overlay, c.Path, value); err != nil {
+			return nil, err
+		}
+	}
+	return overlay, nil
+}
+
+// OverlayMergeOptions returns the merge options under which an Overlay
+// reproduces its target
+func OverlayMergeOptions() *merge.Options {
+	return merge.DefaultOptions().
+		WithStrategy(merge.StrategyDeep).
+		WithArrayStrategy(merge.ArrayReplace).
+		WithOverrideEmpty(true).
+		WithNullDeletes(true)
+}
+
+// pointerFromPath converts a node.Lookup path into a JSON Pointer
+func pointerFromPath(path string) (Pointer, error) {
+	tokens, err := node.SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return Pointer(tokens), nil
+}
+
+// parentOf returns the container holding the node at pointer
+func parentOf(root node.Node, pointer Pointer) node.Node {
+	if len(pointer) == 0 {
+		return nil
+	}
+	parent, err := resolve(root, pointer.parent())
+	if err != nil {
+		return nil
+	}
+	return parent
+}
+
+func kindName(n node.Node) string {
+	switch n.Type() {
+	case node.NodeTypeMapping:
+		return "mapping"
+	case node.NodeTypeSequence:
+		return "sequence"
+	default:
+		return "scalar"
+	}
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "document root"
+	}
+	return path
+}
@@ -0,0 +1,145 @@
+package patch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/merge"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+const target = `image:
+  repository: nginx
+  tag: "1.1"
+replicas: 3
+env:
+  - name: A
+    value: "2"
+resources:
+  cpu: 100m
+`
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{name: "values", from: values, to: target},
+		{name: "trailing array removals", from: "items: [a, b, c, d]\n", to: "items: [a]\n"},
+		{name: "nested arrays", from: "a:\n  - [1, 2, 3]\n  - [4]\n", to: "a:\n  - [1]\n  - [4, 5]\n"},
+		{name: "root scalar", from: "old\n", to: "new\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := parser.ParseString(tt.from)
+			to, _ := parser.ParseString(tt.to)
+
+			p, err := Generate(from, to)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			// Round trip through the JSON form to check MarshalJSON and Decode agree
+			data, err := json.Marshal(p)
+			if err != nil {
+				t.Fatalf("MarshalJSON failed: %v", err)
+			}
+			result, err := Apply(from, data)
+			if err != nil {
+				t.Fatalf("Apply failed: %v\npatch: %s", err, data)
+			}
+
+			got, _ := convert.ToJSON(result)
+			want, _ := convert.ToJSON(to)
+			if !equalJSONText(got, want) {
+				t.Errorf("got  %s\nwant %s\npatch: %s", got, want, data)
+			}
+		})
+	}
+}
+
+func TestGenerateOperations(t *testing.T) {
+	from, _ := parser.ParseString("a: 1\nb: [x, y, z]\n")
+	to, _ := parser.ParseString("a: 2\nb: [x]\nc: true\n")
+
+	p, err := Generate(from, to)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	data, _ := json.Marshal(p)
+
+	expected := `[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/b/2"},{"op":"remove","path":"/b/1"},{"op":"add","path":"/c","value":true}]`
+	if string(data) != expected {
+		t.Errorf("got  %s\nwant %s", data, expected)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	base, _ := parser.ParseString(values)
+	to, _ := parser.ParseString(target)
+
+	overlay, err := Overlay(base, to)
+	if err != nil {
+		t.Fatalf("Overlay failed: %v", err)
+	}
+
+	text, err := serializer.SerializeToString(overlay, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+	if !strings.Contains(text, "debug: null") {
+		t.Errorf("expected removed key as null in overlay:\n%s", text)
+	}
+	if strings.Contains(text, "repository") {
+		t.Errorf("unchanged key in overlay:\n%s", text)
+	}
+
+	merged, err := merge.MergeWithOptions(base, overlay, OverlayMergeOptions())
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	got, _ := convert.ToJSON(merged)
+	want, _ := convert.ToJSON(to)
+	if !equalJSONText(got, want) {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestOverlayErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		to   string
+		msg  string
+	}{
+		{name: "kind change", base: "a: [1]\n", to: "a: {b: 1}\n", msg: "cannot change sequence to mapping"},
+		{name: "null value", base: "a: 1\n", to: "a: null\n", msg: "null values cannot be set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := parser.ParseString(tt.base)
+			to, _ := parser.ParseString(tt.to)
+			_, err := Overlay(base, to)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("expected error containing %q, got %v", tt.msg, err)
+			}
+		})
+	}
+}
+
+// equalJSONText compares JSON documents ignoring object key order
+func equalJSONText(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	x2, _ := json.Marshal(x)
+	y2, _ := json.Marshal(y)
+	return string(x2) == string(y2)
+}
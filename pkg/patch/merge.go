@@ -0,0 +1,68 @@
+package patch
+
+import (
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+// ApplyMerge decodes an RFC 7386 merge patch and applies it to root
+func ApplyMerge(root node.Node, data []byte) (node.Node, error) {
+	p, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return MergePatch(root, p), nil
+}
+
+// MergePatch applies an RFC 7386 merge patch: mappings are merged
+// recursively, null values delete keys and any other value replaces the
+// target. Existing mappings are updated in place.
+func MergePatch(target, patch node.Node) node.Node {
+	if doc, ok := target.(*node.DocumentNode); ok {
+		doc.Content = MergePatch(doc.Content, patch)
+		return doc
+	}
+
+	patchMap, ok := node.Resolve(patch).(*node.MappingNode)
+	if !ok {
+		return carryComments(target, patch)
+	}
+
+	targetMap, ok := node.Resolve(target).(*node.MappingNode)
+	if !ok {
+		targetMap = &node.MappingNode{Style: node.StyleBlock}
+		carryComments(target, targetMap)
+	}
+
+	for _, pair := range patchMap.Pairs {
+		key, ok := pair.Key.(*node.ScalarNode)
+		if !ok {
+			continue
+		}
+
+		existing := findPair(targetMap, key.Value)
+		if isNull(pair.Value) {
+			if existing != nil {
+				_ = node.Delete(targetMap, node.JoinKey("", key.Value))
+			}
+			continue
+		}
+
+		if existing != nil {
+			existing.Value = MergePatch(existing.Value, pair.Value)
+			continue
+		}
+		targetMap.Pairs = append(targetMap.Pairs, &node.MappingPair{
+			Key:   &node.ScalarNode{Value: key.Value, Style: keyStyle(key.Value)},
+			Value: MergePatch(nil, pair.Value),
+		})
+	}
+	return targetMap
+}
+
+// isNull reports whether n is a null scalar
+func isNull(n node.Node) bool {
+	scalar, ok := n.(*node.ScalarNode)
+	return n == nil || (ok && convert.ScalarTag(scalar) == parser.CommonTags.Null)
+}
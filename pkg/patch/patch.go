@@ -0,0 +1,332 @@
+// Package patch applies RFC 6902 JSON Patch and RFC 7386 JSON Merge Patch
+// documents to YAML node trees. Nodes outside the patched paths are left
+// untouched, so their comments and formatting survive serialization.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+// Operation is a single RFC 6902 operation
+type Operation struct {
+	Op    string    // add, remove, replace, move, copy or test
+	Path  string    // JSON Pointer of the target location
+	From  string    // JSON Pointer of the source for move and copy
+	Value node.Node // value for add, replace and test
+}
+
+// Patch is an ordered list of operations
+type Patch []Operation
+
+// Decode reads a JSON Patch document. YAML documents with the same
+// structure are accepted as well.
+func Decode(data []byte) (Patch, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, ok := doc.(*node.SequenceNode)
+	if !ok {
+		return nil, fmt.Errorf("patch must be an array of operations")
+	}
+
+	patch := make(Patch, 0, len(seq.Items))
+	for i, item := range seq.Items {
+		mapping, ok := item.(*node.MappingNode)
+		if !ok {
+			return nil, fmt.Errorf("operation %d: expected an object", i)
+		}
+
+		var op Operation
+		hasValue := false
+		for _, pair := range mapping.Pairs {
+			key, _ := pair.Key.(*node.ScalarNode)
+			if key == nil {
+				continue
+			}
+			switch key.Value {
+			case "op", "path", "from":
+				value, ok := pair.Value.(*node.ScalarNode)
+				if !ok {
+					return nil, fmt.Errorf("operation %d: %q must be a string", i, key.Value)
+				}
+				switch key.Value {
+				case "op":
+					op.Op = value.Value
+				case "path":
+					op.Path = value.Value
+				case "from":
+					op.From = value.Value
+				}
+			case "value":
+				op.Value = pair.Value
+				hasValue = true
+			}
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if !hasValue {
+				return nil, fmt.Errorf("operation %d (%s): missing value", i, op.Op)
+			}
+		case "remove", "move", "copy":
+		default:
+			return nil, fmt.Errorf("operation %d: unknown op %q", i, op.Op)
+		}
+		patch = append(patch, op)
+	}
+	return patch, nil
+}
+
+// Apply decodes a JSON Patch document and applies it to root
+func Apply(root node.Node, data []byte) (node.Node, error) {
+	p, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return p.Apply(root)
+}
+
+// Apply applies the operations in order to a copy of root and returns the
+// patched copy. Patches are atomic as RFC 6902 requires: when an operation
+// fails, root is returned unchanged and the error reports the position of
+// the offending node.
+func (p Patch) Apply(root node.Node) (node.Node, error) {
+	patched := node.Clone(root, nil)
+	for i, op := range p {
+		var err error
+		patched, err = applyOperation(patched, op)
+		if err != nil {
+			if yamlErr, ok := err.(*errors.YAMLError); ok {
+				yamlErr.Message = fmt.Sprintf("operation %d (%s %s): %s", i, op.Op, op.Path, yamlErr.Message)
+				return root, yamlErr
+			}
+			return root, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return patched, nil
+}
+
+func applyOperation(root node.Node, op Operation) (node.Node, error) {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return root, err
+	}
+
+	switch op.Op {
+	case "add":
+		return add(root, path, op.Value)
+	case "remove":
+		_, err := remove(root, path)
+		return root, err
+	case "replace":
+		return replace(root, path, op.Value)
+	case "move":
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return root, err
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return root, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		value, err := remove(root, from)
+		if err != nil {
+			return root, err
+		}
+		return add(root, path, value)
+	case "copy":
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return root, err
+		}
+		value, err := resolve(root, from)
+		if err != nil {
+			return root, err
+		}
+		return add(root, path, node.Clone(value, nil))
+	case "test":
+		target, err := resolve(root, path)
+		if err != nil {
+			return root, err
+		}
+		if !equalJSON(target, op.Value) {
+			return root, positioned(locate(root, path), "test failed: expected %s, found %s", jsonText(op.Value), jsonText(target))
+		}
+		return root, nil
+	default:
+		return root, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// add inserts value at path: a new or existing mapping key, or an array
+// position where "-" appends
+func add(root node.Node, path Pointer, value node.Node) (node.Node, error) {
+	if len(path) == 0 {
+		return carryComments(root, value), nil
+	}
+
+	parent, err := resolve(root, path.parent())
+	if err != nil {
+		return root, err
+	}
+
+	switch container := node.Resolve(parent).(type) {
+	case *node.MappingNode:
+		if pair := findPair(container, path.last()); pair != nil {
+			pair.Value = carryComments(pair.Value, value)
+		} else {
+			container.Pairs = append(container.Pairs, &node.MappingPair{
+				Key:   &node.ScalarNode{Value: path.last(), Style: keyStyle(path.last())},
+				Value: value,
+			})
+		}
+	case *node.SequenceNode:
+		index := len(container.Items)
+		if path.last() != "-" {
+			// The index may also be the length, appending the value
+			index, err = arrayIndex(path.last(), len(container.Items)+1)
+			if err != nil {
+				return root, positioned(locate(root, path.parent()), "%v", err)
+			}
+		}
+		container.Items = append(container.Items, nil)
+		copy(container.Items[index+1:], container.Items[index:])
+		container.Items[index] = value
+	default:
+		return root, positioned(locate(root, path.parent()), "cannot add to a scalar")
+	}
+	return root, nil
+}
+
+// remove deletes the node at path and returns it
+func remove(root node.Node, path Pointer) (node.Node, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the document root")
+	}
+	target, err := resolve(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := node.Delete(root, path.Path(root)); err != nil {
+		return nil, positioned(locate(root, path), "%v", err)
+	}
+	return target, nil
+}
+
+// replace swaps the node at path for value, keeping its comments
+func replace(root node.Node, path Pointer, value node.Node) (node.Node, error) {
+	if len(path) == 0 {
+		return carryComments(root, value), nil
+	}
+
+	if _, err := resolve(root, path); err != nil {
+		return root, err
+	}
+	parent, _ := resolve(root, path.parent())
+
+	switch container := node.Resolve(parent).(type) {
+	case *node.MappingNode:
+		pair := findPair(container, path.last())
+		pair.Value = carryComments(pair.Value, value)
+	case *node.SequenceNode:
+		i, _ := arrayIndex(path.last(), len(container.Items))
+		container.Items[i] = carryComments(container.Items[i], value)
+	}
+	return root, nil
+}
+
+// carryComments moves the comments of a replaced node to its replacement.
+// A quoted string keeps its quoting style when replaced by another string.
+func carryComments(old, replacement node.Node) node.Node {
+	oldBase, okOld := old.(interface{ GetBase() *node.BaseNode })
+	newBase, okNew := replacement.(interface{ GetBase() *node.BaseNode })
+	if !okOld || !okNew {
+		return replacement
+	}
+
+	o, n := oldBase.GetBase(), newBase.GetBase()
+	if n.HeadComment == nil {
+		n.HeadComment = o.HeadComment
+	}
+	if n.LineComment == nil {
+		n.LineComment = o.LineComment
+	}
+	if n.FootComment == nil {
+		n.FootComment = o.FootComment
+	}
+
+	oldScalar, okOld := old.(*node.ScalarNode)
+	newScalar, okNew := replacement.(*node.ScalarNode)
+	if okOld && okNew && convert.ScalarTag(newScalar) == parser.CommonTags.Str &&
+		(oldScalar.Style == node.StyleSingleQuoted || oldScalar.Style == node.StyleDoubleQuoted) {
+		newScalar.Style = oldScalar.Style
+	}
+	return replacement
+}
+
+// keyStyle quotes new mapping keys that would not read back as strings
+func keyStyle(key string) node.Style {
+	if parser.InferTag(key) != parser.CommonTags.Str {
+		return node.StyleDoubleQuoted
+	}
+	return node.StylePlain
+}
+
+// equalJSON compares two nodes by their JSON data model, as RFC 6902 test requires
+func equalJSON(a, b node.Node) bool {
+	var x, y interface{}
+	if err := json.Unmarshal([]byte(jsonText(a)), &x); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(jsonText(b)), &y); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func jsonText(n node.Node) string {
+	data, err := convert.ToJSON(n)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(data)
+}
+
+// isPrefix reports whether prefix is a leading part of p
+func isPrefix(prefix, p Pointer) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != p[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// positioned builds an error located at n. Nodes created by the patch have
+// no source position, so their errors carry none.
+func positioned(n node.Node, format string, args ...interface{}) error {
+	if n == nil || n.Line() == 0 {
+		return fmt.Errorf(format, args...)
+	}
+	pos := errors.Position{Line: n.Line(), Column: n.Column()}
+	return errors.New(fmt.Sprintf(format, args...), pos, errors.ErrorTypePatch)
+}
+
+// parseDocument reads JSON, falling back to YAML
+func parseDocument(data []byte) (node.Node, error) {
+	if n, err := convert.FromJSON(data); err == nil {
+		return n, nil
+	}
+	return parser.ParseString(string(data))
+}
@@ -0,0 +1,215 @@
+package patch
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/errors"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+const values = `# Deployment values
+image:
+  repository: nginx
+  tag: "1.0"  # pinned
+replicas: 1
+env:
+  - name: A
+    value: "1"
+debug: true
+`
+
+func TestParsePointer(t *testing.T) {
+	p, err := ParsePointer("/a~1b/c~0d/0")
+	if err != nil {
+		t.Fatalf("ParsePointer failed: %v", err)
+	}
+	if len(p) != 3 || p[0] != "a/b" || p[1] != "c~d" || p[2] != "0" {
+		t.Errorf("unexpected tokens %q", p)
+	}
+	if p.String() != "/a~1b/c~0d/0" {
+		t.Errorf("String() = %q", p.String())
+	}
+	if _, err := ParsePointer("a/b"); err == nil {
+		t.Error("expected error for pointer without leading slash")
+	}
+}
+
+func TestPointerPathThroughAliases(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.PreserveAliases = true
+	stream, err := parser.ParseStreamWithOptions("list: &l [a, b]\nref: *l\n", opts)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	p, _ := ParsePointer("/ref/1")
+	for _, root := range []node.Node{stream.Documents[0].Root, stream.Documents[0].Node()} {
+		if path := p.Path(root); path != "ref[1]" {
+			t.Errorf("Path() = %q, want %q", path, "ref[1]")
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "replace keeps comments and quoting",
+			patch:    `[{"op": "replace", "path": "/image/tag", "value": "1.2.3"}]`,
+			expected: `{"image":{"repository":"nginx","tag":"1.2.3"},"replicas":1,"env":[{"name":"A","value":"1"}],"debug":true}`,
+		},
+		{
+			name:     "add key and array items",
+			patch:    `[{"op": "add", "path": "/image/pullPolicy", "value": "Always"}, {"op": "add", "path": "/env/-", "value": {"name": "B"}}, {"op": "add", "path": "/env/0", "value": {"name": "Z"}}]`,
+			expected: `{"image":{"repository":"nginx","tag":"1.0","pullPolicy":"Always"},"replicas":1,"env":[{"name":"Z"},{"name":"A","value":"1"},{"name":"B"}],"debug":true}`,
+		},
+		{
+			name:     "remove move and copy",
+			patch:    `[{"op": "remove", "path": "/debug"}, {"op": "move", "from": "/replicas", "path": "/image/replicas"}, {"op": "copy", "from": "/env/0", "path": "/env/-"}]`,
+			expected: `{"image":{"repository":"nginx","tag":"1.0","replicas":1},"env":[{"name":"A","value":"1"},{"name":"A","value":"1"}]}`,
+		},
+		{
+			name:     "successful test",
+			patch:    `[{"op": "test", "path": "/replicas", "value": 1.0}, {"op": "test", "path": "/env/0", "value": {"value": "1", "name": "A"}}]`,
+			expected: `{"image":{"repository":"nginx","tag":"1.0"},"replicas":1,"env":[{"name":"A","value":"1"}],"debug":true}`,
+		},
+		{
+			name:     "yaml patch document",
+			patch:    "- op: replace\n  path: /replicas\n  value: 3\n",
+			expected: `{"image":{"repository":"nginx","tag":"1.0"},"replicas":3,"env":[{"name":"A","value":"1"}],"debug":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parser.ParseString(values)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+
+			root, err = Apply(root, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+
+			data, err := convert.ToJSON(root)
+			if err != nil {
+				t.Fatalf("ToJSON failed: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("got  %s\nwant %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyPreservesFormatting(t *testing.T) {
+	root, _ := parser.ParseString(values)
+	root, err := Apply(root, []byte(`[{"op": "replace", "path": "/image/tag", "value": "2.0"}]`))
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	output, err := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+	for _, want := range []string{"# Deployment values\nimage:", `tag: "2.0"  # pinned`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		line  int
+	}{
+		{name: "failed test is positioned", patch: `[{"op": "test", "path": "/image/tag", "value": "2.0"}]`, line: 4},
+		{name: "missing key", patch: `[{"op": "remove", "path": "/image/digest"}]`, line: 3},
+		{name: "index out of range", patch: `[{"op": "replace", "path": "/env/5", "value": 1}]`, line: 7},
+		{name: "add to scalar", patch: `[{"op": "add", "path": "/debug/level", "value": 1}]`, line: 9},
+		{name: "add with leading zero", patch: `[{"op": "add", "path": "/env/01", "value": 1}]`, line: 7},
+		{name: "add with plus sign", patch: `[{"op": "add", "path": "/env/+1", "value": 1}]`, line: 7},
+		{name: "add with minus zero", patch: `[{"op": "add", "path": "/env/-0", "value": 1}]`, line: 7},
+		{name: "add past the end", patch: `[{"op": "add", "path": "/env/2", "value": 1}]`, line: 7},
+		{name: "replace with plus sign", patch: `[{"op": "replace", "path": "/env/+0", "value": 1}]`, line: 7},
+		{name: "remove with minus zero", patch: `[{"op": "remove", "path": "/env/-0"}]`, line: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, _ := parser.ParseString(values)
+			_, err := Apply(root, []byte(tt.patch))
+
+			var yamlErr *errors.YAMLError
+			if !stderrors.As(err, &yamlErr) {
+				t.Fatalf("expected *errors.YAMLError, got %T: %v", err, err)
+			}
+			if yamlErr.Type != errors.ErrorTypePatch || yamlErr.Position.Line != tt.line {
+				t.Errorf("error %v: want line %d", yamlErr, tt.line)
+			}
+		})
+	}
+
+	for _, bad := range []string{`{"op": "add"}`, `[{"op": "frobnicate", "path": "/a"}]`, `[{"op": "add", "path": "/a"}]`} {
+		if _, err := Decode([]byte(bad)); err == nil {
+			t.Errorf("Decode(%s) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	root, _ := parser.ParseString(values)
+	before, _ := convert.ToJSON(root)
+
+	result, err := Apply(root, []byte(`[
+		{"op": "replace", "path": "/image/tag", "value": "2.0"},
+		{"op": "remove", "path": "/debug"},
+		{"op": "test", "path": "/image/tag", "value": "3.0"}
+	]`))
+
+	var yamlErr *errors.YAMLError
+	if !stderrors.As(err, &yamlErr) {
+		t.Fatalf("expected *errors.YAMLError, got %T: %v", err, err)
+	}
+	// The replaced value has no position of its own, so the error points at its parent
+	if yamlErr.Position.Line != 3 {
+		t.Errorf("error %v: want line 3", yamlErr)
+	}
+	if result != root {
+		t.Errorf("expected the original root back after a failed patch")
+	}
+	if after, _ := convert.ToJSON(root); string(after) != string(before) {
+		t.Errorf("failed patch modified the document:\ngot  %s\nwant %s", after, before)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	root, _ := parser.ParseString(values)
+	root, err := ApplyMerge(root, []byte(`{"image": {"tag": "1.1", "digest": null}, "debug": null, "resources": {"cpu": "100m", "memory": null}, "env": [{"name": "B"}]}`))
+	if err != nil {
+		t.Fatalf("ApplyMerge failed: %v", err)
+	}
+
+	data, _ := convert.ToJSON(root)
+	expected := `{"image":{"repository":"nginx","tag":"1.1"},"replicas":1,"env":[{"name":"B"}],"resources":{"cpu":"100m"}}`
+	if string(data) != expected {
+		t.Errorf("got  %s\nwant %s", data, expected)
+	}
+
+	output, _ := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if !strings.Contains(output, `tag: "1.1"  # pinned`) {
+		t.Errorf("expected comment kept on merged value:\n%s", output)
+	}
+}
@@ -0,0 +1,149 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// Pointer is a parsed RFC 6901 JSON Pointer
+type Pointer []string
+
+// ParsePointer parses a JSON Pointer such as "/spec/containers/0/image"
+func ParsePointer(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with '/'", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		// ~1 must be decoded before ~0 so "~01" becomes "~1"
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return Pointer(tokens), nil
+}
+
+// String encodes the pointer back to its textual form
+func (p Pointer) String() string {
+	var sb strings.Builder
+	for _, token := range p {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// Path converts the pointer to the path syntax used by node.Lookup
+func (p Pointer) Path(root node.Node) string {
+	path := ""
+	current := root
+	for _, token := range p {
+		if seq, ok := node.Resolve(current).(*node.SequenceNode); ok {
+			if i, err := strconv.Atoi(token); err == nil {
+				path = node.JoinIndex(path, i)
+				if i >= 0 && i < len(seq.Items) {
+					current = seq.Items[i]
+				}
+				continue
+			}
+		}
+		path = node.JoinKey(path, token)
+		current, _ = child(current, token)
+	}
+	return path
+}
+
+// parent returns the pointer without its last token
+func (p Pointer) parent() Pointer {
+	return p[:len(p)-1]
+}
+
+// last returns the final token of the pointer
+func (p Pointer) last() string {
+	return p[len(p)-1]
+}
+
+// resolve returns the node the pointer refers to
+func resolve(root node.Node, p Pointer) (node.Node, error) {
+	current := root
+	for i, token := range p {
+		next, err := child(current, token)
+		if err != nil {
+			return nil, positioned(locate(root, p[:i]), "path %s: %v", p[:i+1].String(), err)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// locate returns the deepest node along the pointer that has a source
+// position, so errors on nodes a patch created point at the nearest
+// enclosing node from the document
+func locate(root node.Node, p Pointer) node.Node {
+	current, located := root, root
+	for _, token := range p {
+		next, err := child(current, token)
+		if err != nil {
+			break
+		}
+		current = next
+		if next.Line() > 0 {
+			located = next
+		}
+	}
+	return located
+}
+
+// child returns the child of n addressed by a pointer token
+func child(n node.Node, token string) (node.Node, error) {
+	switch v := node.Resolve(n).(type) {
+	case *node.MappingNode:
+		if pair := findPair(v, token); pair != nil {
+			return pair.Value, nil
+		}
+		return nil, fmt.Errorf("key %q not found", token)
+	case *node.SequenceNode:
+		i, err := arrayIndex(token, len(v.Items))
+		if err != nil {
+			return nil, err
+		}
+		return v.Items[i], nil
+	case nil:
+		return nil, fmt.Errorf("no such node")
+	default:
+		return nil, fmt.Errorf("cannot descend into a scalar")
+	}
+}
+
+// arrayIndex parses an array index below length. RFC 6901 writes indexes
+// as digits without a sign or leading zeros; "-" is rejected.
+func arrayIndex(token string, length int) (int, error) {
+	if token == "-" {
+		return 0, fmt.Errorf("index \"-\" refers past the end of the array")
+	}
+	if token == "" || strings.Trim(token, "0123456789") != "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= length {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func findPair(m *node.MappingNode, key string) *node.MappingPair {
+	for _, pair := range m.Pairs {
+		if k, ok := pair.Key.(*node.ScalarNode); ok && k.Value == key {
+			return pair
+		}
+	}
+	return nil
+}
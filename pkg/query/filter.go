@@ -0,0 +1,170 @@
+package query
+
+import (
+	"github.com/elioetibr/golang-yaml/pkg/convert"
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+// filterExpr is a predicate of a [?(...)] selector
+type filterExpr interface {
+	test(m *Match, root node.Node) bool
+}
+
+type orExpr struct{ left, right filterExpr }
+
+func (e *orExpr) test(m *Match, root node.Node) bool {
+	return e.left.test(m, root) || e.right.test(m, root)
+}
+
+type andExpr struct{ left, right filterExpr }
+
+func (e *andExpr) test(m *Match, root node.Node) bool {
+	return e.left.test(m, root) && e.right.test(m, root)
+}
+
+type notExpr struct{ inner filterExpr }
+
+func (e *notExpr) test(m *Match, root node.Node) bool {
+	return !e.inner.test(m, root)
+}
+
+// existsExpr is a bare operand: a path that must match something, or a literal true
+type existsExpr struct{ operand operand }
+
+func (e *existsExpr) test(m *Match, root node.Node) bool {
+	if lit, ok := e.operand.(*literal); ok {
+		b, isBool := lit.value.(bool)
+		return isBool && b
+	}
+	return len(e.operand.values(m, root)) > 0
+}
+
+// compareExpr compares two operands; a path matching several nodes
+// satisfies the comparison when any of its values does
+type compareExpr struct {
+	left, right operand
+	op          string
+}
+
+func (e *compareExpr) test(m *Match, root node.Node) bool {
+	for _, a := range e.left.values(m, root) {
+		for _, b := range e.right.values(m, root) {
+			if compareValues(a, b, e.op) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// operand produces the values a comparison operates on
+type operand interface {
+	values(m *Match, root node.Node) []interface{}
+}
+
+// literal is a constant string, number, boolean or null
+type literal struct{ value interface{} }
+
+func (l *literal) values(*Match, node.Node) []interface{} {
+	return []interface{}{l.value}
+}
+
+// pathOperand is a path relative to the current node (@) or the root ($)
+type pathOperand struct {
+	fromRoot bool
+	steps    []*step
+}
+
+func (p *pathOperand) values(m *Match, root node.Node) []interface{} {
+	start := m
+	if p.fromRoot {
+		start = &Match{Node: root, Index: -1}
+	}
+
+	var result []interface{}
+	for _, match := range evaluate(p.steps, start, root) {
+		result = append(result, nodeValue(match.Node))
+	}
+	return result
+}
+
+// nodeValue resolves a scalar to nil, bool, float64 or string.
+// Collections are returned as nodes and only support existence checks.
+func nodeValue(n node.Node) interface{} {
+	scalar, ok := node.Resolve(n).(*node.ScalarNode)
+	if !ok {
+		return n
+	}
+
+	tag := convert.ScalarTag(scalar)
+	switch tag {
+	case parser.CommonTags.Null:
+		return nil
+	case parser.CommonTags.Bool, parser.CommonTags.Int, parser.CommonTags.Float:
+		v, err := parser.NewTagResolver().ProcessTaggedValue(tag, scalar.Value)
+		if err != nil {
+			return scalar.Value
+		}
+		switch x := v.(type) {
+		case int64:
+			return float64(x)
+		default:
+			return x
+		}
+	default:
+		return scalar.Value
+	}
+}
+
+// compareValues applies a comparison operator to two resolved values
+func compareValues(a, b interface{}, op string) bool {
+	switch op {
+	case "==":
+		return equalValues(a, b)
+	case "!=":
+		return !equalValues(a, b)
+	}
+
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return false
+		}
+		return orderedCompare(x < y, x == y, op)
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return false
+		}
+		return orderedCompare(x < y, x == y, op)
+	}
+	return false
+}
+
+func equalValues(a, b interface{}) bool {
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case bool, float64, string:
+		return a == b
+	case node.Node:
+		return x == b
+	}
+	return false
+}
+
+func orderedCompare(less, equal bool, op string) bool {
+	switch op {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	case ">=":
+		return !less
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// exprParser is a recursive descent parser for query expressions
+type exprParser struct {
+	expr string
+	pos  int
+}
+
+func (p *exprParser) eof() bool {
+	return p.pos >= len(p.expr)
+}
+
+func (p *exprParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.expr[p.pos]
+}
+
+func (p *exprParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(s string) error {
+	p.skipSpaces()
+	if !p.consume(s) {
+		if p.eof() {
+			return p.errorf("expected %q, found end of expression", s)
+		}
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid query %q at offset %d: %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+// parsePath parses a sequence of steps. Inside filters the path ends at the
+// first character that cannot continue it, such as an operator or ')'.
+func (p *exprParser) parsePath(inFilter bool) ([]*step, error) {
+	var steps []*step
+
+	// A leading key needs no dot: spec.containers
+	if !inFilter && !p.consume("$") && !p.eof() && p.peek() != '.' && p.peek() != '[' {
+		s, err := p.parseSelector(false)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+	}
+
+	for !p.eof() {
+		switch {
+		case p.consume(".."):
+			s, err := p.parseSelector(inFilter)
+			if err != nil {
+				return nil, err
+			}
+			s.recursive = true
+			steps = append(steps, s)
+		case p.consume("."):
+			if p.peek() == '[' {
+				return nil, p.errorf("unexpected '[' after '.'")
+			}
+			s, err := p.parseSelector(inFilter)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		case p.peek() == '[':
+			s, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		default:
+			if inFilter {
+				return steps, nil
+			}
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+
+	return steps, nil
+}
+
+// parseSelector parses what follows a dot: a name, '*' or a bracket
+func (p *exprParser) parseSelector(inFilter bool) (*step, error) {
+	switch p.peek() {
+	case '*':
+		p.pos++
+		return &step{kind: stepWildcard}, nil
+	case '[':
+		return p.parseBracket()
+	}
+
+	start := p.pos
+	for !p.eof() && !isNameTerminator(p.peek(), inFilter) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected key name")
+	}
+	return &step{kind: stepKey, key: p.expr[start:p.pos]}, nil
+}
+
+// isNameTerminator reports whether c ends an unquoted key name
+func isNameTerminator(c byte, inFilter bool) bool {
+	switch c {
+	case '.', '[', ']':
+		return true
+	}
+	if inFilter {
+		return strings.IndexByte(" \t)=!<>&|", c) >= 0
+	}
+	return false
+}
+
+// parseBracket parses [*], [n], ["key"], ['key'] and [?(filter)]
+func (p *exprParser) parseBracket() (*step, error) {
+	p.pos++ // '['
+	p.skipSpaces()
+
+	var s *step
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		s = &step{kind: stepWildcard}
+	case c == '?':
+		p.pos++
+		p.skipSpaces()
+		parens := p.consume("(")
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parens {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		s = &step{kind: stepFilter, filter: filter}
+	case c == '"' || c == '\'':
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		s = &step{kind: stepKey, key: key}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.expr[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("invalid index %q", p.expr[start:p.pos])
+		}
+		s = &step{kind: stepIndex, index: index}
+	default:
+		return nil, p.errorf("invalid selector")
+	}
+
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parseString parses a single- or double-quoted string literal
+func (p *exprParser) parseString() (string, error) {
+	quote := p.peek()
+	start := p.pos
+	p.pos++
+
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\\' && p.pos+1 < len(p.expr):
+			if quote == '"' {
+				sb.WriteByte(c)
+			}
+			sb.WriteByte(p.expr[p.pos+1])
+			p.pos += 2
+			continue
+		case c == quote:
+			p.pos++
+			if quote == '\'' {
+				return sb.String(), nil
+			}
+			s, err := strconv.Unquote(`"` + sb.String() + `"`)
+			if err != nil {
+				p.pos = start
+				return "", p.errorf("invalid string literal")
+			}
+			return s, nil
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+
+	p.pos = start
+	return "", p.errorf("unterminated string literal")
+}
+
+// parseOr parses a filter expression: or := and ('||' and)*
+func (p *exprParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+}
+
+// parseAnd parses and := unary ('&&' unary)*
+func (p *exprParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+}
+
+// parseUnary parses unary := '!' unary | '(' or ')' | comparison
+func (p *exprParser) parseUnary() (filterExpr, error) {
+	p.skipSpaces()
+
+	if p.peek() == '!' && !strings.HasPrefix(p.expr[p.pos:], "!=") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{inner: inner}, nil
+	}
+
+	if p.consume("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &compareExpr{left: left, right: right, op: op}, nil
+		}
+	}
+	return &existsExpr{operand: left}, nil
+}
+
+// parseOperand parses @path, $path, a string, a number, true, false or null
+func (p *exprParser) parseOperand() (operand, error) {
+	p.skipSpaces()
+
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		steps, err := p.parsePath(true)
+		if err != nil {
+			return nil, err
+		}
+		return &pathOperand{fromRoot: c == '$', steps: steps}, nil
+	case c == '"' || c == '\'':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &literal{value: s}, nil
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for !p.eof() && strings.IndexByte("+-.eE0123456789", p.peek()) >= 0 {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.expr[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number")
+		}
+		return &literal{value: f}, nil
+	}
+
+	for word, value := range map[string]interface{}{"true": true, "false": false, "null": nil} {
+		if p.consume(word) {
+			return &literal{value: value}, nil
+		}
+	}
+
+	if p.eof() {
+		return nil, p.errorf("expected operand, found end of expression")
+	}
+	return nil, p.errorf("expected operand")
+}
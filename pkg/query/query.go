@@ -0,0 +1,155 @@
+// Package query evaluates path expressions against YAML node trees.
+//
+// The expression language is a subset of JSONPath:
+//
+//	spec.containers[*].image           child keys, wildcards and indexes
+//	..name                             recursive descent
+//	items[?(@.enabled == true)]        filters with ==, !=, <, <=, >, >=, &&, || and !
+//	metadata.labels["app.kubernetes.io/name"]  quoted keys
+//
+// Matches reference the nodes of the original tree, so they can be modified
+// in place without losing comments or formatting.
+package query
+
+import (
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// Match is a node selected by a query
+type Match struct {
+	// Path locates the node using the syntax accepted by node.Lookup
+	Path string
+
+	// Node is the matched node
+	Node node.Node
+
+	// Parent is the mapping or sequence containing Node, nil for the root
+	Parent node.Node
+
+	// Pair is the mapping entry holding Node when the parent is a mapping
+	Pair *node.MappingPair
+
+	// Index is the position of Node in its parent sequence, or -1
+	Index int
+}
+
+// Query is a compiled path expression
+type Query struct {
+	expr  string
+	steps []*step
+}
+
+// Compile parses a path expression
+func Compile(expr string) (*Query, error) {
+	p := &exprParser{expr: strings.TrimSpace(expr)}
+	steps, err := p.parsePath(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.expr[p.pos])
+	}
+	return &Query{expr: expr, steps: steps}, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid
+func MustCompile(expr string) *Query {
+	q, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Find compiles expr and evaluates it against root
+func Find(root node.Node, expr string) ([]*Match, error) {
+	q, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Find(root), nil
+}
+
+// String returns the source expression
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Find returns all nodes under root matching the query, in document order
+func (q *Query) Find(root node.Node) []*Match {
+	if root == nil {
+		return nil
+	}
+	return evaluate(q.steps, &Match{Node: root, Index: -1}, root)
+}
+
+// Nodes returns the matched nodes without their locations
+func (q *Query) Nodes(root node.Node) []node.Node {
+	matches := q.Find(root)
+	nodes := make([]node.Node, len(matches))
+	for i, m := range matches {
+		nodes[i] = m.Node
+	}
+	return nodes
+}
+
+// evaluate applies steps to start; root is the document root used by $ in filters
+func evaluate(steps []*step, start *Match, root node.Node) []*Match {
+	current := []*Match{start}
+	for _, s := range steps {
+		var next []*Match
+		for _, m := range current {
+			if s.recursive {
+				for _, d := range descendants(m) {
+					next = append(next, s.apply(d, root)...)
+				}
+				continue
+			}
+			next = append(next, s.apply(m, root)...)
+		}
+		current = next
+	}
+	return current
+}
+
+// children returns the direct children of a match's node
+func children(m *Match) []*Match {
+	var result []*Match
+	switch n := node.Resolve(m.Node).(type) {
+	case *node.MappingNode:
+		for _, pair := range n.Pairs {
+			key, ok := pair.Key.(*node.ScalarNode)
+			if !ok || pair.Value == nil {
+				continue
+			}
+			result = append(result, &Match{
+				Path:   node.JoinKey(m.Path, key.Value),
+				Node:   pair.Value,
+				Parent: n,
+				Pair:   pair,
+				Index:  -1,
+			})
+		}
+	case *node.SequenceNode:
+		for i, item := range n.Items {
+			result = append(result, &Match{
+				Path:   node.JoinIndex(m.Path, i),
+				Node:   item,
+				Parent: n,
+				Index:  i,
+			})
+		}
+	}
+	return result
+}
+
+// descendants returns m and every node below it in document order
+func descendants(m *Match) []*Match {
+	result := []*Match{m}
+	for _, child := range children(m) {
+		result = append(result, descendants(child)...)
+	}
+	return result
+}
@@ -0,0 +1,197 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
+)
+
+const podSpec = `metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: frontend
+    tier: web
+spec:
+  containers:
+    - name: app
+      image: nginx:1.25 # pinned
+      port: 8080
+    - name: sidecar
+      image: envoy:1.29
+      port: 9901
+items:
+  - id: 1
+    enabled: true
+  - id: 2
+    enabled: false
+  - id: 3
+    enabled: yes
+`
+
+func TestFind(t *testing.T) {
+	root, err := parser.ParseString(podSpec)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		expr   string
+		paths  []string
+		values []string
+	}{
+		{
+			expr:   "spec.containers[*].image",
+			paths:  []string{"spec.containers[0].image", "spec.containers[1].image"},
+			values: []string{"nginx:1.25", "envoy:1.29"},
+		},
+		{
+			expr:   "$.spec.containers[-1].name",
+			paths:  []string{"spec.containers[1].name"},
+			values: []string{"sidecar"},
+		},
+		{
+			expr:   "..name",
+			paths:  []string{"metadata.name", "spec.containers[0].name", "spec.containers[1].name"},
+			values: []string{"web", "app", "sidecar"},
+		},
+		{
+			expr:   `metadata.labels["app.kubernetes.io/name"]`,
+			paths:  []string{`metadata.labels["app.kubernetes.io/name"]`},
+			values: []string{"frontend"},
+		},
+		{
+			expr:   "items[?(@.enabled == true)].id",
+			paths:  []string{"items[0].id", "items[2].id"},
+			values: []string{"1", "3"},
+		},
+		{
+			expr:   "items[?(@.id >= 2 && !(@.enabled == true))].id",
+			paths:  []string{"items[1].id"},
+			values: []string{"2"},
+		},
+		{
+			expr:   `spec.containers[?(@.image == 'envoy:1.29' || @.port < 9000)].name`,
+			paths:  []string{"spec.containers[0].name", "spec.containers[1].name"},
+			values: []string{"app", "sidecar"},
+		},
+		{
+			expr:   "spec.containers[?(@.missing)]",
+			paths:  nil,
+			values: nil,
+		},
+		{
+			expr:   "metadata.labels.*",
+			paths:  []string{`metadata.labels["app.kubernetes.io/name"]`, "metadata.labels.tier"},
+			values: []string{"frontend", "web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			matches, err := Find(root, tt.expr)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var paths, values []string
+			for _, m := range matches {
+				paths = append(paths, m.Path)
+				values = append(values, m.Node.(*node.ScalarNode).Value)
+
+				// Every reported path must resolve back to the same node
+				if found, err := node.Lookup(root, m.Path); err != nil || found != m.Node {
+					t.Errorf("path %q does not resolve to the matched node (%v)", m.Path, err)
+				}
+			}
+
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("paths = %q, want %q", paths, tt.paths)
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("values = %q, want %q", values, tt.values)
+			}
+		})
+	}
+}
+
+func TestFindModifyInPlace(t *testing.T) {
+	root, err := parser.ParseString(podSpec)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	for _, m := range MustCompile("spec.containers[0].image").Find(root) {
+		m.Node.(*node.ScalarNode).Value = "nginx:1.27"
+	}
+
+	output, err := serializer.SerializeToString(root, serializer.DefaultOptions())
+	if err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+	var edited string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "nginx:1.27") {
+			edited = line
+		}
+	}
+	if !strings.Contains(edited, "# pinned") {
+		t.Errorf("expected edited value with its comment, got:\n%s", output)
+	}
+}
+
+// TestFindThroughAliases checks that every step looks through preserved
+// aliases and documents
+func TestFindThroughAliases(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.PreserveAliases = true
+	stream, err := parser.ParseStreamWithOptions("base: &b {x: 1}\nref: *b\nlist: &l [1, 2]\nr2: *l\n", opts)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	doc := stream.Documents[0].Node()
+
+	tests := []struct {
+		expr   string
+		values []string
+	}{
+		{"ref.x", []string{"1"}},
+		{"ref.*", []string{"1"}},
+		{"r2[0]", []string{"1"}},
+		{"r2[-1]", []string{"2"}},
+		{"r2[*]", []string{"1", "2"}},
+		{"base.x", []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		matches, err := Find(doc, tt.expr)
+		if err != nil {
+			t.Fatalf("Find(%q) failed: %v", tt.expr, err)
+		}
+		var values []string
+		for _, m := range matches {
+			values = append(values, m.Node.(*node.ScalarNode).Value)
+		}
+		if !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("Find(%q) = %q, want %q", tt.expr, values, tt.values)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"spec[",
+		"spec[abc]",
+		`labels["unterminated]`,
+		"items[?(@.id ==)]",
+		"items[?(@.id == 1]",
+		"spec.",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", expr)
+		}
+	}
+}
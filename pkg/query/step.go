@@ -0,0 +1,72 @@
+package query
+
+import (
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// stepKind identifies the selector of a path step
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepWildcard
+	stepFilter
+)
+
+// step is one selector of a compiled query
+type step struct {
+	kind      stepKind
+	key       string
+	index     int
+	filter    filterExpr
+	recursive bool // applied to every descendant (..)
+}
+
+// apply selects the children of m matching the step, looking through
+// documents and aliases
+func (s *step) apply(m *Match, root node.Node) []*Match {
+	current := node.Resolve(m.Node)
+	switch s.kind {
+	case stepKey:
+		if mapping, ok := current.(*node.MappingNode); ok {
+			for _, pair := range mapping.Pairs {
+				if key, ok := pair.Key.(*node.ScalarNode); ok && key.Value == s.key && pair.Value != nil {
+					return []*Match{{
+						Path:   node.JoinKey(m.Path, key.Value),
+						Node:   pair.Value,
+						Parent: mapping,
+						Pair:   pair,
+						Index:  -1,
+					}}
+				}
+			}
+		}
+	case stepIndex:
+		if seq, ok := current.(*node.SequenceNode); ok {
+			i := s.index
+			if i < 0 {
+				i += len(seq.Items)
+			}
+			if i >= 0 && i < len(seq.Items) {
+				return []*Match{{
+					Path:   node.JoinIndex(m.Path, i),
+					Node:   seq.Items[i],
+					Parent: seq,
+					Index:  i,
+				}}
+			}
+		}
+	case stepWildcard:
+		return children(m)
+	case stepFilter:
+		var result []*Match
+		for _, child := range children(m) {
+			if s.filter.test(child, root) {
+				result = append(result, child)
+			}
+		}
+		return result
+	}
+	return nil
+}
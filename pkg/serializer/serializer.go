@@ -3,8 +3,10 @@ package serializer
 import (
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 
+	"github.com/elioetibr/golang-yaml/pkg/charset"
 	"github.com/elioetibr/golang-yaml/pkg/node"
 )
 
@@ -33,6 +35,10 @@ type Options struct {
 
 	// Tag handling
 	EmitTags bool // Emit tags from nodes
+
+	// Output encoding
+	Encoding charset.Encoding // Encoding of the output (default: UTF-8)
+	WriteBOM bool             // Start the output with a byte order mark
 }
 
 // DefaultOptions returns the default serialization options
@@ -54,6 +60,10 @@ type Serializer struct {
 	line        int
 	inFlow      bool
 	buffer      strings.Builder
+	propsDone   node.Node         // node whose anchor and tag were written by its parent
+	inSource    bool              // formatting an edit of a tree parsed with its source
+	inKey       bool              // writing a mapping key, which cannot be a block scalar
+	tagHandles  map[string]string // %TAG handles tags are shortened with
 }
 
 // NewSerializer creates a new serializer with the given writer and options
@@ -69,24 +79,44 @@ func NewSerializer(w io.Writer, opts *Options) *Serializer {
 	}
 }
 
-// Serialize serializes a node to the writer
+// Serialize serializes a node to the writer. A tree parsed in CST mode is
+// written as its source text, with only the nodes edited since parsing
+// reformatted.
 func (s *Serializer) Serialize(n node.Node) error {
-	if s.options.ExplicitDocumentStart {
-		s.writeLine("---")
+	if !s.writeSource(n) {
+		if err := s.serializeTree(n); err != nil {
+			return err
+		}
+	}
+
+	// Flush buffer to writer in the chosen encoding
+	_, err := s.writer.Write(charset.Encode(s.buffer.String(), s.options.Encoding, s.options.WriteBOM))
+	return err
+}
+
+// serializeTree formats n with the document markers the options ask for
+func (s *Serializer) serializeTree(n node.Node) error {
+	_, isDocument := n.(*node.DocumentNode)
+	if !isDocument {
+		var directives []node.Directive
+		if s.options.EmitTags {
+			directives = s.declareTags(n, nil)
+		}
+		s.writeDirectives(directives)
+		if s.options.ExplicitDocumentStart || len(directives) > 0 {
+			s.writeLine("---")
+		}
 	}
 
 	if err := s.serializeNode(n, 0); err != nil {
 		return err
 	}
 
-	if s.options.ExplicitDocumentEnd {
+	if s.options.ExplicitDocumentEnd && !isDocument {
 		s.writeLine("")
 		s.writeLine("...")
 	}
-
-	// Flush buffer to writer
-	_, err := s.writer.Write([]byte(s.buffer.String()))
-	return err
+	return nil
 }
 
 // serializeNode serializes any node based on its type
@@ -105,6 +135,19 @@ func (s *Serializer) serializeNodeWithComments(n node.Node, indent int, emitComm
 		s.emitComments(n, node.CommentPositionAbove, indent)
 	}
 
+	// Anchor and tag, unless the parent already wrote them on its own line
+	if props := s.properties(n); props != "" && s.propsDone != n {
+		if s.isComplexNode(n) {
+			s.writeIndent(indent)
+			s.writeLine(props)
+		} else {
+			if s.column == 0 && indent > 0 {
+				s.writeIndent(indent)
+			}
+			s.write(props + " ")
+		}
+	}
+
 	switch v := n.(type) {
 	case *node.ScalarNode:
 		err := s.serializeScalar(v, indent)
@@ -124,16 +167,26 @@ func (s *Serializer) serializeNodeWithComments(n node.Node, indent int, emitComm
 			return err
 		}
 
+	case *node.AliasNode:
+		if s.column == 0 && indent > 0 {
+			s.writeIndent(indent)
+		}
+		s.write("*" + v.Name)
+
+	case *node.DocumentNode:
+		err := s.serializeDocument(v)
+		if err != nil {
+			return err
+		}
+
 	default:
 		return fmt.Errorf("unknown node type: %T", n)
 	}
 
-	// Handle inline comments (skip for mappings/sequences as they're handled specially)
+	// Handle inline comments (skip for block mappings/sequences as they're handled specially)
 	if s.options.PreserveComments && emitComments {
-		if _, isMapping := n.(*node.MappingNode); !isMapping {
-			if _, isSequence := n.(*node.SequenceNode); !isSequence {
-				s.emitComments(n, node.CommentPositionInline, indent)
-			}
+		if !s.isComplexNode(n) || n.Type() == node.NodeTypeScalar {
+			s.emitComments(n, node.CommentPositionInline, indent)
 		}
 	}
 
@@ -149,18 +202,23 @@ func (s *Serializer) serializeNodeWithComments(n node.Node, indent int, emitComm
 func (s *Serializer) serializeScalar(n *node.ScalarNode, indent int) error {
 	value := n.Value
 
-	switch n.Style {
-	case node.StyleDoubleQuoted:
-		value = s.doubleQuoteScalar(value)
-	case node.StyleSingleQuoted:
+	// Keep the text of a parsed scalar, such as its quotes and escapes
+	raw, isRaw := s.rawScalar(n)
+	switch {
+	case isRaw:
+		value = raw
+	case n.Style == node.StyleSingleQuoted:
 		value = s.singleQuoteScalar(value)
-	case node.StyleLiteral:
+	case n.Style == node.StyleLiteral && !s.inKey && fitsBlockScalar(value):
 		return s.serializeLiteralScalar(value, indent)
-	case node.StyleFolded:
+	case n.Style == node.StyleFolded && !s.inKey && fitsBlockScalar(value):
 		return s.serializeFoldedScalar(value, indent)
+	case n.Style == node.StyleDoubleQuoted, n.Style == node.StyleLiteral, n.Style == node.StyleFolded:
+		// A block scalar that cannot hold the value falls back to quotes
+		value = s.doubleQuoteScalar(value)
 	default:
 		// Plain scalar - check if quoting needed
-		if s.needsQuoting(value) {
+		if s.needsQuoting(value) && !s.keepsPlain(n) {
 			value = s.doubleQuoteScalar(value)
 		}
 	}
@@ -174,6 +232,14 @@ func (s *Serializer) serializeScalar(n *node.ScalarNode, indent int) error {
 	return nil
 }
 
+// serializeKey serializes a mapping key
+func (s *Serializer) serializeKey(key node.Node, indent int) error {
+	saved := s.inKey
+	s.inKey = true
+	defer func() { s.inKey = saved }()
+	return s.serializeNodeWithComments(key, indent, false)
+}
+
 // serializeSequence serializes a sequence node
 func (s *Serializer) serializeSequence(seq *node.SequenceNode, indent int) error {
 	if len(seq.Items) == 0 {
@@ -181,11 +247,7 @@ func (s *Serializer) serializeSequence(seq *node.SequenceNode, indent int) error
 		return nil
 	}
 
-	// Determine style
-	useFlow := seq.Style == node.StyleFlow ||
-		(s.options.PreferFlowStyle && !s.options.PreferBlockStyle)
-
-	if useFlow {
+	if s.useFlowStyle(seq.Style) {
 		return s.serializeFlowSequence(seq, indent)
 	}
 	return s.serializeBlockSequence(seq, indent)
@@ -194,7 +256,8 @@ func (s *Serializer) serializeSequence(seq *node.SequenceNode, indent int) error
 // serializeBlockSequence serializes a block-style sequence
 func (s *Serializer) serializeBlockSequence(seq *node.SequenceNode, indent int) error {
 	for i, item := range seq.Items {
-		if i > 0 || s.column > 1 {
+		// Block scalars already end their last line
+		if s.column > 0 && (i > 0 || s.column > 1) {
 			s.writeLine("")
 		}
 
@@ -204,6 +267,7 @@ func (s *Serializer) serializeBlockSequence(seq *node.SequenceNode, indent int)
 		// Check if item is complex (needs new line)
 		if s.isComplexNode(item) {
 			s.write("-")
+			s.writeInlineProperties(item)
 			s.writeLine("")
 			err := s.serializeNode(item, indent+s.options.Indent)
 			if err != nil {
@@ -223,6 +287,7 @@ func (s *Serializer) serializeBlockSequence(seq *node.SequenceNode, indent int)
 // serializeFlowSequence serializes a flow-style sequence
 func (s *Serializer) serializeFlowSequence(seq *node.SequenceNode, indent int) error {
 	s.write("[")
+	wasInFlow := s.inFlow
 	s.inFlow = true
 
 	for i, item := range seq.Items {
@@ -236,7 +301,7 @@ func (s *Serializer) serializeFlowSequence(seq *node.SequenceNode, indent int) e
 	}
 
 	s.write("]")
-	s.inFlow = false
+	s.inFlow = wasInFlow
 	return nil
 }
 
@@ -247,11 +312,7 @@ func (s *Serializer) serializeMapping(m *node.MappingNode, indent int) error {
 		return nil
 	}
 
-	// Determine style
-	useFlow := m.Style == node.StyleFlow ||
-		(s.options.PreferFlowStyle && !s.options.PreferBlockStyle)
-
-	if useFlow {
+	if s.useFlowStyle(m.Style) {
 		return s.serializeFlowMapping(m, indent)
 	}
 	return s.serializeBlockMapping(m, indent)
@@ -260,7 +321,8 @@ func (s *Serializer) serializeMapping(m *node.MappingNode, indent int) error {
 // serializeBlockMapping serializes a block-style mapping
 func (s *Serializer) serializeBlockMapping(m *node.MappingNode, indent int) error {
 	for i, pair := range m.Pairs {
-		if i > 0 || s.column > 1 {
+		// Block scalars already end their last line
+		if s.column > 0 && (i > 0 || s.column > 1) {
 			s.writeLine("")
 		}
 
@@ -285,7 +347,7 @@ func (s *Serializer) serializeBlockMapping(m *node.MappingNode, indent int) erro
 		}
 
 		// Don't emit comments for the key node itself - we already handled them
-		err := s.serializeNodeWithComments(pair.Key, indent, false)
+		err := s.serializeKey(pair.Key, indent)
 		if err != nil {
 			return err
 		}
@@ -293,6 +355,7 @@ func (s *Serializer) serializeBlockMapping(m *node.MappingNode, indent int) erro
 		// Check if value is complex (needs new line)
 		if s.isComplexNode(pair.Value) {
 			s.write(":")
+			s.writeInlineProperties(pair.Value)
 
 			// Check if the value (mapping/sequence) has an inline comment
 			hasInlineComment := false
@@ -333,6 +396,7 @@ func (s *Serializer) serializeBlockMapping(m *node.MappingNode, indent int) erro
 // serializeFlowMapping serializes a flow-style mapping
 func (s *Serializer) serializeFlowMapping(m *node.MappingNode, indent int) error {
 	s.write("{")
+	wasInFlow := s.inFlow
 	s.inFlow = true
 
 	for i, pair := range m.Pairs {
@@ -341,7 +405,7 @@ func (s *Serializer) serializeFlowMapping(m *node.MappingNode, indent int) error
 		}
 
 		// Don't emit comments for the key node itself - we already handled them
-		err := s.serializeNodeWithComments(pair.Key, indent, false)
+		err := s.serializeKey(pair.Key, indent)
 		if err != nil {
 			return err
 		}
@@ -355,40 +419,123 @@ func (s *Serializer) serializeFlowMapping(m *node.MappingNode, indent int) error
 	}
 
 	s.write("}")
-	s.inFlow = false
+	s.inFlow = wasInFlow
 	return nil
 }
 
-// serializeLiteralScalar serializes a literal block scalar
-func (s *Serializer) serializeLiteralScalar(value string, indent int) error {
-	s.write("|")
-	s.writeLine("")
+// serializeDocument writes the directives, markers and content of a document
+func (s *Serializer) serializeDocument(doc *node.DocumentNode) error {
+	directives := doc.Directives
+	if s.options.EmitTags {
+		directives = append(slices.Clone(directives), s.declareTags(doc, directives)...)
+	}
+	s.writeDirectives(directives)
+	if doc.ExplicitStart || len(directives) > 0 || s.options.ExplicitDocumentStart {
+		s.writeLine("---")
+	}
 
-	lines := strings.Split(value, "\n")
-	for _, line := range lines {
-		s.writeIndent(indent + s.options.Indent)
-		s.writeLine(line)
+	if err := s.serializeNode(doc.Content, 0); err != nil {
+		return err
+	}
+
+	if doc.ExplicitEnd || s.options.ExplicitDocumentEnd {
+		if s.column > 0 {
+			s.writeLine("")
+		}
+		s.writeLine("...")
+	}
+	return nil
+}
+
+// writeDirectives writes directives, one per line
+func (s *Serializer) writeDirectives(directives []node.Directive) {
+	for _, d := range directives {
+		s.writeLine(strings.Join(append([]string{"%" + d.Name}, d.Parameters...), " "))
 	}
-	// Add trailing newline for block scalars
-	s.writeLine("")
+}
 
+// properties returns the anchor and, when enabled, the tag of n in the
+// form they precede its content
+func (s *Serializer) properties(n node.Node) string {
+	var props []string
+	if anchor := n.Anchor(); anchor != "" {
+		props = append(props, "&"+anchor)
+	}
+	if s.options.EmitTags && n.Tag() != "" {
+		props = append(props, s.tagText(n))
+	}
+	return strings.Join(props, " ")
+}
+
+// writeInlineProperties writes the properties of a block collection after
+// the key or dash that introduces it
+func (s *Serializer) writeInlineProperties(n node.Node) {
+	if props := s.properties(n); props != "" {
+		s.write(" " + props)
+		s.propsDone = n
+	}
+}
+
+// serializeLiteralScalar serializes a literal block scalar
+func (s *Serializer) serializeLiteralScalar(value string, indent int) error {
+	s.write("|")
+	s.writeBlockScalarLines(value, indent+s.options.Indent)
 	return nil
 }
 
 // serializeFoldedScalar serializes a folded block scalar
 func (s *Serializer) serializeFoldedScalar(value string, indent int) error {
 	s.write(">")
-	s.writeLine("")
+	s.writeBlockScalarLines(value, indent+s.options.Indent)
+	return nil
+}
+
+// writeBlockScalarLines writes the chomping indicator and the content
+// lines of a block scalar
+func (s *Serializer) writeBlockScalarLines(value string, indent int) {
+	s.writeLine(chompingIndicator(value))
+	if value == "" {
+		return
+	}
 
-	lines := strings.Split(value, "\n")
+	lines := strings.Split(strings.TrimSuffix(value, "\n"), "\n")
 	for _, line := range lines {
-		s.writeIndent(indent + s.options.Indent)
+		// Keep empty lines free of trailing whitespace
+		if line != "" {
+			s.writeIndent(indent)
+		}
 		s.writeLine(line)
 	}
-	// Add trailing newline for block scalars
-	s.writeLine("")
+}
 
-	return nil
+// fitsBlockScalar reports whether value reads back unchanged from a block
+// scalar. Without an indentation indicator, spaces starting the first line
+// of content would be taken for indentation, and chomping drops trailing
+// lines of whitespace.
+func fitsBlockScalar(value string) bool {
+	lines := strings.Split(strings.TrimRight(value, "\n"), "\n")
+	for _, line := range lines {
+		if line != "" {
+			if strings.HasPrefix(line, " ") {
+				return false
+			}
+			break
+		}
+	}
+	last := lines[len(lines)-1]
+	return last == "" || strings.TrimSpace(last) != ""
+}
+
+// chompingIndicator returns the indicator that keeps the final line breaks
+// of value in a block scalar: - for none, none for one and + for more
+func chompingIndicator(value string) string {
+	switch {
+	case !strings.HasSuffix(value, "\n"):
+		return "-"
+	case strings.HasSuffix(value, "\n\n") || value == "\n":
+		return "+"
+	}
+	return ""
 }
 
 // Helper methods
@@ -471,14 +618,24 @@ func (s *Serializer) singleQuoteScalar(value string) string {
 	return fmt.Sprintf("'%s'", value)
 }
 
+// useFlowStyle determines whether a collection with the given style is written in flow style
+func (s *Serializer) useFlowStyle(style node.Style) bool {
+	return s.inFlow || style == node.StyleFlow ||
+		(s.options.PreferFlowStyle && !s.options.PreferBlockStyle)
+}
+
 func (s *Serializer) isComplexNode(n node.Node) bool {
 	if n == nil {
 		return false
 	}
 
 	switch v := n.(type) {
-	case *node.SequenceNode, *node.MappingNode:
-		return true
+	case *node.SequenceNode:
+		// Flow and empty sequences stay on the same line as their key
+		return len(v.Items) > 0 && !s.useFlowStyle(v.Style)
+	case *node.MappingNode:
+		// Flow and empty mappings stay on the same line as their key
+		return len(v.Pairs) > 0 && !s.useFlowStyle(v.Style)
 	case *node.ScalarNode:
 		// Scalars with comments above them need to be on a new line
 		if s.options.PreserveComments && v.HeadComment != nil && len(v.HeadComment.Comments) > 0 {
@@ -516,6 +673,13 @@ func (s *Serializer) emitComments(n node.Node, position node.CommentPosition, in
 		return
 	}
 
+	// Keep the blank lines that separated a head comment from the previous content
+	if position == node.CommentPositionAbove && s.options.PreserveBlankLines && s.line > 1 {
+		for i := 0; i < commentGroup.BlankLinesBefore; i++ {
+			s.writeLine("")
+		}
+	}
+
 	for _, comment := range commentGroup.Comments {
 		if position == node.CommentPositionInline {
 			// Inline comment - add spacing
@@ -541,7 +705,8 @@ func (s *Serializer) emitComments(n node.Node, position node.CommentPosition, in
 	}
 }
 
-// SerializeToString is a convenience method to serialize to a string
+// SerializeToString is a convenience method to serialize to a string. With
+// an Encoding other than UTF-8 the string holds the encoded bytes.
 func SerializeToString(n node.Node, opts *Options) (string, error) {
 	var buf strings.Builder
 	serializer := NewSerializer(&buf, opts)
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/elioetibr/golang-yaml/pkg/charset"
 	"github.com/elioetibr/golang-yaml/pkg/node"
 )
 
@@ -41,15 +42,47 @@ func TestSerializeScalar(t *testing.T) {
 		{
 			name: "literal_scalar",
 			node: &node.ScalarNode{
-				Value: "hello\nworld",
+				Value: "hello\nworld\n",
 				Style: node.StyleLiteral,
 			},
 			expected: "|\n  hello\n  world\n",
 		},
 		{
-			name: "folded_scalar",
+			name: "literal_scalar_strip",
 			node: &node.ScalarNode{
 				Value: "hello\nworld",
+				Style: node.StyleLiteral,
+			},
+			expected: "|-\n  hello\n  world\n",
+		},
+		{
+			name: "literal_scalar_keep",
+			node: &node.ScalarNode{
+				Value: "hello\nworld\n\n",
+				Style: node.StyleLiteral,
+			},
+			expected: "|+\n  hello\n  world\n\n",
+		},
+		{
+			name: "literal_scalar_leading_space",
+			node: &node.ScalarNode{
+				Value: "  indented\nline",
+				Style: node.StyleLiteral,
+			},
+			expected: `"  indented\nline"`,
+		},
+		{
+			name: "folded_scalar_trailing_space_line",
+			node: &node.ScalarNode{
+				Value: "text\n  ",
+				Style: node.StyleFolded,
+			},
+			expected: `"text\n  "`,
+		},
+		{
+			name: "folded_scalar",
+			node: &node.ScalarNode{
+				Value: "hello\nworld\n",
 				Style: node.StyleFolded,
 			},
 			expected: ">\n  hello\n  world\n",
@@ -76,9 +109,6 @@ func TestSerializeScalar(t *testing.T) {
 				t.Fatalf("Serialize error: %v", err)
 			}
 
-			// Trim trailing newline for comparison
-			result = strings.TrimSuffix(result, "\n")
-
 			if result != tt.expected {
 				t.Errorf("Expected:\n%q\nGot:\n%q", tt.expected, result)
 			}
@@ -450,3 +480,132 @@ func TestRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializeAnchorsAndAliases(t *testing.T) {
+	defaults := &node.MappingNode{
+		BaseNode: node.BaseNode{AnchorValue: "defaults"},
+		Style:    node.StyleBlock,
+		Pairs: []*node.MappingPair{
+			{Key: &node.ScalarNode{Value: "timeout", Style: node.StylePlain}, Value: &node.ScalarNode{Value: "30", Style: node.StylePlain}},
+		},
+	}
+	name := &node.ScalarNode{BaseNode: node.BaseNode{AnchorValue: "name"}, Value: "app", Style: node.StylePlain}
+	items := &node.SequenceNode{
+		Style: node.StyleBlock,
+		Items: []node.Node{
+			&node.MappingNode{
+				BaseNode: node.BaseNode{AnchorValue: "first"},
+				Style:    node.StyleBlock,
+				Pairs: []*node.MappingPair{
+					{Key: &node.ScalarNode{Value: "id", Style: node.StylePlain}, Value: &node.ScalarNode{Value: "1", Style: node.StylePlain}},
+				},
+			},
+			&node.AliasNode{Name: "first"},
+		},
+	}
+
+	root := &node.MappingNode{
+		Style: node.StyleBlock,
+		Pairs: []*node.MappingPair{
+			{Key: &node.ScalarNode{Value: "defaults", Style: node.StylePlain}, Value: defaults},
+			{Key: &node.ScalarNode{Value: "name", Style: node.StylePlain}, Value: name},
+			{Key: &node.ScalarNode{Value: "service", Style: node.StylePlain}, Value: &node.MappingNode{
+				Style: node.StyleBlock,
+				Pairs: []*node.MappingPair{
+					{Key: &node.ScalarNode{Value: "<<", Style: node.StylePlain}, Value: &node.AliasNode{Name: "defaults", Target: defaults}},
+					{Key: &node.ScalarNode{Value: "label", Style: node.StylePlain}, Value: &node.AliasNode{Name: "name", Target: name}},
+				},
+			}},
+			{Key: &node.ScalarNode{Value: "items", Style: node.StylePlain}, Value: items},
+		},
+	}
+
+	result, err := SerializeToString(root, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	expected := "defaults: &defaults\n  timeout: 30\nname: &name app\nservice:\n  <<: *defaults\n  label: *name\nitems:\n  - &first\n    id: 1\n  - *first"
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestSerializeDocument(t *testing.T) {
+	doc := &node.DocumentNode{
+		Directives:  []node.Directive{{Name: "YAML", Parameters: []string{"1.2"}}},
+		ExplicitEnd: true,
+		Content: &node.MappingNode{
+			Style: node.StyleBlock,
+			Pairs: []*node.MappingPair{
+				{Key: &node.ScalarNode{Value: "key", Style: node.StylePlain}, Value: &node.ScalarNode{Value: "value", Style: node.StylePlain}},
+			},
+		},
+	}
+
+	result, err := SerializeToString(doc, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	expected := "%YAML 1.2\n---\nkey: value\n...\n"
+	if result != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, result)
+	}
+
+	// The document option must not add a second marker
+	result, _ = SerializeToString(doc, &Options{Indent: 2, ExplicitDocumentStart: true})
+	if strings.Count(result, "---") != 1 {
+		t.Errorf("Expected a single document marker, got:\n%s", result)
+	}
+}
+
+func TestSerializeTags(t *testing.T) {
+	root := &node.MappingNode{
+		Style: node.StyleBlock,
+		Pairs: []*node.MappingPair{
+			{Key: &node.ScalarNode{Value: "port", Style: node.StylePlain}, Value: &node.ScalarNode{BaseNode: node.BaseNode{TagValue: "!!str"}, Value: "80", Style: node.StylePlain}},
+		},
+	}
+
+	withTags, _ := SerializeToString(root, &Options{Indent: 2, EmitTags: true})
+	if withTags != "port: !!str 80" {
+		t.Errorf("Expected tag to be emitted, got %q", withTags)
+	}
+	withoutTags, _ := SerializeToString(root, DefaultOptions())
+	if withoutTags != "port: 80" {
+		t.Errorf("Expected tag to be omitted by default, got %q", withoutTags)
+	}
+}
+
+func TestSerializeEncoding(t *testing.T) {
+	root := &node.MappingNode{
+		Style: node.StyleBlock,
+		Pairs: []*node.MappingPair{
+			{Key: &node.ScalarNode{Value: "k", Style: node.StylePlain}, Value: &node.ScalarNode{Value: "é", Style: node.StylePlain}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{"UTF-8", &Options{Indent: 2}, "k: é"},
+		{"UTF-8 with BOM", &Options{Indent: 2, WriteBOM: true}, "\uFEFFk: é"},
+		{"UTF-16LE with BOM", &Options{Indent: 2, Encoding: charset.UTF16LE, WriteBOM: true}, "\xFF\xFEk\x00:\x00 \x00\xE9\x00"},
+		{"UTF-32BE", &Options{Indent: 2, Encoding: charset.UTF32BE}, "\x00\x00\x00k\x00\x00\x00:\x00\x00\x00 \x00\x00\x00\xE9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := SerializeToString(root, tt.opts)
+			if err != nil {
+				t.Fatalf("Serialize error: %v", err)
+			}
+			if output != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,539 @@
+package serializer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// splicer rewrites the source of a document parsed in CST mode. Nodes
+// that were not edited keep their text; edited ones are formatted afresh
+// over the text of the node they replace.
+type splicer struct {
+	s     *Serializer
+	text  string
+	edits []edit
+}
+
+// edit replaces the source between two offsets
+type edit struct {
+	start, end int
+	text       string
+}
+
+// context is where a node sits in the source
+type context struct {
+	indent int  // indentation of the enclosing block collection
+	flow   bool // inside a flow collection
+}
+
+// writeSource writes the source n was parsed from with its edited nodes
+// reformatted. It writes nothing and returns false when n has no source or
+// an edit changes more than its own text, such as a scalar becoming a
+// block mapping; n is then formatted as a whole.
+func (s *Serializer) writeSource(n node.Node) bool {
+	root := n
+	if doc, ok := n.(*node.DocumentNode); ok {
+		root = doc.Content
+	}
+	if root == nil {
+		return false
+	}
+	src := node.SourceOf(root)
+	if src == nil || !src.Outer.IsValid() || src.Outer.End.Offset > len(src.Text) {
+		return false
+	}
+
+	sp := &splicer{s: s, text: src.Text}
+	if !sp.node(root, context{}) && !sp.replace(root, root, context{}) {
+		return false
+	}
+
+	out, ok := sp.apply(src.Outer.Start.Offset, src.Outer.End.Offset)
+	if !ok {
+		return false
+	}
+	s.buffer.WriteString(out)
+	return true
+}
+
+// node collects the edits of a node that was parsed, keeping its text
+// where possible. It reports false, with no edits collected, when the node
+// itself has to be reformatted.
+func (sp *splicer) node(n node.Node, ctx context) bool {
+	src := node.SourceOf(n)
+	if src == nil || src.PropertiesEdited(n) {
+		return false
+	}
+	if node.Unedited(n) {
+		return true
+	}
+	if src.ContentEdited(n) {
+		if isCollection(n) {
+			return false
+		}
+		return sp.replace(n, n, ctx)
+	}
+
+	old, cur := src.Children(), node.ChildrenOf(n)
+	inner := sp.childContext(n, ctx)
+	mark := len(sp.edits)
+	ok := true
+	if len(old) == len(cur) {
+		for i, child := range cur {
+			if ok = sp.child(old[i], child, inner); !ok {
+				break
+			}
+		}
+	} else {
+		ok = sp.entries(n, old, cur, inner)
+	}
+	if !ok {
+		sp.edits = sp.edits[:mark]
+	}
+	return ok
+}
+
+// child collects the edits of child, which took the place of old
+func (sp *splicer) child(old, child node.Node, ctx context) bool {
+	switch {
+	case child == nil || old == nil:
+		return child == old
+	case child == old:
+		return sp.node(child, ctx) || sp.replace(old, child, ctx)
+	default:
+		return sp.replace(old, child, ctx)
+	}
+}
+
+// entries collects the edits of a collection that gained or lost entries:
+// items of a sequence, or key and value pairs of a mapping. Entries are
+// matched by their first node; those kept must stay in order. Removed
+// entries are cut from the text and new ones are formatted alone after the
+// entry before them, so the text of the others stays as it was.
+func (sp *splicer) entries(n node.Node, old, cur []node.Node, ctx context) bool {
+	width := 1
+	if _, ok := n.(*node.MappingNode); ok {
+		width = 2
+	}
+	if len(old) == 0 || len(cur) == 0 || len(old)%width != 0 || len(cur)%width != 0 {
+		return false
+	}
+
+	index := make(map[node.Node]int)
+	for i := 0; i < len(old); i += width {
+		if old[i] == nil {
+			return false
+		}
+		index[old[i]] = i / width
+	}
+	kept := make([]bool, len(old)/width)
+	prev := -1 // old entry the next new entry follows
+	for i := 0; i < len(cur); i += width {
+		j, ok := index[cur[i]]
+		if !ok {
+			if !sp.insert(n, cur[i:i+width], old, prev, ctx) {
+				return false
+			}
+			continue
+		}
+		if j <= prev {
+			return false
+		}
+		for k := 0; k < width; k++ {
+			if !sp.child(old[j*width+k], cur[i+k], ctx) {
+				return false
+			}
+		}
+		kept[j], prev = true, j
+	}
+	if prev < 0 {
+		return false
+	}
+
+	for i := 0; i < len(kept); {
+		if kept[i] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < len(kept) && !kept[j+1] {
+			j++
+		}
+		if !sp.remove(old, width, i, j, ctx) {
+			return false
+		}
+		i = j + 1
+	}
+	return true
+}
+
+// remove cuts the old entries first to last from the text: their lines in
+// a block collection, and with one separating comma in a flow collection
+func (sp *splicer) remove(old []node.Node, width, first, last int, ctx context) bool {
+	r := entryRange(old[first*width : (last+1)*width])
+	end := entryRange(old[last*width : (last+1)*width])
+	if !r.IsValid() || !end.IsValid() {
+		return false
+	}
+	start, stop := r.Start.Offset, end.End.Offset
+
+	if ctx.flow {
+		switch {
+		case (last+1)*width < len(old):
+			next := entryRange(old[(last+1)*width : (last+2)*width])
+			if !next.IsValid() {
+				return false
+			}
+			stop = next.Start.Offset
+		case first > 0:
+			before := entryRange(old[(first-1)*width : first*width])
+			if !before.IsValid() {
+				return false
+			}
+			start = before.End.Offset
+		default:
+			return false
+		}
+	} else {
+		var ok bool
+		if start, ok = sp.lineStart(start, width == 1); !ok {
+			return false
+		}
+		if strings.TrimRight(sp.restOfLine(stop), " \t\r") != "" {
+			return false
+		}
+		stop += len(sp.restOfLine(stop))
+		if stop < len(sp.text) {
+			stop++ // the line break
+		} else if start > 0 {
+			start-- // the line break before the last line
+		}
+	}
+	sp.edits = append(sp.edits, edit{start: start, end: stop})
+	return true
+}
+
+// insert formats a new entry of the collection n and writes it after the
+// old entry prev, or before the first one when prev is negative. In a block
+// collection it goes on its own line, before the line of the old entry
+// that follows so that a block scalar keeps its trailing lines.
+func (sp *splicer) insert(n node.Node, entry, old []node.Node, prev int, ctx context) bool {
+	width := len(entry)
+	style := node.StyleBlock
+	if ctx.flow {
+		style = node.StyleFlow
+	}
+	var tmp node.Node
+	if width == 2 {
+		tmp = &node.MappingNode{Style: style, Pairs: []*node.MappingPair{{Key: entry[0], Value: entry[1]}}}
+	} else {
+		tmp = &node.SequenceNode{Style: style, Items: []node.Node{entry[0]}}
+	}
+
+	opts := *sp.s.options
+	opts.EmitTags = true
+	f := &Serializer{options: &opts, line: 1, inFlow: ctx.flow, propsDone: tmp, inSource: true}
+	if err := f.serializeNodeWithComments(tmp, ctx.indent, false); err != nil {
+		return false
+	}
+	out := f.buffer.String()
+
+	next := (prev + 1) * width
+	var at int
+	if ctx.flow && prev >= 0 || next >= len(old) {
+		r := entryRange(old[prev*width : next])
+		if !r.IsValid() {
+			return false
+		}
+		at = r.End.Offset
+	} else {
+		r := entryRange(old[next : next+width])
+		if !r.IsValid() {
+			return false
+		}
+		at = r.Start.Offset
+	}
+
+	if ctx.flow {
+		// Drop the brackets around the entry
+		if len(out) < 2 || strings.Contains(out, "\n") {
+			return false
+		}
+		out = out[1 : len(out)-1]
+		if prev >= 0 {
+			out = ", " + out
+		} else {
+			out += ", "
+		}
+	} else {
+		indent := strings.Repeat(" ", ctx.indent)
+		if !strings.HasPrefix(out, indent) {
+			return false
+		}
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		if next >= len(old) {
+			// After the last entry, which must not end in a block scalar
+			// whose trailing lines the entry would take
+			if endsInBlockScalar(old[len(old)-1]) || strings.TrimRight(sp.restOfLine(at), " \t\r") != "" {
+				return false
+			}
+			at += len(sp.restOfLine(at))
+			if at < len(sp.text) {
+				at++
+			} else {
+				out = "\n" + strings.TrimSuffix(out, "\n")
+			}
+		} else {
+			var ok bool
+			if at, ok = sp.lineStart(at, width == 1); !ok {
+				return false
+			}
+		}
+	}
+	sp.edits = append(sp.edits, edit{start: at, end: at, text: out})
+	return true
+}
+
+// lineStart returns the start of the line of offset when only indentation,
+// or for a sequence item its dash, comes before offset on that line
+func (sp *splicer) lineStart(offset int, item bool) (int, bool) {
+	start := strings.LastIndexByte(sp.text[:offset], '\n') + 1
+	before := strings.TrimSpace(sp.text[start:offset])
+	return start, before == "" || (item && before == "-")
+}
+
+// endsInBlockScalar reports whether the text of n ends with a literal or
+// folded scalar
+func endsInBlockScalar(n node.Node) bool {
+	for n != nil {
+		if s, ok := n.(*node.ScalarNode); ok {
+			return s.Style == node.StyleLiteral || s.Style == node.StyleFolded
+		}
+		children := node.ChildrenOf(n)
+		if len(children) == 0 {
+			return false
+		}
+		n = children[len(children)-1]
+	}
+	return false
+}
+
+// entryRange returns the range of the nodes of an entry with their
+// comments as they were parsed
+func entryRange(entry []node.Node) node.Range {
+	var r node.Range
+	for _, n := range entry {
+		if n == nil {
+			continue
+		}
+		nr := node.RangeOf(n)
+		if b, ok := n.(interface{ GetBase() *node.BaseNode }); ok {
+			base := b.GetBase()
+			for _, cg := range []*node.CommentGroup{base.HeadComment, base.LineComment, base.FootComment} {
+				if cg != nil {
+					nr = nr.Union(cg.Range)
+				}
+			}
+		}
+		r = r.Union(descendantComments(n, nr))
+	}
+	return r
+}
+
+// replace formats n over the text of old, which must have had the same
+// properties and comments since those stay in the text around it. Block
+// collections only replace block collections.
+func (sp *splicer) replace(old, n node.Node, ctx context) bool {
+	src := node.SourceOf(old)
+	if src == nil || src.PropertiesEdited(n) {
+		return false
+	}
+	r := descendantComments(old, node.RangeOf(old))
+	if !r.IsValid() || r.Start.Offset > r.End.Offset || r.End.Offset > len(sp.text) {
+		return false
+	}
+	oldBlock := isCollection(old) && !sp.isFlowSource(old)
+	block := !ctx.flow && sp.s.isComplexNode(n) && isCollection(n)
+	if block != oldBlock {
+		return false
+	}
+
+	// Tags in the source stay written
+	opts := *sp.s.options
+	opts.EmitTags = true
+	f := &Serializer{options: &opts, line: 1, inFlow: ctx.flow, propsDone: n, inSource: true}
+	indent := ctx.indent
+	if block {
+		indent = node.RangeOf(old).Start.Column - 1
+	} else {
+		f.column = r.Start.Column - 1
+	}
+	if err := f.serializeNodeWithComments(n, indent, false); err != nil {
+		return false
+	}
+	out := f.buffer.String()
+
+	if block {
+		// The first line starts at the column of the region
+		prefix := strings.Repeat(" ", r.Start.Column-1)
+		if !strings.HasPrefix(out, prefix) {
+			return false
+		}
+		out = out[len(prefix):]
+	}
+	if strings.Contains(out, "\n") {
+		// Nothing may follow multi-line text on its last line
+		if ctx.flow || strings.TrimRight(sp.restOfLine(r.End.Offset), " \t\r") != "" {
+			return false
+		}
+		if !strings.HasSuffix(r.Text(sp.text), "\n") {
+			out = strings.TrimSuffix(out, "\n")
+		}
+	}
+	if r.Start.Offset == r.End.Offset && r.Start.Offset > 0 && !isSpace(sp.text[r.Start.Offset-1]) {
+		// An omitted value sits right after its indicator
+		out = " " + out
+	}
+
+	sp.edits = append(sp.edits, edit{start: r.Start.Offset, end: r.End.Offset, text: out})
+	return true
+}
+
+// childContext returns the context of the children of n
+func (sp *splicer) childContext(n node.Node, ctx context) context {
+	if ctx.flow || sp.isFlowSource(n) {
+		return context{indent: ctx.indent, flow: true}
+	}
+	if isCollection(n) {
+		return context{indent: node.RangeOf(n).Start.Column - 1}
+	}
+	return ctx
+}
+
+// isFlowSource reports whether n was written as a flow collection
+func (sp *splicer) isFlowSource(n node.Node) bool {
+	r := node.RangeOf(n)
+	if !isCollection(n) || !r.IsValid() || r.Start.Offset >= len(sp.text) {
+		return false
+	}
+	c := sp.text[r.Start.Offset]
+	return c == '[' || c == '{'
+}
+
+// restOfLine returns the text from offset to the end of its line
+func (sp *splicer) restOfLine(offset int) string {
+	rest := sp.text[offset:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
+
+// apply returns the text between two offsets with the edits made. It
+// reports false when edits overlap or fall outside the text.
+func (sp *splicer) apply(start, end int) (string, bool) {
+	// Insertions go before an edit starting at the same offset
+	slices.SortStableFunc(sp.edits, func(a, b edit) int {
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		return a.end - b.end
+	})
+
+	var out strings.Builder
+	pos := start
+	for _, e := range sp.edits {
+		if e.start < pos || e.end > end {
+			return "", false
+		}
+		out.WriteString(sp.text[pos:e.start])
+		out.WriteString(e.text)
+		pos = e.end
+	}
+	out.WriteString(sp.text[pos:end])
+	return out.String(), true
+}
+
+// rawScalar returns the source of a scalar whose value and style were not
+// edited, when it fits on one line where it is written
+func (s *Serializer) rawScalar(n *node.ScalarNode) (string, bool) {
+	src := node.SourceOf(n)
+	if src == nil || src.ContentEdited(n) || n.Style == node.StyleLiteral || n.Style == node.StyleFolded {
+		return "", false
+	}
+	raw := n.Range.Text(src.Text)
+	if raw == "" || strings.ContainsAny(raw, "\r\n") {
+		return "", false
+	}
+	if s.inFlow && n.Style == node.StylePlain && strings.ContainsAny(raw, ",[]{}") {
+		return "", false
+	}
+	return raw, true
+}
+
+// keepsPlain reports whether a plain scalar in a tree parsed with its
+// source can be written plain. needsQuoting is stricter, and would quote
+// nginx:1.1 after an edit of nginx:1.0.
+func (s *Serializer) keepsPlain(n *node.ScalarNode) bool {
+	value := n.Value
+	if !s.inSource && node.SourceOf(n) == nil {
+		return false
+	}
+	if value == "" || value != strings.TrimSpace(value) {
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(value[0])) {
+		return false
+	}
+	if strings.Contains(value, ": ") || strings.Contains(value, " #") || strings.HasSuffix(value, ":") || strings.ContainsAny(value, "\r\n") {
+		return false
+	}
+	if s.inFlow && strings.ContainsAny(value, ",[]{}") {
+		return false
+	}
+	switch strings.ToLower(value) {
+	case "yes", "no", "on", "off":
+		return false
+	}
+	return true
+}
+
+// descendantComments extends r over the comments of the nodes below n as
+// they were parsed
+func descendantComments(n node.Node, r node.Range) node.Range {
+	children := node.ChildrenOf(n)
+	if src := node.SourceOf(n); src != nil {
+		children = src.Children()
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if b, ok := child.(interface{ GetBase() *node.BaseNode }); ok {
+			base := b.GetBase()
+			for _, cg := range []*node.CommentGroup{base.HeadComment, base.LineComment, base.FootComment} {
+				if cg != nil {
+					r = r.Union(cg.Range)
+				}
+			}
+		}
+		r = descendantComments(child, r)
+	}
+	return r
+}
+
+func isCollection(n node.Node) bool {
+	switch n.(type) {
+	case *node.SequenceNode, *node.MappingNode:
+		return true
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,254 @@
+package serializer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+// parseSource parses input in CST mode
+func parseSource(t *testing.T, input string) node.Node {
+	t.Helper()
+	opts := parser.DefaultOptions()
+	opts.KeepSource = true
+	root, err := parser.ParseStringWithOptions(input, opts)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	return root
+}
+
+func TestSerializeSourceUnedited(t *testing.T) {
+	inputs := []string{
+		"key:   value   # comment  \nother: 'quoted' \n",
+		"# head\n\nlist:\n- a\n-   b\n\n# foot\n",
+		"flow: [ 1,2 ,  {a: b} ]\nempty: {}\n",
+		"%YAML 1.2\n---\ntext: |+\n  kept\n\nfolded: >-\n  one\n  two\n...\n",
+		"base: &b {x: 1}\nuse: *b\nmerged:\n  <<: *b\n  y: 2\n",
+		"escaped: \"a\\tb\\u00e9\"\nplain: one line\n",
+	}
+
+	for _, input := range inputs {
+		root := parseSource(t, input)
+		got, err := SerializeToString(root, nil)
+		if err != nil {
+			t.Fatalf("Serialize error: %v", err)
+		}
+		if got != input {
+			t.Errorf("Expected the input back:\n%q\ngot:\n%q", input, got)
+		}
+	}
+}
+
+func TestSerializeSourceEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		edit     func(t *testing.T, root node.Node)
+		expected string
+	}{
+		{
+			name:  "image tag bump",
+			input: "spec:\n  containers:\n    - name:   app\n      image: nginx:1.0   # pinned  \n      ports: [80,  443]\n",
+			edit: func(t *testing.T, root node.Node) {
+				set(t, root, "spec.containers[0].image", "nginx:1.1")
+			},
+			expected: "spec:\n  containers:\n    - name:   app\n      image: nginx:1.1   # pinned  \n      ports: [80,  443]\n",
+		},
+		{
+			name:  "flow item",
+			input: "ports: [80,  443]  # open\nname: x\n",
+			edit: func(t *testing.T, root node.Node) {
+				set(t, root, "ports[1]", "8443")
+			},
+			expected: "ports: [80,  8443]  # open\nname: x\n",
+		},
+		{
+			name:  "quoted scalar keeps its style",
+			input: "a:   'old'  \nb: \"x\"\n",
+			edit: func(t *testing.T, root node.Node) {
+				set(t, root, "a", "it's new")
+			},
+			expected: "a:   'it''s new'  \nb: \"x\"\n",
+		},
+		{
+			name:  "literal scalar",
+			input: "script: |\n  echo one\n\nnext:  1\n",
+			edit: func(t *testing.T, root node.Node) {
+				set(t, root, "script", "echo two\necho three\n")
+			},
+			expected: "script: |\n  echo two\n  echo three\n\nnext:  1\n",
+		},
+		{
+			name:  "walk replacement",
+			input: "env:\n  - {name: A,   value: x}\n  - {name: B,   value: y}\n",
+			edit: func(t *testing.T, root node.Node) {
+				node.Walk(root, func(c *node.Cursor) bool {
+					if s, ok := c.Node().(*node.ScalarNode); ok && s.Value == "y" {
+						c.Replace(&node.ScalarNode{Value: "z", Style: node.StyleDoubleQuoted})
+					}
+					return true
+				}, nil)
+			},
+			expected: "env:\n  - {name: A,   value: x}\n  - {name: B,   value: \"z\"}\n",
+		},
+		{
+			name:  "added key keeps its siblings",
+			input: "top:    1  # kept\nnested:\n  a:   1\n  b: 2   # b\nlast:  3\n",
+			edit: func(t *testing.T, root node.Node) {
+				set(t, root, "nested.c", "3")
+			},
+			expected: "top:    1  # kept\nnested:\n  a:   1\n  b: 2   # b\n  c: 3\nlast:  3\n",
+		},
+		{
+			name:  "added item in a sequence of mappings",
+			input: "- name: a\n  image: x:1\n- name:   b\n",
+			edit: func(t *testing.T, root node.Node) {
+				set(t, root, "[0].tag", "v2")
+			},
+			expected: "- name: a\n  image: x:1\n  tag: v2\n- name:   b\n",
+		},
+		{
+			name:  "tag kept on a reformatted node",
+			input: "a: !!str 1\nb:\n",
+			edit: func(t *testing.T, root node.Node) {
+				m := root.(*node.MappingNode)
+				m.Pairs[1].Value = &node.ScalarNode{Value: "x", Style: node.StylePlain}
+				m.Pairs = append(m.Pairs, &node.MappingPair{
+					Key:   &node.ScalarNode{Value: "c", Style: node.StylePlain},
+					Value: &node.ScalarNode{Value: "y", Style: node.StylePlain},
+				})
+			},
+			expected: "a: !!str 1\nb: x\nc: y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := parseSource(t, tt.input)
+			tt.edit(t, root)
+			got, err := SerializeToString(root, nil)
+			if err != nil {
+				t.Fatalf("Serialize error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestSerializeSourceEntries checks that entries added to or removed from
+// a collection leave the text of the other entries byte for byte
+func TestSerializeSourceEntries(t *testing.T) {
+	input := "# images\nimage:   \"nginx:1.0\"   # pinned\nflow: {x: 1,  y: 2}\nlist:\n-   b\n-   c\nscript: |+\n  run\n\nlast:  3\n"
+	tests := []struct {
+		name     string
+		edit     func(root node.Node) error
+		expected string
+	}{
+		{
+			name:     "key at the end",
+			edit:     func(root node.Node) error { return node.Set(root, "tag", "v2") },
+			expected: input + "tag: v2\n",
+		},
+		{
+			name:     "key after another",
+			edit:     func(root node.Node) error { return node.InsertAfter(root, "image", "tag", "v2") },
+			expected: strings.Replace(input, "# pinned\n", "# pinned\ntag: v2\n", 1),
+		},
+		{
+			name:     "key after a block scalar",
+			edit:     func(root node.Node) error { return node.InsertAfter(root, "script", "tag", "v2") },
+			expected: strings.Replace(input, "last:", "tag: v2\nlast:", 1),
+		},
+		{
+			name:     "removed key",
+			edit:     func(root node.Node) error { return node.Delete(root, "flow") },
+			expected: strings.Replace(input, "flow: {x: 1,  y: 2}\n", "", 1),
+		},
+		{
+			name:     "removed last key",
+			edit:     func(root node.Node) error { return node.Delete(root, "last") },
+			expected: strings.TrimSuffix(input, "last:  3\n"),
+		},
+		{
+			name:     "flow key",
+			edit:     func(root node.Node) error { return node.Set(root, "flow.z", 3) },
+			expected: strings.Replace(input, "{x: 1,  y: 2}", "{x: 1,  y: 2, z: 3}", 1),
+		},
+		{
+			name:     "removed flow key",
+			edit:     func(root node.Node) error { return node.Delete(root, "flow.x") },
+			expected: strings.Replace(input, "{x: 1,  y: 2}", "{y: 2}", 1),
+		},
+		{
+			name:     "removed last flow key",
+			edit:     func(root node.Node) error { return node.Delete(root, "flow.y") },
+			expected: strings.Replace(input, "{x: 1,  y: 2}", "{x: 1}", 1),
+		},
+		{
+			name:     "item",
+			edit:     func(root node.Node) error { return node.Append(root, "list", "d") },
+			expected: strings.Replace(input, "-   c\n", "-   c\n- d\n", 1),
+		},
+		{
+			name:     "removed item",
+			edit:     func(root node.Node) error { return node.Delete(root, "list[0]") },
+			expected: strings.Replace(input, "-   b\n", "", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := parseSource(t, input)
+			if err := tt.edit(root); err != nil {
+				t.Fatalf("Edit error: %v", err)
+			}
+			got, err := SerializeToString(root, nil)
+			if err != nil {
+				t.Fatalf("Serialize error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestSerializeSourceStream checks that the documents of a stream serialize
+// to the stream's text, each one with its own edits
+func TestSerializeSourceStream(t *testing.T) {
+	input := "# first\nversion: 1\n---   # second\nversion: 2\n...\n--- !!map\nversion: 3\n"
+	opts := parser.DefaultOptions()
+	opts.KeepSource = true
+	stream, err := parser.ParseStreamWithOptions(input, opts)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if err := node.Set(stream.Documents[1].Root, "version", 20); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+
+	var sb strings.Builder
+	for _, doc := range stream.Documents {
+		out, err := SerializeToString(doc.Node(), nil)
+		if err != nil {
+			t.Fatalf("Serialize error: %v", err)
+		}
+		sb.WriteString(out)
+	}
+	expected := strings.Replace(input, "version: 2", "version: 20", 1)
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, sb.String())
+	}
+}
+
+func set(t *testing.T, root node.Node, path string, value interface{}) {
+	t.Helper()
+	if err := node.Set(root, path, value); err != nil {
+		t.Fatalf("Set %s error: %v", path, err)
+	}
+}
@@ -0,0 +1,114 @@
+package serializer
+
+import (
+	"strings"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+)
+
+// yamlTagPrefix is the prefix the secondary handle !! stands for unless a
+// %TAG directive says otherwise
+const yamlTagPrefix = "tag:yaml.org,2002:"
+
+// declareTags sets the %TAG handles the tags below n are written with:
+// those of directives, then the handles of the shorthands the parser kept
+// for resolved tags. It returns the directives to write for the handles
+// that no directive declares; the first prefix found for a handle wins.
+func (s *Serializer) declareTags(n node.Node, directives []node.Directive) []node.Directive {
+	s.tagHandles = make(map[string]string)
+	for _, d := range directives {
+		if d.Name == "TAG" && len(d.Parameters) >= 2 {
+			s.tagHandles[d.Parameters[0]] = d.Parameters[1]
+		}
+	}
+
+	var added []node.Directive
+	node.Walk(n, func(c *node.Cursor) bool {
+		tag, short := c.Node().Tag(), tagShorthand(c.Node())
+		handle, suffix := node.SplitTag(short)
+		if handle == "" || strings.HasPrefix(tag, "!") || !strings.HasSuffix(tag, suffix) {
+			return true
+		}
+		prefix := tag[:len(tag)-len(suffix)]
+		if _, ok := s.tagHandles[handle]; ok || prefix == "" {
+			return true
+		}
+		if (handle == "!!" && prefix == yamlTagPrefix) || (handle == "!" && prefix == "!") {
+			return true
+		}
+		s.tagHandles[handle] = prefix
+		added = append(added, node.Directive{Name: "TAG", Parameters: []string{handle, prefix}})
+		return true
+	}, nil)
+	return added
+}
+
+// tagText returns the tag of n as it is written. A full tag is written as
+// the shorthand it was parsed from while its handle still means the same,
+// with another handle that shortens it, or in the verbatim !<...> form.
+func (s *Serializer) tagText(n node.Node) string {
+	tag := n.Tag()
+	if strings.HasPrefix(tag, "!") {
+		// Written as parsed, under the handles of its document
+		return tag
+	}
+
+	if short := tagShorthand(n); short != "" {
+		handle, suffix := node.SplitTag(short)
+		prefix, ok := s.tagPrefix(handle)
+		if ok && prefix+suffix == tag {
+			return short
+		}
+		// Without declared handles, as in an edit of a parsed source,
+		// the handles of the document are unknown
+		if !ok && s.tagHandles == nil && handle != "" && strings.HasSuffix(tag, suffix) {
+			return short
+		}
+	}
+
+	best, bestPrefix := "", ""
+	consider := func(handle, prefix string) {
+		suffix, ok := strings.CutPrefix(tag, prefix)
+		if !ok || !isTagSuffix(suffix) {
+			return
+		}
+		if len(prefix) > len(bestPrefix) || (len(prefix) == len(bestPrefix) && handle < best) {
+			best, bestPrefix = handle, prefix
+		}
+	}
+	for handle, prefix := range s.tagHandles {
+		consider(handle, prefix)
+	}
+	if _, ok := s.tagHandles["!!"]; !ok {
+		consider("!!", yamlTagPrefix)
+	}
+	if best != "" {
+		return best + tag[len(bestPrefix):]
+	}
+	return "!<" + tag + ">"
+}
+
+// tagPrefix returns the prefix a handle stands for
+func (s *Serializer) tagPrefix(handle string) (string, bool) {
+	if prefix, ok := s.tagHandles[handle]; ok {
+		return prefix, true
+	}
+	if handle == "!!" {
+		return yamlTagPrefix, true
+	}
+	return "", false
+}
+
+// tagShorthand returns the tag n was written with, when the parser
+// resolved it
+func tagShorthand(n node.Node) string {
+	if b, ok := n.(interface{ GetBase() *node.BaseNode }); ok {
+		return b.GetBase().TagShorthand
+	}
+	return ""
+}
+
+// isTagSuffix reports whether suffix can follow a handle in a shorthand
+func isTagSuffix(suffix string) bool {
+	return suffix != "" && !strings.ContainsAny(suffix, " \t\r\n!,[]{}<>")
+}
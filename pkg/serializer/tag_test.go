@@ -0,0 +1,98 @@
+package serializer
+
+import (
+	"testing"
+
+	"github.com/elioetibr/golang-yaml/pkg/node"
+	"github.com/elioetibr/golang-yaml/pkg/parser"
+)
+
+func TestSerializeFullTags(t *testing.T) {
+	tagged := func(tag, shorthand string) *node.ScalarNode {
+		return &node.ScalarNode{BaseNode: node.BaseNode{TagValue: tag, TagShorthand: shorthand}, Value: "v", Style: node.StylePlain}
+	}
+	mapping := func(values ...node.Node) *node.MappingNode {
+		m := &node.MappingNode{Style: node.StyleBlock}
+		for i, v := range values {
+			m.Pairs = append(m.Pairs, &node.MappingPair{Key: &node.ScalarNode{Value: string(rune('a' + i)), Style: node.StylePlain}, Value: v})
+		}
+		return m
+	}
+	opts := &Options{Indent: 2, EmitTags: true}
+
+	tests := []struct {
+		name     string
+		root     node.Node
+		expected string
+	}{
+		{
+			name:     "core schema tag",
+			root:     mapping(tagged("tag:yaml.org,2002:int", "")),
+			expected: "a: !!int v",
+		},
+		{
+			name:     "unknown prefix",
+			root:     mapping(tagged("tag:example.com,2024:foo", "")),
+			expected: "a: !<tag:example.com,2024:foo> v",
+		},
+		{
+			name:     "shorthand declared",
+			root:     mapping(tagged("tag:example.com,2024:foo", "!e!foo"), tagged("tag:example.com,2024:bar", ""), tagged("tag:local.org,1:x", "!x")),
+			expected: "%TAG !e! tag:example.com,2024:\n%TAG ! tag:local.org,1:\n---\na: !e!foo v\nb: !e!bar v\nc: !x v",
+		},
+		{
+			name: "directive of the document",
+			root: &node.DocumentNode{
+				Directives: []node.Directive{{Name: "TAG", Parameters: []string{"!e!", "tag:other.org,1:"}}},
+				Content:    mapping(tagged("tag:example.com,2024:foo", "!e!foo"), tagged("tag:other.org,1:bar", "")),
+			},
+			expected: "%TAG !e! tag:other.org,1:\n---\na: !<tag:example.com,2024:foo> v\nb: !e!bar v",
+		},
+		{
+			name:     "local tag",
+			root:     mapping(tagged("!local", "")),
+			expected: "a: !local v",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SerializeToString(tt.root, opts)
+			if err != nil {
+				t.Fatalf("Serialize error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestSerializeTagDirectivesRoundTrip checks that tags resolved through
+// %TAG handles are written back with their handles declared
+func TestSerializeTagDirectivesRoundTrip(t *testing.T) {
+	input := "%TAG !e! tag:example.com,2024:\n---\nkind: !e!Service x\nport: !!int 80\nraw: !<tag:x.org,1:y> 1\nlist: [!e!a 1]"
+	popts := &parser.Options{ResolveTags: true}
+	stream, err := parser.ParseStreamWithOptions(input, popts)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	for _, n := range []node.Node{stream.Documents[0].Node(), stream.Documents[0].Root} {
+		got, err := SerializeToString(n, &Options{Indent: 2, EmitTags: true})
+		if err != nil {
+			t.Fatalf("Serialize error: %v", err)
+		}
+		if got != input {
+			t.Errorf("Expected:\n%q\ngot:\n%q", input, got)
+		}
+
+		back, err := parser.ParseStringWithOptions(got, popts)
+		if err != nil {
+			t.Fatalf("Reparse error: %v", err)
+		}
+		if !node.Equal(stream.Documents[0].Root, back, nil) {
+			t.Error("Expected the same tree after a round trip")
+		}
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/elioetibr/golang-yaml/pkg/charset"
 	"github.com/elioetibr/golang-yaml/pkg/decoder"
 	"github.com/elioetibr/golang-yaml/pkg/encoder"
+	"github.com/elioetibr/golang-yaml/pkg/lexer"
 	"github.com/elioetibr/golang-yaml/pkg/parser"
+	"github.com/elioetibr/golang-yaml/pkg/serializer"
 )
 
 // FuzzParser tests the parser with random inputs
@@ -46,6 +49,83 @@ func FuzzParser(f *testing.F) {
 	})
 }
 
+// FuzzTokenizeLossless checks that lossless tokens concatenate back to
+// their input
+func FuzzTokenizeLossless(f *testing.F) {
+	seeds := []string{
+		"key: value # comment\n",
+		"- item1\n\n  - item2\r\n",
+		"{a: 1, b: [2, 3]}",
+		"---\nkey: |\n  literal\n\n...\n",
+		"key: 'it''s'   \t\n",
+		"-",
+		"|",
+		"\"\\",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		// UTF-16 and UTF-32 input is tokenized after transcoding
+		if enc, _ := charset.Detect([]byte(input)); enc != charset.UTF8 {
+			return
+		}
+		tokens, err := lexer.TokenizeWithOptions(input, &lexer.TokenizeOptions{Lossless: true})
+		if err != nil {
+			return
+		}
+		var sb strings.Builder
+		for _, token := range tokens {
+			sb.WriteString(token.Text)
+		}
+		if sb.String() != input {
+			t.Errorf("Tokens of %q concatenate to %q", input, sb.String())
+		}
+	})
+}
+
+// FuzzSourceRoundTrip checks that documents parsed in CST mode serialize
+// back to their input when nothing was edited
+func FuzzSourceRoundTrip(f *testing.F) {
+	seeds := []string{
+		"key:   value # comment  \n",
+		"- a\n-   {b: 1,  c: [2]}\n",
+		"%YAML 1.2\n---\nkey: |\n  literal\n\n...\n# next\n--- !!map\nother: 'x'\n",
+		"base: &b {x: 1}\nuse: *b\nm:\n  <<: *b\n",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	opts := parser.DefaultOptions()
+	opts.KeepSource = true
+	f.Fuzz(func(t *testing.T, input string) {
+		if enc, _ := charset.Detect([]byte(input)); enc != charset.UTF8 {
+			return
+		}
+		// Text outside documents with content is not kept
+		stream, err := parser.ParseStreamWithOptions(input, opts)
+		if err != nil || len(stream.Documents) == 0 {
+			return
+		}
+		var sb strings.Builder
+		for _, doc := range stream.Documents {
+			if doc.Root == nil {
+				return
+			}
+			out, err := serializer.SerializeToString(doc.Root, nil)
+			if err != nil {
+				t.Fatalf("Serialize error on %q: %v", input, err)
+			}
+			sb.WriteString(out)
+		}
+		if want := strings.TrimPrefix(input, "\uFEFF"); sb.String() != want {
+			t.Errorf("Documents of %q serialize to %q", want, sb.String())
+		}
+	})
+}
+
 // FuzzMarshalUnmarshal tests marshal/unmarshal with random data
 func FuzzMarshalUnmarshal(f *testing.F) {
 	// Add seed values-with-comments